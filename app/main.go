@@ -33,6 +33,12 @@ func main() {
 		commands.GetHashObjects(os.Args...)
 	case "add":
 		commands.Add(os.Args...)
+	case "check-attr":
+		commands.CheckAttr(os.Args...)
+	case "check-ignore":
+		commands.CheckIgnore(os.Args...)
+	case "ls-files":
+		commands.LsFiles(os.Args...)
 	case "status":
 		commands.Status(os.Args...)
 	case "write-tree":
@@ -43,6 +49,40 @@ func main() {
 		commands.Diff(os.Args...)
 	case "push":
 		commands.Push(os.Args...)
+	case "pack-refs":
+		commands.PackRefs(os.Args...)
+	case "branch":
+		commands.Branch(os.Args...)
+	case "checkout":
+		commands.Checkout(os.Args...)
+	case "switch":
+		commands.Switch(os.Args...)
+	case "restore":
+		commands.Restore(os.Args...)
+	case "reset":
+		commands.Reset(os.Args...)
+	case "log":
+		commands.Log(os.Args...)
+	case "show":
+		commands.Show(os.Args...)
+	case "merge":
+		commands.Merge(os.Args...)
+	case "merge-base":
+		commands.MergeBase(os.Args...)
+	case "merge-tree":
+		commands.MergeTree(os.Args...)
+	case "merge-file":
+		commands.MergeFile(os.Args...)
+	case "cherry-pick":
+		commands.CherryPick(os.Args...)
+	case "revert":
+		commands.Revert(os.Args...)
+	case "rebase":
+		commands.Rebase(os.Args...)
+	case "stash":
+		commands.Stash(os.Args...)
+	case "config":
+		commands.Config(os.Args...)
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command %s\n", command)
 		os.Exit(1)
@@ -5,36 +5,44 @@ import (
 	"log/slog"
 	"maps"
 	"os"
+	"path/filepath"
+	"slices"
 
+	"github.com/codecrafters-io/git-starter-go/pkg/config"
 	"github.com/codecrafters-io/git-starter-go/pkg/types"
 	"github.com/codecrafters-io/git-starter-go/pkg/utils"
 )
 
 func Add(args ...string) {
 	if len(args) < 3 {
-		fmt.Fprintf(os.Stderr, "usage: ccgit add [<file>...]\n")
+		fmt.Fprintf(os.Stderr, "usage: ccgit add [-f | --force] [<file>...]\n")
 		os.Exit(1)
 	}
 
-	ignoreFile, _ := os.ReadFile(".gitignore")
-	var ignoreNames []string
-	i := 0
-	for i < len(ignoreFile) {
-		startPath := i
-
-		for i < len(ignoreFile) && ignoreFile[i] != '\n' {
-			i++
+	force := false
+	var pathArgs []string
+	for i := 2; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "-f" || arg == "--force":
+			force = true
+		case arg == "--":
+			pathArgs = append(pathArgs, args[i+1:]...)
+			i = len(args)
+		default:
+			pathArgs = append(pathArgs, arg)
 		}
+	}
 
-		pathComp := string(ignoreFile[startPath:i])
-
-		ignoreNames = append(ignoreNames, pathComp)
-		i++
+	ignore := utils.StandardIgnore()
+	skip := skipIgnored(ignore, ReadIndex().Entries)
+	if force {
+		skip = nil
 	}
 
 	var errorPaths []string
 	var paths []string
-	for _, arg := range args[2:] {
+	var ignoredPaths []string
+	for _, arg := range pathArgs {
 		stat, err := os.Stat(arg)
 		if err != nil {
 			if os.IsNotExist(err) {
@@ -46,8 +54,15 @@ func Add(args ...string) {
 			}
 		}
 
+		arg = filepath.Clean(arg)
+		// a raiz do repositório não passa pelo .gitignore, só o que está abaixo dela
+		if skip != nil && !isWorktreeRoot(arg) && skip(arg, stat.IsDir()) {
+			ignoredPaths = append(ignoredPaths, ignoredRoot(ignore, arg))
+			continue
+		}
+
 		if stat.IsDir() {
-			fileNames, _ := utils.GetDirTree(stat.Name(), ignoreNames, true)
+			fileNames, _ := utils.GetDirTree(arg, skip, true)
 			paths = append(paths, fileNames...)
 		} else {
 			paths = append(paths, arg)
@@ -92,10 +107,49 @@ func Add(args ...string) {
 
 	if len(paths) > 0 {
 		for _, path := range paths {
-			hash, _, content := utils.GetBlobHashObject(path)
+			hash, object, content := worktreeBlob(path, true)
 			slog.Debug(fmt.Sprintf("%s - %x - %+v", path, hash, string(content)))
 
+			// como no git, o blob vai para o repositório junto com a entrada do índice
+			if !objectExists(fmt.Sprintf("%x", hash[:])) {
+				utils.SaveHashedObject(hash, object)
+			}
 			UpdateIndex(path, hash)
 		}
 	}
+
+	if len(ignoredPaths) > 0 {
+		slices.Sort(ignoredPaths)
+		fmt.Fprintf(os.Stderr, "The following paths are ignored by one of your .gitignore files:\n")
+		for _, path := range slices.Compact(ignoredPaths) {
+			fmt.Fprintf(os.Stderr, "%s\n", path)
+		}
+		if config.GetBool("advice.addignoredfile", true) {
+			fmt.Fprintf(os.Stderr, "hint: Use -f if you really want to add them.\n")
+			fmt.Fprintf(os.Stderr, "hint: Turn this message off by running\n")
+			fmt.Fprintf(os.Stderr, "hint: \"git config advice.addIgnoredFile false\"\n")
+		}
+		os.Exit(1)
+	}
+}
+
+// o git aponta o diretório ignorado mais alto, não o arquivo dentro dele
+func ignoredRoot(ignore *utils.Ignore, path string) string {
+	path = filepath.ToSlash(path)
+	for i, c := range path {
+		if c == '/' && ignore.IsIgnored(path[:i], true) {
+			return path[:i]
+		}
+	}
+
+	return path
+}
+
+func isWorktreeRoot(path string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	root, err := filepath.Abs(".")
+	return err == nil && abs == root
 }
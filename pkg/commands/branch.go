@@ -0,0 +1,466 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/config"
+	"github.com/codecrafters-io/git-starter-go/pkg/utils"
+)
+
+type branchInfo struct {
+	Name    string
+	Ref     string
+	Hash    string
+	Current bool
+	Remote  bool
+}
+
+func Branch(args ...string) {
+	err := utils.CheckGitRepo(".", false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	var verbose, all, remotes, noColor, force bool
+	var mode, upstream string
+	var positional []string
+
+	for i := 2; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-v" || arg == "-vv" || arg == "--verbose":
+			verbose = true
+		case arg == "-a" || arg == "--all":
+			all = true
+		case arg == "-r" || arg == "--remotes":
+			remotes = true
+		case arg == "--no-color":
+			noColor = true
+		case arg == "-f" || arg == "--force":
+			force = true
+		case arg == "--list" || arg == "-l":
+			mode = "list"
+		case arg == "-d" || arg == "--delete":
+			mode = "delete"
+		case arg == "-D":
+			mode, force = "delete", true
+		case arg == "-m" || arg == "--move":
+			mode = "move"
+		case arg == "-M":
+			mode, force = "move", true
+		case arg == "--unset-upstream":
+			mode = "unset-upstream"
+		case arg == "-u" || arg == "--set-upstream-to":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "error: option `set-upstream-to' requires a value\n")
+				os.Exit(1)
+			}
+			mode, upstream = "set-upstream", args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--set-upstream-to="):
+			mode, upstream = "set-upstream", strings.TrimPrefix(arg, "--set-upstream-to=")
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			fmt.Fprintf(os.Stderr, "error: unknown option `%s'\n", strings.TrimLeft(arg, "-"))
+			fmt.Fprintf(os.Stderr, "usage: ccgit branch [-v] [-a | -r] [--list [<pattern>...]]\n")
+			fmt.Fprintf(os.Stderr, "   or: ccgit branch [-f] <branchname> [<start-point>]\n")
+			fmt.Fprintf(os.Stderr, "   or: ccgit branch (-d | -D) <branchname>...\n")
+			fmt.Fprintf(os.Stderr, "   or: ccgit branch (-m | -M) [<oldbranch>] <newbranch>\n")
+			fmt.Fprintf(os.Stderr, "   or: ccgit branch (-u <upstream> | --set-upstream-to=<upstream>) [<branchname>]\n")
+			os.Exit(1)
+		default:
+			positional = append(positional, arg)
+		}
+	}
+
+	if mode == "" {
+		mode = "list"
+		if len(positional) > 0 && !verbose && !all && !remotes {
+			mode = "create"
+		}
+	}
+
+	switch mode {
+	case "list":
+		shouldColor := !noColor && utils.IsTerminal()
+		listBranches(positional, verbose, all, remotes, shouldColor)
+	case "create":
+		if len(positional) > 2 {
+			fmt.Fprintf(os.Stderr, "fatal: too many arguments for a create operation\n")
+			os.Exit(1)
+		}
+		startPoint := "HEAD"
+		if len(positional) == 2 {
+			startPoint = positional[1]
+		}
+		createBranch(positional[0], startPoint, force)
+	case "delete":
+		if len(positional) == 0 {
+			fmt.Fprintf(os.Stderr, "fatal: branch name required\n")
+			os.Exit(1)
+		}
+		exitCode := 0
+		for _, name := range positional {
+			if !deleteBranch(name, force, remotes) {
+				exitCode = 1
+			}
+		}
+		os.Exit(exitCode)
+	case "move":
+		switch len(positional) {
+		case 1:
+			current := utils.GetHeadBranch()
+			if current == "" {
+				fmt.Fprintf(os.Stderr, "fatal: cannot rename the current branch while not on any\n")
+				os.Exit(1)
+			}
+			renameBranch(current, positional[0], force)
+		case 2:
+			renameBranch(positional[0], positional[1], force)
+		default:
+			fmt.Fprintf(os.Stderr, "fatal: too many arguments for a rename operation\n")
+			os.Exit(1)
+		}
+	case "set-upstream", "unset-upstream":
+		name := utils.GetHeadBranch()
+		if len(positional) > 0 {
+			name = positional[0]
+		}
+		if name == "" {
+			fmt.Fprintf(os.Stderr, "fatal: could not set upstream of HEAD to %s when it does not point to any branch\n", upstream)
+			os.Exit(1)
+		}
+		if !utils.RefExists("refs/heads/" + name) {
+			fmt.Fprintf(os.Stderr, "fatal: branch '%s' does not exist\n", name)
+			os.Exit(1)
+		}
+
+		if mode == "unset-upstream" {
+			_ = config.Unset(config.LocalPath(), "branch."+name+".remote", nil, true)
+			_ = config.Unset(config.LocalPath(), "branch."+name+".merge", nil, true)
+			return
+		}
+		setBranchUpstream(name, upstream)
+	}
+}
+
+func readBranches(local bool, remote bool) []branchInfo {
+	current := utils.GetHeadBranch()
+
+	var branches []branchInfo
+	for ref, hash := range utils.ReadAllRefs() {
+		switch {
+		case local && strings.HasPrefix(ref, "refs/heads/"):
+			name := strings.TrimPrefix(ref, "refs/heads/")
+			branches = append(branches, branchInfo{Name: name, Ref: ref, Hash: hash, Current: name == current})
+		case remote && strings.HasPrefix(ref, "refs/remotes/"):
+			name := strings.TrimPrefix(ref, "refs/remotes/")
+			branches = append(branches, branchInfo{Name: name, Ref: ref, Hash: hash, Remote: true})
+		}
+	}
+
+	sort.Slice(branches, func(i, j int) bool {
+		if branches[i].Remote != branches[j].Remote {
+			return !branches[i].Remote
+		}
+		return branches[i].Name < branches[j].Name
+	})
+
+	return branches
+}
+
+func listBranches(patterns []string, verbose bool, all bool, remotes bool, color bool) {
+	branches := readBranches(!remotes || all, remotes || all)
+
+	var shown []branchInfo
+	if utils.IsHeadDetached() && !remotes {
+		headHash := string(utils.GetHeadHash())
+		shown = append(shown, branchInfo{
+			Name:    fmt.Sprintf("(HEAD detached at %s)", headHash[:min(7, len(headHash))]),
+			Hash:    headHash,
+			Current: true,
+		})
+	}
+
+	for _, branch := range branches {
+		if branch.Remote && all {
+			branch.Name = "remotes/" + branch.Name
+		}
+
+		if len(patterns) > 0 && !matchesAnyPattern(branch.Name, patterns) {
+			continue
+		}
+		shown = append(shown, branch)
+	}
+
+	width := 0
+	for _, branch := range shown {
+		width = max(width, len(branch.Name))
+	}
+
+	for _, branch := range shown {
+		mark := " "
+		colorCode := ""
+		if branch.Current {
+			mark = "*"
+			if color {
+				colorCode = "\033[32m"
+			}
+		} else if branch.Remote && color {
+			colorCode = "\033[31m"
+		}
+
+		resetCode := ""
+		if colorCode != "" {
+			resetCode = "\033[0m"
+		}
+
+		if !verbose {
+			fmt.Fprintf(os.Stdout, "%s %s%s%s\n", mark, colorCode, branch.Name, resetCode)
+			continue
+		}
+
+		subject := ""
+		if commit, err := ReadCommit(branch.Hash); err == nil {
+			subject = commitSubject(commit)
+		}
+		fmt.Fprintf(os.Stdout, "%s %s%-*s%s %s %s\n", mark, colorCode, width, branch.Name, resetCode, branch.Hash[:7], subject)
+	}
+}
+
+func matchesAnyPattern(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func createBranch(name string, startPoint string, force bool) {
+	if !utils.CheckRefFormat(name) || name == "HEAD" {
+		fmt.Fprintf(os.Stderr, "fatal: '%s' is not a valid branch name\n", name)
+		os.Exit(1)
+	}
+
+	ref := "refs/heads/" + name
+	if utils.RefExists(ref) {
+		if !force {
+			fmt.Fprintf(os.Stderr, "fatal: a branch named '%s' already exists\n", name)
+			os.Exit(1)
+		}
+		if name == utils.GetHeadBranch() {
+			fmt.Fprintf(os.Stderr, "fatal: cannot force update the current branch\n")
+			os.Exit(1)
+		}
+	}
+
+	hash, err := ResolveCommit(startPoint)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: not a valid object name: '%s'\n", startPoint)
+		os.Exit(1)
+	}
+
+	message := fmt.Sprintf("branch: Created from %s", startPoint)
+	if force && utils.RefExists(ref) {
+		message = fmt.Sprintf("branch: Reset to %s", startPoint)
+	}
+
+	if err := utils.UpdateRef(ref, hash, message); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(1)
+	}
+
+	if utils.RefExists("refs/remotes/" + startPoint) {
+		setBranchUpstream(name, startPoint)
+	}
+}
+
+func deleteBranch(name string, force bool, remote bool) bool {
+	ref := "refs/heads/" + name
+	if remote {
+		ref = "refs/remotes/" + name
+	}
+
+	hash, err := utils.ResolveRef(ref)
+	if err != nil {
+		kind := "branch"
+		if remote {
+			kind = "remote-tracking branch"
+		}
+		fmt.Fprintf(os.Stderr, "error: %s '%s' not found\n", kind, name)
+		return false
+	}
+
+	if !remote && name == utils.GetHeadBranch() {
+		fmt.Fprintf(os.Stderr, "error: cannot delete branch '%s' used by worktree at '%s'\n", name, currentWorktree())
+		return false
+	}
+
+	if !remote && !force && !isBranchMerged(name, hash) {
+		fmt.Fprintf(os.Stderr, "error: the branch '%s' is not fully merged\n", name)
+		fmt.Fprintf(os.Stderr, "hint: If you are sure you want to delete it, run 'ccgit branch -D %s'\n", name)
+		return false
+	}
+
+	if err := utils.DeleteRef(ref); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return false
+	}
+
+	if !remote {
+		_ = config.RemoveSection(config.LocalPath(), "branch."+name)
+		fmt.Fprintf(os.Stdout, "Deleted branch %s (was %s).\n", name, hash[:7])
+	} else {
+		fmt.Fprintf(os.Stdout, "Deleted remote-tracking branch %s (was %s).\n", name, hash[:7])
+	}
+
+	return true
+}
+
+// ref configurada em branch.<name>.remote/merge
+func branchUpstreamRef(name string) (string, bool) {
+	remote, hasRemote := config.Get("branch." + name + ".remote")
+	merge, hasMerge := config.Get("branch." + name + ".merge")
+	if !hasRemote || !hasMerge {
+		return "", false
+	}
+
+	if remote == "." {
+		return merge, true
+	}
+
+	return strings.Replace(merge, "refs/heads/", fmt.Sprintf("refs/remotes/%s/", remote), 1), true
+}
+
+func isBranchMerged(name string, hash string) bool {
+	target := string(utils.GetHeadHash())
+
+	if upstreamRef, ok := branchUpstreamRef(name); ok {
+		if upstreamHash, err := utils.ResolveRef(upstreamRef); err == nil {
+			target = upstreamHash
+		}
+	}
+
+	if target == "" {
+		return false
+	}
+
+	return IsAncestor(hash, target)
+}
+
+func renameBranch(oldName string, newName string, force bool) {
+	oldRef := "refs/heads/" + oldName
+	newRef := "refs/heads/" + newName
+
+	hash, err := utils.ResolveRef(oldRef)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: refname %s not found\n", oldRef)
+		fmt.Fprintf(os.Stderr, "fatal: branch rename failed\n")
+		os.Exit(1)
+	}
+
+	if !utils.CheckRefFormat(newName) || newName == "HEAD" {
+		fmt.Fprintf(os.Stderr, "fatal: '%s' is not a valid branch name\n", newName)
+		os.Exit(1)
+	}
+
+	if oldName == newName {
+		return
+	}
+
+	if utils.RefExists(newRef) {
+		if !force {
+			fmt.Fprintf(os.Stderr, "fatal: a branch named '%s' already exists\n", newName)
+			os.Exit(1)
+		}
+		_ = utils.DeleteRef(newRef)
+	}
+
+	// o reflog sai do caminho antes de mexer nas refs, como o .tmp-renamed-log do
+	// git: de a para a/b o arquivo logs/refs/heads/a precisa deixar de existir
+	oldLog := filepath.Join(".git", "logs", oldRef)
+	newLog := filepath.Join(".git", "logs", newRef)
+	tmpLog := filepath.Join(".git", "logs", "refs", ".tmp-renamed-log")
+	_, err = os.Stat(oldLog)
+	movedLog := err == nil
+	if movedLog {
+		if err := os.Rename(oldLog, tmpLog); err != nil {
+			fmt.Fprintf(os.Stderr, "fatal: unable to move logfile %s to %s: %v\n", oldLog, tmpLog, err)
+			os.Exit(1)
+		}
+		utils.RemoveEmptyRefDirs(filepath.Dir(oldLog))
+	}
+
+	if err := utils.DeleteRef(oldRef); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(1)
+	}
+
+	if movedLog {
+		_ = os.MkdirAll(filepath.Dir(newLog), 0755)
+		if err := os.Rename(tmpLog, newLog); err != nil {
+			fmt.Fprintf(os.Stderr, "fatal: unable to move logfile %s to %s: %v\n", tmpLog, newLog, err)
+			os.Exit(1)
+		}
+	}
+
+	message := fmt.Sprintf("Branch: renamed %s to %s", oldRef, newRef)
+	if err := utils.WriteRef(newRef, hash); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(1)
+	}
+	_ = utils.AppendReflog(newRef, hash, hash, message)
+
+	if utils.GetHeadBranch() == oldName {
+		_ = utils.SetHeadBranch(newName, message)
+	}
+
+	_ = config.RemoveSection(config.LocalPath(), "branch."+newName)
+	_ = config.RenameSection(config.LocalPath(), "branch."+oldName, "branch."+newName)
+}
+
+func setBranchUpstream(name string, upstream string) {
+	var remote, merge string
+
+	switch {
+	case utils.RefExists("refs/remotes/" + upstream):
+		remoteName, branch, _ := strings.Cut(upstream, "/")
+		remote, merge = remoteName, "refs/heads/"+branch
+	case utils.RefExists("refs/heads/" + upstream):
+		remote, merge = ".", "refs/heads/"+upstream
+	default:
+		fmt.Fprintf(os.Stderr, "fatal: the requested upstream branch '%s' does not exist\n", upstream)
+		os.Exit(1)
+	}
+
+	if err := config.Set(config.LocalPath(), "branch."+name+".remote", remote, nil); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(1)
+	}
+	if err := config.Set(config.LocalPath(), "branch."+name+".merge", merge, nil); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(1)
+	}
+
+	if remote == "." {
+		fmt.Fprintf(os.Stdout, "branch '%s' set up to track local branch '%s'.\n", name, upstream)
+	} else {
+		fmt.Fprintf(os.Stdout, "branch '%s' set up to track '%s'.\n", name, upstream)
+	}
+}
+
+func currentWorktree() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "."
+	}
+
+	return dir
+}
@@ -8,6 +8,7 @@ import (
 	"log"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 
@@ -94,6 +95,14 @@ func CatFileReadObject(folder string, file string) []byte {
 	return decompressedData
 }
 
+func objectExists(hash string) bool {
+	if len(hash) != 40 {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(".git", "objects", hash[0:2], hash[2:]))
+	return err == nil
+}
+
 func DeserializeTreeObject(data []byte) (*types.TreeObject, error) {
 	nulIndex := bytes.IndexByte(data, 0)
 	if nulIndex < 0 {
@@ -207,3 +216,62 @@ func DeserializeCommitObject(data []byte) (*types.CommitObject, []byte, []byte,
 		Tree: treeObject,
 	}, parentHash, treeHash, nil
 }
+
+var commitCache = map[string]*types.CommitObject{}
+
+func ReadCommit(hash string) (*types.CommitObject, error) {
+	if commit, ok := commitCache[hash]; ok {
+		return commit, nil
+	}
+
+	if !objectExists(hash) {
+		return nil, fmt.Errorf("object %s not found", hash)
+	}
+
+	data := CatFileReadObject(hash[0:2], hash[2:])
+	commit, err := ParseCommitObject(data)
+	if err != nil {
+		return nil, err
+	}
+
+	commit.Hash = hash
+	commitCache[hash] = commit
+	return commit, nil
+}
+
+func ParseCommitObject(data []byte) (*types.CommitObject, error) {
+	nulIndex := bytes.IndexByte(data, 0)
+	if nulIndex < 0 || !strings.HasPrefix(string(data[:nulIndex]), "commit ") {
+		return nil, fmt.Errorf("Commit file is corrupted")
+	}
+
+	body := string(data[nulIndex+1:])
+	headers, message, _ := strings.Cut(body, "\n\n")
+
+	commit := &types.CommitObject{Message: message}
+	for _, line := range strings.Split(headers, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "tree":
+			commit.TreeHash = value
+		case "parent":
+			commit.Parents = append(commit.Parents, value)
+		case "author":
+			commit.Author = value
+		case "committer":
+			commit.Committer = value
+		}
+	}
+
+	return commit, nil
+}
+
+func commitSubject(commit *types.CommitObject) string {
+	for _, line := range strings.Split(commit.Message, "\n") {
+		if strings.TrimSpace(line) != "" {
+			return line
+		}
+	}
+
+	return ""
+}
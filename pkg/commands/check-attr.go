@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/utils"
+)
+
+func checkAttrUsage(message string) {
+	if message != "" {
+		fmt.Fprintf(os.Stderr, "error: %s\n", message)
+	}
+
+	fmt.Fprintf(os.Stderr, "usage: ccgit check-attr [--cached] [-a | --all | <attr>...] [--] <pathname>...\n")
+	fmt.Fprintf(os.Stderr, "   or: ccgit check-attr [--cached] --stdin [-z] [-a | --all | <attr>...]\n")
+	os.Exit(129)
+}
+
+// mostra os atributos de cada caminho, como "caminho: atributo: valor"
+func CheckAttr(args ...string) {
+	var all, cached, stdin, nulTerminated bool
+	var rest []string
+
+	for i := 2; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-a" || arg == "--all":
+			all = true
+		case arg == "--cached":
+			cached = true
+		case arg == "--stdin":
+			stdin = true
+		case arg == "-z":
+			nulTerminated = true
+		case arg == "--":
+			rest = append(rest, args[i:]...)
+			i = len(args)
+		case strings.HasPrefix(arg, "--"):
+			checkAttrUsage(fmt.Sprintf("unknown option `%s'", arg[2:]))
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			checkAttrUsage(fmt.Sprintf("unknown switch `%s'", arg[1:2]))
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	// sem "--", só o primeiro argumento é atributo (ou todos, com --stdin)
+	doubleDash := slices.Index(rest, "--")
+	var names, paths []string
+	switch {
+	case all:
+		if doubleDash >= 1 {
+			checkAttrUsage("Attributes and --all both specified")
+		}
+		paths = rest
+		if doubleDash == 0 {
+			paths = rest[1:]
+		}
+	case doubleDash == 0 || len(rest) == 0:
+		checkAttrUsage("No attribute specified")
+	case doubleDash > 0:
+		names, paths = rest[:doubleDash], rest[doubleDash+1:]
+	case stdin:
+		names = rest
+	default:
+		names, paths = rest[:1], rest[1:]
+	}
+
+	if stdin && len(paths) > 0 {
+		checkAttrUsage("Can't specify files with --stdin")
+	}
+	if !stdin && len(paths) == 0 {
+		checkAttrUsage("No file specified")
+	}
+	for _, name := range names {
+		if !utils.ValidAttrName(name) {
+			fmt.Fprintf(os.Stderr, "error: %s: not a valid attribute name\n", name)
+			os.Exit(255)
+		}
+	}
+
+	read := func(path string) ([]byte, error) {
+		if data, err := os.ReadFile(path); err == nil {
+			return data, nil
+		}
+		return indexBlob(path)
+	}
+	if cached {
+		read = indexBlob
+	}
+
+	// os arquivos só são lidos quando o primeiro caminho chega
+	var attrs *utils.Attributes
+	check := func(path string) {
+		if attrs == nil {
+			attrs = utils.LoadAttributes(read)
+		}
+		values := attrs.Check(path)
+		checked := names
+		if all {
+			checked = nil
+			for _, name := range attrs.Names() {
+				if values[name] != utils.AttrUnspecified {
+					checked = append(checked, name)
+				}
+			}
+		}
+
+		for _, name := range checked {
+			if nulTerminated {
+				fmt.Printf("%s\x00%s\x00%s\x00", path, name, values[name])
+			} else {
+				fmt.Printf("%s: %s: %s\n", path, name, values[name])
+			}
+		}
+	}
+
+	if stdin {
+		scanner := bufio.NewScanner(os.Stdin)
+		if nulTerminated {
+			scanner.Split(splitNul)
+		}
+		for scanner.Scan() {
+			check(scanner.Text())
+		}
+		return
+	}
+
+	for _, path := range paths {
+		check(path)
+	}
+}
@@ -0,0 +1,162 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/utils"
+)
+
+func checkIgnoreUsage(message string) {
+	if message != "" {
+		fmt.Fprintf(os.Stderr, "%s\n", message)
+	}
+
+	fmt.Fprintf(os.Stderr, "usage: ccgit check-ignore [-q | --quiet] [-v | --verbose] [-n | --non-matching] [--no-index] <pathname>...\n")
+	fmt.Fprintf(os.Stderr, "   or: ccgit check-ignore [-q | --quiet] [-v | --verbose] [-n | --non-matching] [--no-index] [-z] --stdin\n")
+	os.Exit(129)
+}
+
+// mostra quais caminhos seriam ignorados e, com -v, a regra responsável
+func CheckIgnore(args ...string) {
+	var quiet, verbose, stdin, nulTerminated, nonMatching, noIndex bool
+	var paths []string
+
+	for i := 2; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-q" || arg == "--quiet":
+			quiet = true
+		case arg == "-v" || arg == "--verbose":
+			verbose = true
+		case arg == "--stdin":
+			stdin = true
+		case arg == "-z":
+			nulTerminated = true
+		case arg == "-n" || arg == "--non-matching":
+			nonMatching = true
+		case arg == "--no-index":
+			noIndex = true
+		case arg == "--":
+			paths = append(paths, args[i+1:]...)
+			i = len(args)
+		case strings.HasPrefix(arg, "--"):
+			checkIgnoreUsage(fmt.Sprintf("error: unknown option `%s'", arg[2:]))
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			checkIgnoreUsage(fmt.Sprintf("error: unknown switch `%s'", arg[1:2]))
+		default:
+			paths = append(paths, arg)
+		}
+	}
+
+	if stdin {
+		if len(paths) > 0 {
+			checkIgnoreFatal("cannot specify pathnames with --stdin")
+		}
+	} else {
+		if nulTerminated {
+			checkIgnoreFatal("-z only makes sense with --stdin")
+		}
+		if len(paths) == 0 {
+			checkIgnoreFatal("no path specified")
+		}
+	}
+	if quiet {
+		if len(paths) > 1 {
+			checkIgnoreFatal("--quiet is only valid with a single pathname")
+		}
+		if verbose {
+			checkIgnoreFatal("cannot have both --quiet and --verbose")
+		}
+	}
+	if nonMatching && !verbose {
+		checkIgnoreFatal("--non-matching is only valid with --verbose")
+	}
+
+	ignore := utils.StandardIgnore()
+	tracked := map[string]bool{}
+	if !noIndex {
+		tracked = trackedPaths(ReadIndex().Entries)
+	}
+
+	terminator := "\n"
+	if nulTerminated {
+		terminator = "\x00"
+	}
+
+	ignored := 0
+	check := func(path string) {
+		pattern := matchIgnored(ignore, tracked, path)
+		if !verbose && pattern != nil && pattern.Negated {
+			pattern = nil
+		}
+		if pattern != nil {
+			ignored++
+		}
+		if quiet || (pattern == nil && !nonMatching) {
+			return
+		}
+
+		switch {
+		case !verbose:
+			fmt.Printf("%s%s", path, terminator)
+		case pattern == nil && nulTerminated:
+			fmt.Printf("\x00\x00\x00%s\x00", path)
+		case pattern == nil:
+			fmt.Printf("::\t%s\n", path)
+		case nulTerminated:
+			fmt.Printf("%s\x00%d\x00%s\x00%s\x00", pattern.Source, pattern.Line, pattern.Text, path)
+		default:
+			fmt.Printf("%s:%d:%s\t%s\n", pattern.Source, pattern.Line, pattern.Text, path)
+		}
+	}
+
+	if stdin {
+		scanner := bufio.NewScanner(os.Stdin)
+		if nulTerminated {
+			scanner.Split(splitNul)
+		}
+		for scanner.Scan() {
+			check(scanner.Text())
+		}
+	} else {
+		for _, path := range paths {
+			check(path)
+		}
+	}
+
+	if ignored == 0 {
+		os.Exit(1)
+	}
+}
+
+func checkIgnoreFatal(message string) {
+	fmt.Fprintf(os.Stderr, "fatal: %s\n", message)
+	os.Exit(128)
+}
+
+// caminhos rastreados nunca são ignorados; o tipo vem do disco
+func matchIgnored(ignore *utils.Ignore, tracked map[string]bool, path string) *utils.IgnorePattern {
+	clean := filepath.ToSlash(filepath.Clean(path))
+	if tracked[clean] {
+		return nil
+	}
+
+	info, err := os.Lstat(clean)
+	return ignore.Match(clean, err == nil && info.IsDir())
+}
+
+func splitNul(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}
@@ -0,0 +1,652 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/types"
+	"github.com/codecrafters-io/git-starter-go/pkg/utils"
+)
+
+func Checkout(args ...string) {
+	err := utils.CheckGitRepo(".", false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	var force, detach bool
+	var newBranch string
+	var resetBranch bool
+	var positional []string
+	var pathspecs []string
+
+	for i := 2; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "--":
+			pathspecs = append(pathspecs, args[i+1:]...)
+			i = len(args)
+		case "-f", "--force":
+			force = true
+		case "--detach":
+			detach = true
+		case "-b", "-B":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "error: switch `%s' requires a value\n", strings.TrimPrefix(arg, "-"))
+				os.Exit(1)
+			}
+			newBranch, resetBranch = args[i+1], arg == "-B"
+			i++
+		default:
+			if strings.HasPrefix(arg, "-") {
+				fmt.Fprintf(os.Stderr, "error: unknown option `%s'\n", strings.TrimLeft(arg, "-"))
+				fmt.Fprintf(os.Stderr, "usage: ccgit checkout [-f] [--detach] [-b | -B <new-branch>] <branch | commit>\n")
+				fmt.Fprintf(os.Stderr, "   or: ccgit checkout [<tree-ish>] -- <pathspec>...\n")
+				os.Exit(1)
+			}
+			positional = append(positional, arg)
+		}
+	}
+
+	if len(pathspecs) > 0 && newBranch == "" {
+		if len(positional) > 1 {
+			fmt.Fprintf(os.Stderr, "fatal: only one reference expected, %d given.\n", len(positional))
+			os.Exit(1)
+		}
+
+		restored := false
+		if len(positional) == 1 {
+			restored = restorePaths(positional[0], true, true, true, pathspecs)
+		} else {
+			restored = restorePaths("", false, true, false, pathspecs)
+		}
+		if !restored {
+			os.Exit(1)
+		}
+		runPostCheckout(string(utils.GetHeadHash()), false)
+		return
+	}
+
+	if newBranch != "" {
+		if len(positional) > 1 {
+			fmt.Fprintf(os.Stderr, "fatal: only one reference expected, %d given.\n", len(positional))
+			os.Exit(1)
+		}
+
+		startPoint := "HEAD"
+		if len(positional) == 1 {
+			startPoint = positional[0]
+		}
+		switchToNewBranch(newBranch, startPoint, resetBranch, force)
+		return
+	}
+
+	if len(positional) == 0 {
+		fmt.Fprintf(os.Stderr, "usage: ccgit checkout [-f] [--detach] [-b | -B <new-branch>] <branch | commit>\n")
+		fmt.Fprintf(os.Stderr, "   or: ccgit checkout [<tree-ish>] -- <pathspec>...\n")
+		os.Exit(1)
+	}
+
+	if _, err := ResolveCommit(positional[0]); err != nil && !utils.RefExists("refs/heads/"+positional[0]) && guessRemoteBranch(positional[0]) == "" {
+		if !restorePaths("", false, true, false, positional) {
+			os.Exit(1)
+		}
+		runPostCheckout(string(utils.GetHeadHash()), false)
+		return
+	}
+
+	if len(positional) > 1 {
+		fmt.Fprintf(os.Stderr, "fatal: only one reference expected, %d given.\n", len(positional))
+		os.Exit(1)
+	}
+
+	rev := positional[0]
+	if !detach && utils.RefExists("refs/heads/"+rev) {
+		switchToBranch(rev, force)
+		return
+	}
+
+	if !detach {
+		if remoteRef := guessRemoteBranch(rev); remoteRef != "" {
+			switchToNewBranch(rev, strings.TrimPrefix(remoteRef, "refs/remotes/"), false, force)
+			return
+		}
+	}
+
+	hash, _ := ResolveCommit(rev)
+	switchToDetached(rev, hash, force)
+}
+
+func Switch(args ...string) {
+	err := utils.CheckGitRepo(".", false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	var force, detach bool
+	var newBranch string
+	var resetBranch bool
+	var positional []string
+
+	for i := 2; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "-f", "--force", "--discard-changes":
+			force = true
+		case "-d", "--detach":
+			detach = true
+		case "-c", "-C", "--create", "--force-create":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "error: switch `%s' requires a value\n", strings.TrimLeft(arg, "-"))
+				os.Exit(1)
+			}
+			newBranch, resetBranch = args[i+1], arg == "-C" || arg == "--force-create"
+			i++
+		default:
+			if strings.HasPrefix(arg, "-") {
+				fmt.Fprintf(os.Stderr, "error: unknown option `%s'\n", strings.TrimLeft(arg, "-"))
+				fmt.Fprintf(os.Stderr, "usage: ccgit switch [-f] (-c | -C) <new-branch> [<start-point>]\n")
+				fmt.Fprintf(os.Stderr, "   or: ccgit switch [-f] --detach [<start-point>]\n")
+				fmt.Fprintf(os.Stderr, "   or: ccgit switch [-f] <branch>\n")
+				os.Exit(1)
+			}
+			positional = append(positional, arg)
+		}
+	}
+
+	if len(positional) > 1 {
+		fmt.Fprintf(os.Stderr, "fatal: only one reference expected, %d given.\n", len(positional))
+		os.Exit(1)
+	}
+
+	if newBranch != "" {
+		startPoint := "HEAD"
+		if len(positional) == 1 {
+			startPoint = positional[0]
+		}
+		switchToNewBranch(newBranch, startPoint, resetBranch, force)
+		return
+	}
+
+	if detach {
+		rev := "HEAD"
+		if len(positional) == 1 {
+			rev = positional[0]
+		}
+		hash, err := ResolveCommit(rev)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fatal: invalid reference: %s\n", rev)
+			os.Exit(1)
+		}
+		switchToDetached(rev, hash, force)
+		return
+	}
+
+	if len(positional) == 0 {
+		fmt.Fprintf(os.Stderr, "fatal: missing branch or commit argument\n")
+		os.Exit(1)
+	}
+
+	name := positional[0]
+	if utils.RefExists("refs/heads/" + name) {
+		switchToBranch(name, force)
+		return
+	}
+
+	if remoteRef := guessRemoteBranch(name); remoteRef != "" {
+		switchToNewBranch(name, strings.TrimPrefix(remoteRef, "refs/remotes/"), false, force)
+		return
+	}
+
+	if _, err := ResolveCommit(name); err == nil {
+		fmt.Fprintf(os.Stderr, "fatal: a branch is expected, got commit '%s'\n", name)
+		fmt.Fprintf(os.Stderr, "hint: If you want to detach HEAD at the commit, try again with the --detach option.\n")
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr, "fatal: invalid reference: %s\n", name)
+	os.Exit(1)
+}
+
+func switchToBranch(name string, force bool) {
+	hash, _ := utils.ResolveRef("refs/heads/" + name)
+	defer runPostCheckout(string(utils.GetHeadHash()), true)
+
+	if !utils.IsHeadDetached() && utils.GetHeadBranch() == name {
+		if force && !checkoutTree(hash, true, "checkout") {
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Already on '%s'\n", name)
+		return
+	}
+
+	if !checkoutTree(hash, force, "checkout") {
+		os.Exit(1)
+	}
+
+	printPreviousHeadPosition(hash)
+	message := fmt.Sprintf("checkout: moving from %s to %s", describeHeadForReflog(), name)
+	if err := utils.SetHeadBranch(name, message); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr, "Switched to branch '%s'\n", name)
+}
+
+func switchToNewBranch(name string, startPoint string, reset bool, force bool) {
+	defer runPostCheckout(string(utils.GetHeadHash()), true)
+
+	if !utils.CheckRefFormat(name) || name == "HEAD" {
+		fmt.Fprintf(os.Stderr, "fatal: '%s' is not a valid branch name\n", name)
+		os.Exit(1)
+	}
+
+	ref := "refs/heads/" + name
+	exists := utils.RefExists(ref)
+	if exists && !reset {
+		fmt.Fprintf(os.Stderr, "fatal: a branch named '%s' already exists\n", name)
+		os.Exit(1)
+	}
+
+	hash, err := ResolveCommit(startPoint)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: '%s' is not a commit and a branch '%s' cannot be created from it\n", startPoint, name)
+		os.Exit(1)
+	}
+
+	if !checkoutTree(hash, force, "checkout") {
+		os.Exit(1)
+	}
+
+	message := fmt.Sprintf("branch: Created from %s", startPoint)
+	if exists {
+		message = fmt.Sprintf("branch: Reset to %s", startPoint)
+	}
+	if err := utils.UpdateRef(ref, hash, message); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(1)
+	}
+
+	if utils.RefExists("refs/remotes/" + startPoint) {
+		setBranchUpstream(name, startPoint)
+	}
+
+	printPreviousHeadPosition(hash)
+	headMessage := fmt.Sprintf("checkout: moving from %s to %s", describeHeadForReflog(), name)
+	if err := utils.SetHeadBranch(name, headMessage); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(1)
+	}
+
+	if exists {
+		fmt.Fprintf(os.Stderr, "Switched to and reset branch '%s'\n", name)
+	} else {
+		fmt.Fprintf(os.Stderr, "Switched to a new branch '%s'\n", name)
+	}
+}
+
+func switchToDetached(rev string, hash string, force bool) {
+	defer runPostCheckout(string(utils.GetHeadHash()), true)
+
+	if !checkoutTree(hash, force, "checkout") {
+		os.Exit(1)
+	}
+
+	wasDetached := utils.IsHeadDetached()
+	printPreviousHeadPosition(hash)
+
+	message := fmt.Sprintf("checkout: moving from %s to %s", describeHeadForReflog(), rev)
+	if err := utils.DetachHead(hash, message); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !wasDetached {
+		fmt.Fprintf(os.Stderr, "Note: switching to '%s'.\n\n", rev)
+		fmt.Fprintf(os.Stderr, "You are in 'detached HEAD' state. You can look around, make experimental\n")
+		fmt.Fprintf(os.Stderr, "changes and commit them, and you can discard any commits you make in this\n")
+		fmt.Fprintf(os.Stderr, "state without impacting any branches by switching back to a branch.\n\n")
+	}
+
+	fmt.Fprintf(os.Stderr, "HEAD is now at %s\n", describeCommitOneline(hash))
+}
+
+// o post-checkout recebe o HEAD anterior, o novo e 1 quando trocou de branch (0 para arquivos)
+func runPostCheckout(oldHead string, branchCheckout bool) {
+	newHead := string(utils.GetHeadHash())
+	if oldHead == "" {
+		oldHead = strings.Repeat("0", 40)
+	}
+	flag := "0"
+	if branchCheckout {
+		flag = "1"
+	}
+
+	if runHook("post-checkout", "", nil, oldHead, newHead, flag) != 0 {
+		os.Exit(1)
+	}
+}
+
+func guessRemoteBranch(name string) string {
+	var matches []string
+	for ref := range utils.ReadAllRefs() {
+		remoteBranch, ok := strings.CutPrefix(ref, "refs/remotes/")
+		if !ok {
+			continue
+		}
+		if _, branch, found := strings.Cut(remoteBranch, "/"); found && branch == name {
+			matches = append(matches, ref)
+		}
+	}
+
+	if len(matches) != 1 {
+		return ""
+	}
+
+	return matches[0]
+}
+
+func describeHeadForReflog() string {
+	if utils.IsHeadDetached() {
+		return string(utils.GetHeadHash())
+	}
+
+	return utils.GetHeadBranch()
+}
+
+func describeCommitOneline(hash string) string {
+	commit, err := ReadCommit(hash)
+	if err != nil {
+		return hash[:7]
+	}
+
+	return fmt.Sprintf("%s %s", hash[:7], commitSubject(commit))
+}
+
+func printPreviousHeadPosition(newHash string) {
+	if !utils.IsHeadDetached() {
+		return
+	}
+
+	headHash := string(utils.GetHeadHash())
+	if headHash != "" && headHash != newHash {
+		fmt.Fprintf(os.Stderr, "Previous HEAD position was %s\n", describeCommitOneline(headHash))
+	}
+}
+
+func checkoutTree(targetCommit string, force bool, operation string) bool {
+	oldTree := readCommitTreeEntries(string(utils.GetHeadHash()))
+	newTree := readCommitTreeEntries(targetCommit)
+
+	indexFile := ReadIndex()
+	indexEntries := map[string]types.Entry{}
+	for _, entry := range indexFile.Entries {
+		if entry.Stage != 0 && !force {
+			fmt.Fprintf(os.Stderr, "error: you need to resolve your current index first\n")
+			fmt.Fprintf(os.Stderr, "%s: needs merge\n", entry.Path)
+			return false
+		}
+		indexEntries[entry.Path] = entry
+	}
+
+	var changed []string
+	seen := map[string]bool{}
+	addChanged := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			changed = append(changed, path)
+		}
+	}
+
+	for path, oldEntry := range oldTree {
+		newEntry, inNew := newTree[path]
+		if force || !inNew || !sameTreeEntry(oldEntry, newEntry) {
+			addChanged(path)
+		}
+	}
+	for path := range newTree {
+		if _, inOld := oldTree[path]; !inOld || force {
+			addChanged(path)
+		}
+	}
+	if force {
+		for path := range indexEntries {
+			addChanged(path)
+		}
+	}
+	sort.Strings(changed)
+
+	if !force {
+		var dirty, untracked, removed, lostDirs []string
+		for _, path := range changed {
+			newEntry, inNew := newTree[path]
+			oldEntry, inOld := oldTree[path]
+			indexEntry, inIndex := indexEntries[path]
+
+			if inIndex && inNew && indexMatchesTreeEntry(indexEntry, newEntry) && worktreeMatchesIndex(indexEntry) {
+				continue
+			}
+
+			// já removido do índice e ausente do destino: nada se perde, a não ser
+			// que o arquivo continue no disco como não rastreado
+			if !inIndex && !inNew {
+				if inOld && pathExists(path) {
+					removed = append(removed, path)
+				}
+				continue
+			}
+
+			if !inIndex && !inOld {
+				if !inNew || !pathExists(path) {
+					continue
+				}
+				if stat, err := os.Lstat(path); err == nil && stat.IsDir() {
+					if !trackedDirectory(path, oldTree, indexEntries) {
+						lostDirs = append(lostDirs, path)
+					}
+				} else if !worktreeMatchesTreeEntry(path, newEntry) {
+					untracked = append(untracked, path)
+				}
+				continue
+			}
+
+			if inIndex != inOld || (inIndex && !indexMatchesTreeEntry(indexEntry, oldEntry)) {
+				dirty = append(dirty, path)
+				continue
+			}
+
+			if inIndex && !worktreeMatchesIndex(indexEntry) {
+				if !inNew && !pathExists(path) {
+					continue
+				}
+				dirty = append(dirty, path)
+			}
+		}
+
+		if len(dirty) > 0 {
+			fmt.Fprintf(os.Stderr, "error: Your local changes to the following files would be overwritten by %s:\n", operation)
+			for _, path := range dirty {
+				fmt.Fprintf(os.Stderr, "\t%s\n", path)
+			}
+			fmt.Fprintf(os.Stderr, "Please commit your changes or stash them before you %s.\n", operationVerb(operation))
+		}
+		if len(untracked) > 0 {
+			fmt.Fprintf(os.Stderr, "error: The following untracked working tree files would be overwritten by %s:\n", operation)
+			for _, path := range untracked {
+				fmt.Fprintf(os.Stderr, "\t%s\n", path)
+			}
+			fmt.Fprintf(os.Stderr, "Please move or remove them before you %s.\n", operationVerb(operation))
+		}
+		if len(removed) > 0 {
+			fmt.Fprintf(os.Stderr, "error: The following untracked working tree files would be removed by %s:\n", operation)
+			for _, path := range removed {
+				fmt.Fprintf(os.Stderr, "\t%s\n", path)
+			}
+			fmt.Fprintf(os.Stderr, "Please move or remove them before you %s.\n", operationVerb(operation))
+		}
+		if len(lostDirs) > 0 {
+			fmt.Fprintf(os.Stderr, "error: Updating the following directories would lose untracked files in them:\n")
+			for _, path := range lostDirs {
+				fmt.Fprintf(os.Stderr, "\t%s\n", path)
+			}
+			fmt.Fprintf(os.Stderr, "\n")
+		}
+		if len(dirty)+len(untracked)+len(removed)+len(lostDirs) > 0 {
+			fmt.Fprintf(os.Stderr, "Aborting\n")
+			return false
+		}
+	}
+
+	for _, path := range changed {
+		if _, inNew := newTree[path]; inNew {
+			continue
+		}
+		// fora do índice o arquivo é não rastreado e fica onde está
+		if _, inIndex := indexEntries[path]; !inIndex {
+			continue
+		}
+		removeWorktreeFile(path)
+		delete(indexEntries, path)
+	}
+
+	for _, path := range changed {
+		newEntry, inNew := newTree[path]
+		if !inNew {
+			continue
+		}
+
+		if err := writeWorktreeFile(path, newEntry.Mode, newEntry.Hash); err != nil {
+			fmt.Fprintf(os.Stderr, "error: unable to write file %s: %v\n", path, err)
+			return false
+		}
+		indexEntries[path] = NewIndexEntry(path, [20]byte(newEntry.Hash))
+	}
+
+	WriteIndex(sortedIndexEntries(indexEntries))
+	return true
+}
+
+func operationVerb(operation string) string {
+	if operation == "checkout" {
+		return "switch branches"
+	}
+
+	return operation
+}
+
+func sortedIndexEntries(indexEntries map[string]types.Entry) []types.Entry {
+	entries := make([]types.Entry, 0, len(indexEntries))
+	for _, entry := range indexEntries {
+		entries = append(entries, entry)
+	}
+
+	return sortIndexEntries(entries)
+}
+
+func sameTreeEntry(a types.TreeEntry, b types.TreeEntry) bool {
+	return a.Mode == b.Mode && bytes.Equal(a.Hash, b.Hash)
+}
+
+func indexMatchesTreeEntry(entry types.Entry, treeEntry types.TreeEntry) bool {
+	return utils.IndexModeString(entry.Mode) == treeEntry.Mode && bytes.Equal(entry.SHA1[:], treeEntry.Hash)
+}
+
+func worktreeMatchesIndex(entry types.Entry) bool {
+	if !pathExists(entry.Path) {
+		return false
+	}
+
+	hash, _, _ := worktreeBlob(entry.Path, false)
+	return hash == entry.SHA1
+}
+
+func worktreeMatchesTreeEntry(path string, treeEntry types.TreeEntry) bool {
+	hash, _, _ := worktreeBlob(path, false)
+	return bytes.Equal(hash[:], treeEntry.Hash)
+}
+
+// um diretório cujos arquivos são todos rastreados some na remoção que o checkout
+// faz antes de escrever, e pode dar lugar a um arquivo de mesmo nome
+func trackedDirectory(path string, oldTree map[string]types.TreeEntry, indexEntries map[string]types.Entry) bool {
+	files, _ := utils.GetDirTree(path, nil, true)
+	for _, file := range files {
+		file = filepath.ToSlash(file)
+		_, inOld := oldTree[file]
+		_, inIndex := indexEntries[file]
+		if !inOld && !inIndex {
+			return false
+		}
+	}
+
+	return true
+}
+
+func pathExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+func readBlob(hash string) []byte {
+	data := CatFileReadObject(hash[0:2], hash[2:])
+	nulIndex := bytes.IndexByte(data, 0)
+	return data[nulIndex+1:]
+}
+
+func writeWorktreeFile(path string, mode string, hash []byte) error {
+	if !objectExists(fmt.Sprintf("%x", hash)) {
+		return fmt.Errorf("unable to read sha1 file of %s (%x)", path, hash)
+	}
+	content := readBlob(fmt.Sprintf("%x", hash))
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	if stat, err := os.Lstat(path); err == nil {
+		if stat.IsDir() {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+		} else {
+			os.Remove(path)
+		}
+	}
+
+	if mode == "120000" {
+		return os.Symlink(string(content), path)
+	}
+
+	perm := os.FileMode(0644)
+	if mode == "100755" {
+		perm = 0755
+	}
+
+	if err := os.WriteFile(path, convertToWorktree(path, content), perm); err != nil {
+		return err
+	}
+	forgetAttributes(path)
+
+	return os.Chmod(path, perm)
+}
+
+func removeWorktreeFile(path string) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "warning: unable to unlink '%s': %v\n", path, err)
+		return
+	}
+
+	dir := filepath.Dir(path)
+	for dir != "." && dir != "/" {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,309 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/config"
+	"github.com/codecrafters-io/git-starter-go/pkg/utils"
+)
+
+const scissorsLine = "# ------------------------ >8 ------------------------"
+
+var cleanupModes = []string{"default", "verbatim", "whitespace", "strip", "scissors"}
+
+// monta a mensagem a partir de -m, -F, MERGE_MSG ou do template e, sem -m/-F, abre o editor
+func prepareCommitMessage(options commitOptions, author string) string {
+	useEditor := options.useEditor()
+
+	mode := options.cleanup
+	if mode == "" {
+		mode, _ = config.Get("commit.cleanup")
+	}
+	if mode == "" {
+		mode = "default"
+	}
+	if !slices.Contains(cleanupModes, mode) {
+		fmt.Fprintf(os.Stderr, "fatal: Invalid cleanup mode %s\n", mode)
+		os.Exit(128)
+	}
+	switch {
+	case mode == "default" && useEditor:
+		mode = "strip"
+	case mode == "default", mode == "scissors" && !useEditor:
+		mode = "whitespace"
+	}
+
+	var message, template, source string
+	switch {
+	case len(options.messages) > 0:
+		for _, paragraph := range options.messages {
+			if message != "" {
+				message += "\n"
+			}
+			message += paragraph
+			if !strings.HasSuffix(message, "\n") {
+				message += "\n"
+			}
+		}
+		source = "message"
+	case options.file != "":
+		message = readMessageFile(options.file)
+		source = "message"
+	case pathExists(filepath.Join(".git", "MERGE_MSG")):
+		data, _ := os.ReadFile(filepath.Join(".git", "MERGE_MSG"))
+		message = string(data)
+		source = "merge"
+	case useEditor:
+		path := options.template
+		if path == "" {
+			path, _ = config.GetPath("commit.template")
+		}
+		if path != "" {
+			data, err := os.ReadFile(config.ExpandPath(path))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "fatal: could not read '%s': %s\n", path, errnoText(err))
+				os.Exit(128)
+			}
+			template = string(data)
+			message = template
+			source = "template"
+		}
+	}
+
+	if mode != "verbatim" {
+		message = stripSpace(message, false)
+		if message != "" {
+			message += "\n"
+		}
+	}
+
+	path := filepath.Join(".git", "COMMIT_EDITMSG")
+	if useEditor {
+		message += commitTemplateComments(mode, author)
+	}
+	_ = os.WriteFile(path, []byte(message), 0644)
+
+	// os hooks recebem o arquivo e podem reescrevê-lo antes e depois do editor
+	hookArgs := []string{path}
+	if source != "" {
+		hookArgs = append(hookArgs, source)
+	}
+	if runHook("prepare-commit-msg", "", commitHookEnv(useEditor), hookArgs...) != 0 {
+		os.Exit(1)
+	}
+
+	if useEditor {
+		editor := gitEditor()
+		if err := launchEditor(editor, path); err != nil {
+			fmt.Fprintf(os.Stderr, "error: There was a problem with the editor '%s'.\n", editor)
+			fmt.Fprintf(os.Stderr, "Please supply the message using either -m or -F option.\n")
+			os.Exit(1)
+		}
+	}
+
+	if !options.noVerify && runHook("commit-msg", "", commitHookEnv(useEditor), path) != 0 {
+		os.Exit(1)
+	}
+
+	data, _ := os.ReadFile(path)
+	edited := string(data)
+	if useEditor && mode == "scissors" {
+		edited = cutAtScissors(edited)
+	}
+
+	return finishCommitMessage(edited, mode, template, options.allowEmpty)
+}
+
+// -e e --no-edit decidem; sem eles, só quando não há -m nem -F
+func (o commitOptions) useEditor() bool {
+	if o.edit != nil {
+		return *o.edit
+	}
+
+	return len(o.messages) == 0 && o.file == ""
+}
+
+// sem editor os hooks também não devem abrir um
+func commitHookEnv(useEditor bool) []string {
+	env := []string{"GIT_INDEX_FILE=" + filepath.Join(".git", "index")}
+	if !useEditor {
+		env = append(env, "GIT_EDITOR=:")
+	}
+
+	return env
+}
+
+func readMessageFile(path string) string {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: could not read log file '%s': %s\n", path, errnoText(err))
+		os.Exit(128)
+	}
+
+	return string(data)
+}
+
+// aplica o modo de limpeza e recusa a mensagem vazia ou o template intocado
+func finishCommitMessage(message string, mode string, template string, allowEmpty bool) string {
+	switch mode {
+	case "strip":
+		message = stripSpace(message, true)
+	case "whitespace", "scissors":
+		message = stripSpace(message, false)
+	default:
+		message = strings.TrimSuffix(message, "\n")
+	}
+
+	if template != "" && mode != "verbatim" && message == stripSpace(template, mode == "strip") {
+		fmt.Fprintf(os.Stderr, "Aborting commit; you did not edit the message.\n")
+		os.Exit(1)
+	}
+
+	if !allowEmpty && stripSpace(message, mode != "verbatim") == "" {
+		fmt.Fprintf(os.Stderr, "Aborting commit due to empty commit message.\n")
+		os.Exit(1)
+	}
+
+	return message
+}
+
+// tudo a partir da linha de tesoura é descartado
+func cutAtScissors(message string) string {
+	lines := strings.SplitAfter(message, "\n")
+	for i, line := range lines {
+		if strings.TrimRight(line, "\n") == scissorsLine {
+			return strings.Join(lines[:i], "")
+		}
+	}
+
+	return message
+}
+
+// as instruções e o resumo do status que o git põe abaixo da mensagem
+func commitTemplateComments(mode string, author string) string {
+	var sb strings.Builder
+
+	mergeHead, headName := "", ""
+	if len(readMergeHeads()) > 0 {
+		mergeHead, headName = "merge", "MERGE_HEAD"
+	} else if readPseudoRef("CHERRY_PICK_HEAD") != "" {
+		mergeHead, headName = "cherry-pick", "CHERRY_PICK_HEAD"
+	}
+	if mergeHead != "" {
+		if mode == "scissors" {
+			writeScissors(&sb)
+		}
+		fmt.Fprintf(&sb, "#\n# It looks like you may be committing a %s.\n", mergeHead)
+		fmt.Fprintf(&sb, "# If this is not correct, please run\n#\tgit update-ref -d %s\n# and try again.\n\n", headName)
+	}
+
+	sb.WriteString("\n")
+	switch mode {
+	case "strip":
+		sb.WriteString("# Please enter the commit message for your changes. Lines starting\n")
+		sb.WriteString("# with '#' will be ignored, and an empty message aborts the commit.\n")
+	case "scissors":
+		if mergeHead == "" {
+			writeScissors(&sb)
+		}
+	default:
+		sb.WriteString("# Please enter the commit message for your changes. Lines starting\n")
+		sb.WriteString("# with '#' will be kept; you may remove them yourself if you want to.\n")
+		sb.WriteString("# An empty message aborts the commit.\n")
+	}
+
+	// autor ou data diferentes de quem faz o commit aparecem para conferência
+	authorSig, committerSig := utils.ParseSignature(author), utils.ParseSignature(utils.CommitterIdent())
+	separator := "#\n"
+	if authorSig.Name != committerSig.Name || authorSig.Email != committerSig.Email {
+		fmt.Fprintf(&sb, "%s# Author:    %s <%s>\n", separator, authorSig.Name, authorSig.Email)
+		separator = ""
+	}
+	if !authorSig.When.Equal(committerSig.When) {
+		fmt.Fprintf(&sb, "%s# Date:      %s\n", separator, authorSig.When.Format("Mon Jan 2 15:04:05 2006 -0700"))
+	}
+	sb.WriteString("#\n")
+
+	writeCommitStatus(&sb)
+	return sb.String()
+}
+
+func writeScissors(sb *strings.Builder) {
+	sb.WriteString(scissorsLine + "\n")
+	sb.WriteString("# Do not modify or remove the line above.\n")
+	sb.WriteString("# Everything below it will be ignored.\n")
+}
+
+// o status comentado, sem as dicas de comando
+func writeCommitStatus(sb *strings.Builder) {
+	report := readStatus()
+
+	var header strings.Builder
+	printStatusHeader(&header, false)
+	for _, line := range strings.Split(strings.TrimSuffix(header.String(), "\n"), "\n") {
+		if strings.HasPrefix(line, "  (") {
+			continue
+		}
+		if line == "" {
+			sb.WriteString("#\n")
+		} else {
+			fmt.Fprintf(sb, "# %s\n", line)
+		}
+	}
+
+	writeSection := func(title string, paths []string, label func(string) string) {
+		if len(paths) == 0 {
+			return
+		}
+		slices.Sort(paths)
+		fmt.Fprintf(sb, "# %s:\n", title)
+		for _, path := range paths {
+			if label == nil {
+				fmt.Fprintf(sb, "#\t%s\n", path)
+			} else {
+				fmt.Fprintf(sb, "#\t%-12s%s\n", label(path)+":", path)
+			}
+		}
+		sb.WriteString("#\n")
+	}
+
+	writeSection("Changes to be committed", report.staged, func(path string) string {
+		if _, ok := report.headTree[path]; !ok {
+			return "new file"
+		}
+		if _, ok := report.index[path]; !ok {
+			return "deleted"
+		}
+		return "modified"
+	})
+	writeSection("Changes not staged for commit", slices.Concat(report.changed, report.deleted), func(path string) string {
+		if slices.Contains(report.deleted, path) {
+			return "deleted"
+		}
+		return "modified"
+	})
+	writeSection("Untracked files", report.untracked, nil)
+}
+
+// texto do erro como o strerror do C, que o git usa nas mensagens
+func errnoText(err error) string {
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	}
+
+	text := err.Error()
+	return strings.ToUpper(text[:1]) + text[1:]
+}
@@ -2,11 +2,14 @@ package commands
 
 import (
 	"fmt"
+	"log"
 	"maps"
 	"os"
 	"path/filepath"
+	"regexp"
 	"slices"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/codecrafters-io/git-starter-go/pkg/types"
@@ -19,60 +22,223 @@ type CommitStatus struct {
 	Path  string
 }
 
+type commitOptions struct {
+	messages   []string
+	file       string
+	template   string
+	cleanup    string
+	edit       *bool
+	allowEmpty bool
+	noVerify   bool
+	author     string
+	date       string
+}
+
 func Commit(args ...string) {
-	if len(args) < 4 {
-		fmt.Fprintf(os.Stderr, "usage: ccgit commit -m <message>\n")
+	var options commitOptions
+
+	// aceita "-mvalor", "--opção=valor" e o valor no argumento seguinte
+	optionValue := func(i *int, arg string, short string, long string) string {
+		if value, ok := strings.CutPrefix(arg, long+"="); ok && long != "" {
+			return value
+		}
+		if short != "" && arg != short && strings.HasPrefix(arg, short) {
+			return arg[len(short):]
+		}
+		if *i+1 >= len(args) {
+			if arg == short {
+				fmt.Fprintf(os.Stderr, "error: switch `%s' requires a value\n", strings.TrimPrefix(short, "-"))
+			} else {
+				fmt.Fprintf(os.Stderr, "error: option `%s' requires a value\n", strings.TrimPrefix(long, "--"))
+			}
+			os.Exit(129)
+		}
+		*i++
+		return args[*i]
+	}
+
+	for i := 2; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-am":
+			options.messages = append(options.messages, optionValue(&i, "-m", "-m", ""))
+		case arg == "--message" || strings.HasPrefix(arg, "--message=") || strings.HasPrefix(arg, "-m"):
+			options.messages = append(options.messages, optionValue(&i, arg, "-m", "--message"))
+		case arg == "--file" || strings.HasPrefix(arg, "--file=") || strings.HasPrefix(arg, "-F"):
+			options.file = optionValue(&i, arg, "-F", "--file")
+		case arg == "--template" || strings.HasPrefix(arg, "--template=") || strings.HasPrefix(arg, "-t"):
+			options.template = optionValue(&i, arg, "-t", "--template")
+		case arg == "--cleanup" || strings.HasPrefix(arg, "--cleanup="):
+			options.cleanup = optionValue(&i, arg, "", "--cleanup")
+		case arg == "-e" || arg == "--edit":
+			edit := true
+			options.edit = &edit
+		case arg == "--no-edit":
+			edit := false
+			options.edit = &edit
+		case arg == "--allow-empty-message":
+			options.allowEmpty = true
+		case arg == "-n" || arg == "--no-verify":
+			options.noVerify = true
+		case arg == "--author" || strings.HasPrefix(arg, "--author="):
+			options.author = optionValue(&i, arg, "", "--author")
+		case arg == "--date" || strings.HasPrefix(arg, "--date="):
+			options.date = optionValue(&i, arg, "", "--date")
+		default:
+			fmt.Fprintf(os.Stderr, "usage: ccgit commit [-m <message> | -F <file>] [-t <file>] [-e] [-n] [--cleanup=<mode>] [--author=<author>] [--date=<date>]\n")
+			os.Exit(129)
+		}
+	}
+
+	if len(options.messages) > 0 && options.file != "" {
+		fmt.Fprintf(os.Stderr, "fatal: options '-m' and '-F' cannot be used together\n")
+		os.Exit(128)
+	}
+
+	exitIfUnmerged("Committing")
+	author := resolveCommitAuthor(commitAuthor(options.author, options.date))
+	if !options.noVerify && runHook("pre-commit", "", commitHookEnv(options.useEditor())) != 0 {
 		os.Exit(1)
 	}
+	commitIndex(prepareCommitMessage(options, author), readMergeHeads(), author)
+	runHook("post-commit", "", commitHookEnv(options.useEditor()))
+}
 
-	var messages []string
-	for _, arg := range os.Args[2:] {
-		if arg != "-m" && arg != "-am" {
-			messages = append(messages, arg)
+// "" quando nem --author nem --date foram passados
+func commitAuthor(author string, date string) string {
+	if author == "" && date == "" {
+		return ""
+	}
+
+	var when time.Time
+	if date != "" {
+		parsed, err := utils.ParseDate(date)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fatal: invalid date format: %s\n", date)
+			os.Exit(128)
+		}
+		when = parsed
+	}
+
+	var name, email string
+	if author != "" {
+		var ok bool
+		if name, email, ok = utils.ParseIdent(author); !ok {
+			name, email, ok = findAuthor(author)
+		}
+		if !ok {
+			fmt.Fprintf(os.Stderr, "fatal: --author '%s' is not 'Name <email>' and matches no existing author\n", author)
+			os.Exit(128)
+		}
+	}
+
+	return utils.OverrideAuthorIdent(name, email, when)
+}
+
+// sem --author, o cherry-pick concluído à mão mantém o autor do commit original
+func resolveCommitAuthor(author string) string {
+	if author != "" {
+		return author
+	}
+
+	if pickHash := readPseudoRef("CHERRY_PICK_HEAD"); pickHash != "" {
+		if commit, err := ReadCommit(pickHash); err == nil {
+			return commit.Author
+		}
+	}
+
+	return utils.AuthorIdent()
+}
+
+// como o git, procura o autor mais recente de qualquer ref que case com o padrão
+func findAuthor(pattern string) (string, string, bool) {
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		re = regexp.MustCompile("(?i)" + regexp.QuoteMeta(pattern))
+	}
+
+	for _, commit := range WalkCommits(allRefTips(), nil, false) {
+		authorLine, _, _ := strings.Cut(commit.Author, ">")
+		if re.MatchString(authorLine + ">") {
+			sig := utils.ParseSignature(commit.Author)
+			return sig.Name, sig.Email, true
 		}
 	}
 
+	return "", "", false
+}
+
+func exitIfUnmerged(action string) {
+	if !hasUnmergedEntries() {
+		return
+	}
+
+	printUnmergedError(action)
+	fmt.Fprintf(os.Stderr, "fatal: Exiting because of an unresolved conflict.\n")
+	os.Exit(128)
+}
+
+func printUnmergedError(action string) {
+	fmt.Fprintf(os.Stderr, "error: %s is not possible because you have unmerged files.\n", action)
+	fmt.Fprintf(os.Stderr, "hint: Fix them up in the work tree, and then use 'git add/rm <file>'\n")
+	fmt.Fprintf(os.Stderr, "hint: as appropriate to mark resolution and make a commit.\n")
+}
+
+func commitIndex(message string, mergeHeads []string, author string) {
 	headTree := map[string]types.TreeEntry{}
 
-	indexFile := ReadIndex(args...)
+	indexFile := ReadIndex()
 	headTreeObject := ReadHead()
 	maps.Copy(headTree, extractTreeEntries(".", headTreeObject.Entries))
-	dirTree, _ := utils.GetDirTree(".", []string{}, false)
+	dirTree, _ := utils.GetDirTree(".", nil, false)
+
+	var parents []string
+	if headHash := utils.GetHeadHash(); len(headHash) > 0 {
+		parents = append(parents, string(headHash))
+	}
+	parents = append(parents, mergeHeads...)
 
 	treeHash := WriteTree()
-	hash, object := utils.GetCommitHashObject(treeHash, messages...)
+	author = resolveCommitAuthor(author)
+	pickHash := readPseudoRef("CHERRY_PICK_HEAD")
+	var messages []string
+	if message != "" {
+		messages = append(messages, message)
+	}
+	hash, object := utils.GetCommitHashObjectWithAuthor(treeHash, parents, author, messages...)
 	utils.SaveHashedObject(hash, object)
-	branch := utils.GetHeadBranch()
-	_ = os.MkdirAll(filepath.Join(".git", "refs", "heads"), 0755)
-	refBranchHead, err := os.Create(filepath.Join(".git", "refs", "heads", branch))
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %+v", err)
-		os.Exit(1)
+
+	subject, _, _ := strings.Cut(message, "\n")
+	reflogMessage := fmt.Sprintf("commit: %s", subject)
+	if len(parents) == 0 {
+		reflogMessage = fmt.Sprintf("commit (initial): %s", subject)
+	} else if len(mergeHeads) > 0 {
+		reflogMessage = fmt.Sprintf("commit (merge): %s", subject)
+	} else if pickHash != "" {
+		reflogMessage = fmt.Sprintf("commit (cherry-pick): %s", subject)
 	}
-	defer refBranchHead.Close()
 
-	_, err = fmt.Fprintf(refBranchHead, "%x", hash)
-	if err != nil {
+	if err := utils.UpdateHead(fmt.Sprintf("%x", hash), reflogMessage); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %+v", err)
 		os.Exit(1)
 	}
+	removeMergeState()
 
-	fmt.Fprintf(os.Stdout, "[%s %s] %s\n", branch, fmt.Sprintf("%x", hash[:])[:7], messages[0])
-	fmt.Fprintf(os.Stdout, "Date: %s\n", time.Now().Format("Mon Jan 2 15:04:05 2006 -0700"))
+	branch := utils.GetHeadBranch()
+	if utils.IsHeadDetached() {
+		branch = "detached HEAD"
+	}
+
+	fmt.Fprintf(os.Stdout, "[%s %s] %s\n", branch, fmt.Sprintf("%x", hash[:])[:7], subject)
+	fmt.Fprintf(os.Stdout, "Date: %s\n", utils.ParseSignature(author).When.Format("Mon Jan 2 15:04:05 2006 -0700"))
 
 	commitTree := []CommitStatus{}
 
 	for _, e := range indexFile.Entries {
-		valueHead, inHead := headTree[e.Path]
-		if !inHead {
-			hash, object, _ := utils.GetBlobHashObject(e.Path)
-			utils.SaveHashedObject(hash, object)
+		if _, inHead := headTree[e.Path]; !inHead {
 			commitTree = append(commitTree, CommitStatus{
-				Path: e.Path, Mode: utils.TreeModeString(os.FileMode(e.Mode)), Stage: "create",
+				Path: e.Path, Mode: utils.IndexModeString(e.Mode), Stage: "create",
 			})
-		} else if string(valueHead.Hash) != fmt.Sprintf("%x", e.SHA1[:]) {
-			hash, object, _ := utils.GetBlobHashObject(e.Path)
-			utils.SaveHashedObject(hash, object)
 		}
 	}
 
@@ -106,7 +272,7 @@ func extractTreeEntries(basePath string, entries []types.TreeEntry) map[string]t
 
 			treeFileData := CatFileReadObject(hash[0:2], hash[2:])
 			headTreeObject, _ := DeserializeTreeObject(treeFileData)
-			subtreeHahses := extractTreeEntries(entry.Name, headTreeObject.Entries)
+			subtreeHahses := extractTreeEntries(filepath.Join(basePath, entry.Name), headTreeObject.Entries)
 			maps.Copy(hashes, subtreeHahses)
 		} else {
 			hashes[filepath.Join(basePath, entry.Name)] = entry
@@ -115,3 +281,30 @@ func extractTreeEntries(basePath string, entries []types.TreeEntry) map[string]t
 
 	return hashes
 }
+
+func readTreeEntries(treeHash string) map[string]types.TreeEntry {
+	if treeHash == "" {
+		return map[string]types.TreeEntry{}
+	}
+
+	treeFileData := CatFileReadObject(treeHash[0:2], treeHash[2:])
+	treeObject, err := DeserializeTreeObject(treeFileData)
+	if err != nil {
+		log.Fatalf("An error ocurred on read tree %s, %v", treeHash, err)
+	}
+
+	return extractTreeEntries(".", treeObject.Entries)
+}
+
+func readCommitTreeEntries(commitHash string) map[string]types.TreeEntry {
+	if commitHash == "" {
+		return map[string]types.TreeEntry{}
+	}
+
+	commit, err := ReadCommit(commitHash)
+	if err != nil {
+		log.Fatalf("An error ocurred on read commit %s, %v", commitHash, err)
+	}
+
+	return readTreeEntries(commit.TreeHash)
+}
@@ -0,0 +1,498 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/config"
+)
+
+type configOptions struct {
+	action     string
+	scope      string
+	file       string
+	kind       string
+	null       bool
+	nameOnly   bool
+	showOrigin bool
+	showScope  bool
+	fallback   *string
+	includes   *bool
+}
+
+func Config(args ...string) {
+	var options configOptions
+	var positional []string
+
+	setAction := func(action string) {
+		if options.action != "" && options.action != action {
+			configUsage("error: only one action at a time")
+		}
+		options.action = action
+	}
+	setScope := func(scope string) {
+		if options.scope != "" && options.scope != scope {
+			configUsage("error: only one config file at a time")
+		}
+		options.scope = scope
+	}
+
+	for i := 2; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--get" || arg == "--get-all" || arg == "--get-regexp" || arg == "--add" || arg == "--unset" || arg == "--unset-all" || arg == "--replace-all" || arg == "--rename-section" || arg == "--remove-section":
+			setAction(strings.TrimPrefix(arg, "--"))
+		case arg == "-l" || arg == "--list":
+			setAction("list")
+		case arg == "-e" || arg == "--edit":
+			setAction("edit")
+		case arg == "--global" || arg == "--system" || arg == "--local" || arg == "--worktree":
+			setScope(strings.TrimPrefix(arg, "--"))
+		case arg == "-f" || arg == "--file":
+			if i+1 >= len(args) {
+				configUsage("error: switch `f' requires a value")
+			}
+			i++
+			setScope("file")
+			options.file = args[i]
+		case strings.HasPrefix(arg, "--file="):
+			setScope("file")
+			options.file = strings.TrimPrefix(arg, "--file=")
+		case arg == "--bool" || arg == "--int" || arg == "--bool-or-int" || arg == "--path":
+			options.kind = strings.TrimPrefix(arg, "--")
+		case arg == "--no-type":
+			options.kind = ""
+		case arg == "-t" || arg == "--type":
+			if i+1 >= len(args) {
+				configUsage("error: option `type' requires a value")
+			}
+			i++
+			options.kind = configType(args[i])
+		case strings.HasPrefix(arg, "--type="):
+			options.kind = configType(strings.TrimPrefix(arg, "--type="))
+		case arg == "--default":
+			if i+1 >= len(args) {
+				configUsage("error: option `default' requires a value")
+			}
+			i++
+			options.fallback = &args[i]
+		case strings.HasPrefix(arg, "--default="):
+			value := strings.TrimPrefix(arg, "--default=")
+			options.fallback = &value
+		case arg == "-z" || arg == "--null":
+			options.null = true
+		case arg == "--name-only":
+			options.nameOnly = true
+		case arg == "--show-origin":
+			options.showOrigin = true
+		case arg == "--show-scope":
+			options.showScope = true
+		case arg == "--includes" || arg == "--no-includes":
+			includes := arg == "--includes"
+			options.includes = &includes
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			configUsage(fmt.Sprintf("error: unknown option `%s'", strings.TrimLeft(arg, "-")))
+		default:
+			positional = append(positional, arg)
+		}
+	}
+
+	if options.action == "" {
+		switch len(positional) {
+		case 0:
+			configUsage("")
+		case 1:
+			options.action = "get"
+		case 2, 3:
+			options.action = "set"
+		default:
+			configUsage("")
+		}
+	}
+
+	switch options.action {
+	case "get", "get-all", "get-regexp", "unset", "unset-all":
+		configArgCount(positional, 1, 2)
+	case "set", "replace-all":
+		configArgCount(positional, 2, 3)
+	case "add", "rename-section":
+		configArgCount(positional, 2, 2)
+	case "remove-section":
+		configArgCount(positional, 1, 1)
+	case "list", "edit":
+		configArgCount(positional, 0, 0)
+	}
+
+	switch options.action {
+	case "get", "get-all":
+		configGet(options, positional)
+	case "get-regexp":
+		configGetRegexp(options, positional)
+	case "list":
+		for _, entry := range configEntries(options) {
+			printConfigEntry(options, entry, "=")
+		}
+	case "set", "add", "replace-all":
+		configSet(options, positional)
+	case "unset", "unset-all":
+		configUnset(options, positional)
+	case "rename-section", "remove-section":
+		configSection(options, positional)
+	case "edit":
+		path := configWritePath(options)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			_ = os.WriteFile(path, nil, 0644)
+		}
+		if err := launchEditor(gitEditor(), path); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+func configUsage(message string) {
+	if message != "" {
+		fmt.Fprintf(os.Stderr, "%s\n", message)
+	}
+
+	fmt.Fprintf(os.Stderr, "usage: ccgit config [<options>]\n\n")
+	fmt.Fprintf(os.Stderr, "Config file location\n")
+	fmt.Fprintf(os.Stderr, "    --global | --system | --local | --worktree | -f <file>\n\n")
+	fmt.Fprintf(os.Stderr, "Action\n")
+	fmt.Fprintf(os.Stderr, "    --get | --get-all | --get-regexp | --replace-all | --add | --unset | --unset-all\n")
+	fmt.Fprintf(os.Stderr, "    --rename-section | --remove-section | -l | -e\n\n")
+	fmt.Fprintf(os.Stderr, "Type\n")
+	fmt.Fprintf(os.Stderr, "    -t <type> | --bool | --int | --bool-or-int | --path\n\n")
+	fmt.Fprintf(os.Stderr, "Other\n")
+	fmt.Fprintf(os.Stderr, "    -z | --name-only | --show-origin | --show-scope | --default <value> | --[no-]includes\n")
+	os.Exit(129)
+}
+
+func configArgCount(positional []string, least int, most int) {
+	if len(positional) >= least && len(positional) <= most {
+		return
+	}
+
+	if least == most {
+		configUsage(fmt.Sprintf("error: wrong number of arguments, should be %d", least))
+	}
+	configUsage(fmt.Sprintf("error: wrong number of arguments, should be from %d to %d", least, most))
+}
+
+func configType(value string) string {
+	switch value {
+	case "bool", "int", "bool-or-int", "path":
+		return value
+	}
+
+	fmt.Fprintf(os.Stderr, "fatal: unrecognized --type argument, %s\n", value)
+	os.Exit(128)
+	return ""
+}
+
+// com um escopo escolhido os includes ficam desligados, como no git
+func configEntries(options configOptions) []config.Entry {
+	var sources []config.Source
+	switch options.scope {
+	case "":
+		if options.includes == nil || *options.includes {
+			return config.All()
+		}
+		sources = config.Sources()
+	case "global":
+		for _, path := range config.GlobalPaths() {
+			sources = append(sources, config.Source{Scope: "global", Path: path})
+		}
+	case "file":
+		sources = append(sources, config.Source{Scope: "command", Path: options.file})
+	default:
+		sources = append(sources, config.Source{Scope: options.scope, Path: configWritePath(options)})
+	}
+
+	includes := options.includes != nil && *options.includes
+	var entries []config.Entry
+	for _, source := range sources {
+		fileEntries, err := config.ReadFile(source.Path, source.Scope, includes)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+			os.Exit(128)
+		}
+		entries = append(entries, fileEntries...)
+	}
+
+	return entries
+}
+
+func configWritePath(options configOptions) string {
+	switch options.scope {
+	case "file":
+		return options.file
+	case "system":
+		return config.SystemPath()
+	case "global":
+		path := config.GlobalPath()
+		if path == "" {
+			fmt.Fprintf(os.Stderr, "fatal: $HOME not set\n")
+			os.Exit(128)
+		}
+		return path
+	case "worktree":
+		if config.GetBool("extensions.worktreeConfig", false) {
+			return config.WorktreePath()
+		}
+	}
+
+	if _, err := os.Stat(".git"); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: not in a git directory\n")
+		os.Exit(128)
+	}
+	return config.LocalPath()
+}
+
+func validateConfigKey(key string) {
+	if err := config.ValidateKey(key); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// "!padrão" casa com os valores que não batem com o padrão
+func configValueMatcher(positional []string, index int) func(string) bool {
+	if len(positional) <= index {
+		return nil
+	}
+
+	pattern := positional[index]
+	negate := false
+	if rest, ok := strings.CutPrefix(pattern, "!"); ok {
+		pattern, negate = rest, true
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: invalid pattern: %s\n", positional[index])
+		os.Exit(6)
+	}
+
+	return func(value string) bool {
+		return re.MatchString(value) != negate
+	}
+}
+
+func configGet(options configOptions, positional []string) {
+	validateConfigKey(positional[0])
+	key := config.CanonicalKey(positional[0])
+	matcher := configValueMatcher(positional, 1)
+
+	var matches []config.Entry
+	for _, entry := range configEntries(options) {
+		if entry.Key == key && (matcher == nil || matcher(entry.Value)) {
+			matches = append(matches, entry)
+		}
+	}
+
+	if len(matches) == 0 {
+		if options.fallback == nil {
+			os.Exit(1)
+		}
+		matches = append(matches, config.Entry{Key: key, Value: *options.fallback, Scope: "command"})
+	}
+	if options.action == "get" {
+		matches = matches[len(matches)-1:]
+	}
+
+	for _, entry := range matches {
+		printConfigValue(options, entry)
+	}
+}
+
+func configGetRegexp(options configOptions, positional []string) {
+	re, err := regexp.Compile(positional[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: invalid key pattern: %s\n", positional[0])
+		os.Exit(6)
+	}
+	matcher := configValueMatcher(positional, 1)
+
+	found := false
+	for _, entry := range configEntries(options) {
+		if re.MatchString(entry.Key) && (matcher == nil || matcher(entry.Value)) {
+			printConfigEntry(options, entry, " ")
+			found = true
+		}
+	}
+
+	if !found {
+		os.Exit(1)
+	}
+}
+
+func configPrefix(options configOptions, entry config.Entry) string {
+	var prefix string
+	separator := "\t"
+	if options.null {
+		separator = "\x00"
+	}
+
+	if options.showScope {
+		prefix += entry.Scope + separator
+	}
+	if options.showOrigin {
+		if entry.Path == "" {
+			prefix += "command line:" + separator
+		} else {
+			prefix += "file:" + entry.Path + separator
+		}
+	}
+
+	return prefix
+}
+
+func printConfigValue(options configOptions, entry config.Entry) {
+	terminator := "\n"
+	if options.null {
+		terminator = "\x00"
+	}
+
+	fmt.Fprintf(os.Stdout, "%s%s%s", configPrefix(options, entry), formatConfigValue(options, entry), terminator)
+}
+
+// no -z a chave e o valor vão separados por uma quebra de linha
+func printConfigEntry(options configOptions, entry config.Entry, separator string) {
+	terminator := "\n"
+	if options.null {
+		separator, terminator = "\n", "\x00"
+	}
+
+	line := configPrefix(options, entry) + entry.Key
+	if !options.nameOnly && (!entry.NoValue || options.kind != "") {
+		line += separator + formatConfigValue(options, entry)
+	}
+	fmt.Fprintf(os.Stdout, "%s%s", line, terminator)
+}
+
+func formatConfigValue(options configOptions, entry config.Entry) string {
+	switch options.kind {
+	case "bool":
+		value, err := config.ParseBool(entry.Value, entry.NoValue)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fatal: bad boolean config value '%s' for '%s'\n", entry.Value, entry.Key)
+			os.Exit(128)
+		}
+		return strconv.FormatBool(value)
+	case "int":
+		return strconv.FormatInt(parseConfigInt(entry), 10)
+	case "bool-or-int":
+		if number, err := config.ParseInt(entry.Value); err == nil && !entry.NoValue {
+			return strconv.FormatInt(number, 10)
+		}
+		value, err := config.ParseBool(entry.Value, entry.NoValue)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fatal: bad boolean config value '%s' for '%s'\n", entry.Value, entry.Key)
+			os.Exit(128)
+		}
+		return strconv.FormatBool(value)
+	case "path":
+		return config.ExpandPath(entry.Value)
+	}
+
+	return entry.Value
+}
+
+func parseConfigInt(entry config.Entry) int64 {
+	number, err := config.ParseInt(entry.Value)
+	if err != nil {
+		if entry.Path != "" {
+			fmt.Fprintf(os.Stderr, "fatal: bad numeric config value '%s' for '%s' in file %s: %v\n", entry.Value, entry.Key, entry.Path, err)
+		} else {
+			fmt.Fprintf(os.Stderr, "fatal: bad numeric config value '%s' for '%s': %v\n", entry.Value, entry.Key, err)
+		}
+		os.Exit(128)
+	}
+
+	return number
+}
+
+func configSet(options configOptions, positional []string) {
+	key := positional[0]
+	validateConfigKey(key)
+
+	// com um tipo o valor é gravado já normalizado
+	value := positional[1]
+	if options.kind != "" && options.kind != "path" {
+		value = formatConfigValue(options, config.Entry{Key: config.CanonicalKey(key), Value: value})
+	}
+
+	path := configWritePath(options)
+	var err error
+	switch options.action {
+	case "add":
+		err = config.Add(path, key, value)
+	case "replace-all":
+		err = config.ReplaceAll(path, key, value, configValueMatcher(positional, 2))
+	default:
+		err = config.Set(path, key, value, configValueMatcher(positional, 2))
+	}
+
+	if errors.Is(err, config.ErrMultipleValues) {
+		fmt.Fprintf(os.Stderr, "warning: %s has multiple values\n", key)
+		fmt.Fprintf(os.Stderr, "error: cannot overwrite multiple values with a single value\n")
+		fmt.Fprintf(os.Stderr, "       Use a regexp, --add or --replace-all to change %s.\n", key)
+		os.Exit(5)
+	}
+	exitOnConfigWriteError(path, err)
+}
+
+func configUnset(options configOptions, positional []string) {
+	key := positional[0]
+	validateConfigKey(key)
+
+	path := configWritePath(options)
+	err := config.Unset(path, key, configValueMatcher(positional, 1), options.action == "unset-all")
+	switch {
+	case errors.Is(err, config.ErrNotFound):
+		os.Exit(5)
+	case errors.Is(err, config.ErrMultipleValues):
+		fmt.Fprintf(os.Stderr, "warning: %s has multiple values\n", key)
+		os.Exit(5)
+	}
+	exitOnConfigWriteError(path, err)
+}
+
+func configSection(options configOptions, positional []string) {
+	path := configWritePath(options)
+
+	var err error
+	if options.action == "rename-section" {
+		if section, _, _ := strings.Cut(positional[1], "."); config.ValidateKey(section+".x") != nil {
+			fmt.Fprintf(os.Stderr, "error: invalid section name: %s\n", positional[1])
+			os.Exit(1)
+		}
+		err = config.RenameSection(path, positional[0], positional[1])
+	} else {
+		err = config.RemoveSection(path, positional[0])
+	}
+
+	if errors.Is(err, config.ErrNoSection) {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(128)
+	}
+	exitOnConfigWriteError(path, err)
+}
+
+func exitOnConfigWriteError(path string, err error) {
+	if err == nil {
+		return
+	}
+
+	if os.IsPermission(err) || errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintf(os.Stderr, "error: could not lock config file %s: %v\n", path, err)
+		os.Exit(4)
+	}
+	fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+	os.Exit(128)
+}
@@ -0,0 +1,346 @@
+package commands
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/config"
+	"github.com/codecrafters-io/git-starter-go/pkg/utils"
+)
+
+// o que fazer com os finais de linha de um arquivo, como o crlf_action do git
+type crlfAction int
+
+const (
+	crlfUndefined crlfAction = iota
+	crlfBinary
+	crlfText
+	crlfTextInput
+	crlfTextCRLF
+	crlfAuto
+	crlfAutoInput
+	crlfAutoCRLF
+)
+
+type textStats struct {
+	nul, lonecr, lonelf, crlf int
+	printable, nonprintable   int
+}
+
+var cachedAttributes *utils.Attributes
+
+// atributos lidos do diretório de trabalho, com o índice como reserva
+func worktreeAttributes() *utils.Attributes {
+	if cachedAttributes == nil {
+		cachedAttributes = utils.LoadAttributes(func(path string) ([]byte, error) {
+			if data, err := os.ReadFile(path); err == nil {
+				return data, nil
+			}
+			return indexBlob(path)
+		})
+	}
+
+	return cachedAttributes
+}
+
+func indexBlob(path string) ([]byte, error) {
+	for _, entry := range ReadIndex().Entries {
+		hash := fmt.Sprintf("%x", entry.SHA1[:])
+		if entry.Path == path && entry.Stage == 0 && objectExists(hash) {
+			return readBlob(hash), nil
+		}
+	}
+
+	return nil, os.ErrNotExist
+}
+
+// conteúdo do arquivo como vai para o repositório; substitui o
+// utils.GetBlobHashObject onde os atributos devem valer
+func worktreeBlob(path string, write bool) ([20]byte, []byte, []byte) {
+	var content []byte
+	if stat, err := os.Lstat(path); err == nil && stat.Mode()&os.ModeSymlink != 0 {
+		target, _ := os.Readlink(path)
+		content = []byte(target)
+	} else {
+		content, _ = os.ReadFile(path)
+		content = convertToGit(path, content, write)
+	}
+
+	hash, object := blobObject(content)
+	return hash, object, content
+}
+
+func blobObject(content []byte) ([20]byte, []byte) {
+	object := append(fmt.Appendf(nil, "blob %d\x00", len(content)), content...)
+	return sha1.Sum(object), object
+}
+
+func autoCRLF() string {
+	value, _ := config.Get("core.autocrlf")
+	if strings.EqualFold(value, "input") {
+		return "input"
+	}
+	if enabled, _ := config.ParseBool(value, false); enabled {
+		return "true"
+	}
+
+	return "false"
+}
+
+func textEOLIsCRLF() bool {
+	switch autoCRLF() {
+	case "true":
+		return true
+	case "input":
+		return false
+	}
+
+	eol, _ := config.Get("core.eol")
+	return strings.EqualFold(eol, "crlf")
+}
+
+func crlfFromAttr(value utils.AttrValue) crlfAction {
+	switch value {
+	case utils.AttrSet:
+		return crlfText
+	case utils.AttrUnset:
+		return crlfBinary
+	case "input":
+		return crlfTextInput
+	case "auto":
+		return crlfAuto
+	}
+
+	return crlfUndefined
+}
+
+// text (ou o antigo crlf), eol e core.autocrlf decidem a conversão
+func crlfActionFor(path string) crlfAction {
+	attrs := worktreeAttributes().Check(path)
+
+	action := crlfFromAttr(attrs["text"])
+	if action == crlfUndefined {
+		action = crlfFromAttr(attrs["crlf"])
+	}
+
+	if action != crlfBinary {
+		switch eol := attrs["eol"]; {
+		case action == crlfAuto && eol == "lf":
+			action = crlfAutoInput
+		case action == crlfAuto && eol == "crlf":
+			action = crlfAutoCRLF
+		case eol == "lf":
+			action = crlfTextInput
+		case eol == "crlf":
+			action = crlfTextCRLF
+		}
+	}
+
+	if action == crlfText {
+		action = crlfTextInput
+		if textEOLIsCRLF() {
+			action = crlfTextCRLF
+		}
+	}
+	if action == crlfUndefined {
+		switch autoCRLF() {
+		case "true":
+			action = crlfAutoCRLF
+		case "input":
+			action = crlfAutoInput
+		default:
+			action = crlfBinary
+		}
+	}
+
+	return action
+}
+
+func (a crlfAction) auto() bool {
+	return a == crlfAuto || a == crlfAutoInput || a == crlfAutoCRLF
+}
+
+func (a crlfAction) outputCRLF() bool {
+	switch a {
+	case crlfTextCRLF, crlfUndefined, crlfAutoCRLF:
+		return true
+	case crlfText, crlfAuto:
+		return textEOLIsCRLF()
+	}
+
+	return false
+}
+
+func gatherStats(data []byte) textStats {
+	var stats textStats
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '\r':
+			if i+1 < len(data) && data[i+1] == '\n' {
+				stats.crlf++
+				i++
+			} else {
+				stats.lonecr++
+			}
+		case c == '\n':
+			stats.lonelf++
+		case c == 127:
+			stats.nonprintable++
+		case c < 32:
+			switch c {
+			case '\b', '\t', '\033', '\014':
+				stats.printable++
+			case 0:
+				stats.nul++
+				stats.nonprintable++
+			default:
+				stats.nonprintable++
+			}
+		default:
+			stats.printable++
+		}
+	}
+
+	// um ^Z no fim (EOF do DOS) não conta
+	if len(data) > 0 && data[len(data)-1] == '\032' {
+		stats.nonprintable--
+	}
+
+	return stats
+}
+
+func (s textStats) binary() bool {
+	return s.lonecr > 0 || s.nul > 0 || (s.printable>>7) < s.nonprintable
+}
+
+func willConvertLFToCRLF(stats textStats, action crlfAction) bool {
+	if !action.outputCRLF() || stats.lonelf == 0 {
+		return false
+	}
+	// no modo automático arquivos que já têm CR ficam como estão
+	if action.auto() && (stats.lonecr > 0 || stats.crlf > 0 || stats.binary()) {
+		return false
+	}
+
+	return true
+}
+
+// um CRLF já gravado no índice indica que o arquivo deve ficar como está
+func hasCRLFInIndex(path string) bool {
+	data, err := indexBlob(path)
+	if err != nil || bytes.IndexByte(data, '\r') < 0 {
+		return false
+	}
+
+	stats := gatherStats(data)
+	return !stats.binary() && stats.crlf > 0
+}
+
+// o filtro clean roda primeiro e CRLF vira LF na ida para o repositório. Com write,
+// o core.safecrlf avisa (ou aborta) quando o arquivo não voltaria igual num checkout
+func convertToGit(path string, content []byte, write bool) []byte {
+	content = cleanFilter(path, content)
+
+	action := crlfActionFor(path)
+	if action == crlfBinary || len(content) == 0 {
+		return content
+	}
+
+	stats := gatherStats(content)
+	convert := stats.crlf > 0
+	if action.auto() {
+		if stats.binary() {
+			return content
+		}
+		if convert && hasCRLFInIndex(path) {
+			convert = false
+		}
+	}
+
+	if safe := safeCRLF(); write && safe != "false" {
+		after := stats
+		if convert {
+			after.lonelf += after.crlf
+			after.crlf = 0
+		}
+		if willConvertLFToCRLF(after, action) {
+			after.crlf += after.lonelf
+			after.lonelf = 0
+		}
+		checkRoundTrip(path, stats, after, safe)
+	}
+
+	if !convert {
+		return content
+	}
+
+	converted := make([]byte, 0, len(content))
+	for i, c := range content {
+		if c == '\r' && (action.auto() || (i+1 < len(content) && content[i+1] == '\n')) {
+			continue
+		}
+		converted = append(converted, c)
+	}
+
+	return converted
+}
+
+func safeCRLF() string {
+	value, ok := config.Get("core.safecrlf")
+	if !ok || strings.EqualFold(value, "warn") {
+		return "warn"
+	}
+	if enabled, _ := config.ParseBool(value, false); enabled {
+		return "true"
+	}
+
+	return "false"
+}
+
+func checkRoundTrip(path string, before textStats, after textStats, safe string) {
+	switch {
+	case before.crlf > 0 && after.crlf == 0:
+		if safe == "true" {
+			fmt.Fprintf(os.Stderr, "fatal: CRLF would be replaced by LF in %s\n", path)
+			os.Exit(128)
+		}
+		fmt.Fprintf(os.Stderr, "warning: in the working copy of '%s', CRLF will be replaced by LF the next time Git touches it\n", path)
+	case before.lonelf > 0 && after.lonelf == 0:
+		if safe == "true" {
+			fmt.Fprintf(os.Stderr, "fatal: LF would be replaced by CRLF in %s\n", path)
+			os.Exit(128)
+		}
+		fmt.Fprintf(os.Stderr, "warning: in the working copy of '%s', LF will be replaced by CRLF the next time Git touches it\n", path)
+	}
+}
+
+// LF vira CRLF na escrita do arquivo, quando text/eol ou core.autocrlf pedem, e
+// depois roda o filtro smudge
+func convertToWorktree(path string, content []byte) []byte {
+	action := crlfActionFor(path)
+	if len(content) == 0 || !willConvertLFToCRLF(gatherStats(content), action) {
+		return smudgeFilter(path, content)
+	}
+
+	converted := make([]byte, 0, len(content)+bytes.Count(content, []byte("\n")))
+	for i, c := range content {
+		if c == '\n' && (i == 0 || content[i-1] != '\r') {
+			converted = append(converted, '\r')
+		}
+		converted = append(converted, c)
+	}
+
+	return smudgeFilter(path, converted)
+}
+
+// um .gitattributes recém-escrito muda a conversão dos próximos arquivos
+func forgetAttributes(path string) {
+	if filepath.Base(path) == ".gitattributes" {
+		cachedAttributes = nil
+	}
+}
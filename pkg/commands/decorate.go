@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/utils"
+)
+
+type refDecoration struct {
+	Ref  string
+	Name string
+	Kind string
+}
+
+var decorationColors = map[string]string{
+	"HEAD":   "\033[1;36m",
+	"branch": "\033[1;32m",
+	"remote": "\033[1;31m",
+	"tag":    "\033[1;33m",
+	"stash":  "\033[1;35m",
+}
+
+func loadDecorations(full bool) map[string][]refDecoration {
+	decorations := map[string][]refDecoration{}
+
+	refs := utils.ReadAllRefs()
+	_ = filepath.Walk(filepath.Join(".git", "refs"), func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil || !strings.HasPrefix(string(content), "ref: ") {
+			return nil
+		}
+		rel, _ := filepath.Rel(".git", path)
+		if hash, err := utils.ResolveRef(filepath.ToSlash(rel)); err == nil {
+			refs[filepath.ToSlash(rel)] = hash
+		}
+		return nil
+	})
+
+	names := slices.Sorted(maps.Keys(refs))
+
+	// cada nova decoração entra na frente da lista, como no git
+	add := func(hash string, decoration refDecoration) {
+		decorations[hash] = append([]refDecoration{decoration}, decorations[hash]...)
+	}
+
+	for _, name := range names {
+		var kind, short string
+		switch {
+		case strings.HasPrefix(name, "refs/heads/"):
+			kind, short = "branch", strings.TrimPrefix(name, "refs/heads/")
+		case strings.HasPrefix(name, "refs/remotes/"):
+			kind, short = "remote", strings.TrimPrefix(name, "refs/remotes/")
+		case strings.HasPrefix(name, "refs/tags/"):
+			kind, short = "tag", strings.TrimPrefix(name, "refs/tags/")
+		case name == "refs/stash":
+			kind, short = "stash", name
+		default:
+			continue
+		}
+
+		display := short
+		if full {
+			display = name
+		}
+
+		hash := refs[name]
+		add(hash, refDecoration{Ref: name, Name: display, Kind: kind})
+		if target, isTag := peelTag(hash); isTag {
+			add(target, refDecoration{Ref: name, Name: display, Kind: "tag"})
+		}
+	}
+
+	if headHash := string(utils.GetHeadHash()); headHash != "" {
+		add(headHash, refDecoration{Ref: "HEAD", Name: "HEAD", Kind: "HEAD"})
+	}
+
+	return decorations
+}
+
+func formatDecorations(decorations []refDecoration, prefix string, suffix string, color bool) string {
+	if len(decorations) == 0 {
+		return ""
+	}
+
+	colorCommit, colorReset := "", ""
+	if color {
+		colorCommit, colorReset = "\033[33m", "\033[0m"
+	}
+	kindColor := func(kind string) string {
+		if !color {
+			return ""
+		}
+		return decorationColors[kind]
+	}
+
+	// HEAD e o branch atual aparecem juntos como "HEAD -> branch"
+	var current *refDecoration
+	if !utils.IsHeadDetached() {
+		headRef := "refs/heads/" + utils.GetHeadBranch()
+		hasHead := slices.ContainsFunc(decorations, func(d refDecoration) bool { return d.Kind == "HEAD" })
+		for i := range decorations {
+			if hasHead && decorations[i].Ref == headRef {
+				current = &decorations[i]
+			}
+		}
+	}
+
+	var sb strings.Builder
+	separator := ", "
+	for i := range decorations {
+		decoration := &decorations[i]
+		if decoration == current {
+			continue
+		}
+
+		sb.WriteString(colorCommit + prefix + colorReset + kindColor(decoration.Kind))
+		if decoration.Kind == "tag" {
+			sb.WriteString("tag: ")
+		}
+		sb.WriteString(decoration.Name)
+		if current != nil && decoration.Kind == "HEAD" {
+			sb.WriteString(" -> " + colorReset + kindColor(current.Kind) + current.Name)
+		}
+		sb.WriteString(colorReset)
+		prefix = separator
+	}
+	sb.WriteString(colorCommit + suffix + colorReset)
+
+	return sb.String()
+}
@@ -34,7 +34,7 @@ func Diff(args ...string) {
 	shouldColor := !noColor && utils.IsTerminal()
 
 	for _, entry := range indexFile.Entries {
-		_, _, workingContent := utils.GetBlobHashObject(entry.Path)
+		_, _, workingContent := worktreeBlob(entry.Path, false)
 
 		hash := fmt.Sprintf("%x", entry.SHA1[:])
 		blobContent := CatFileReadObject(hash[0:2], hash[2:])
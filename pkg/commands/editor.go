@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/config"
+)
+
+// mesma ordem de preferência do git; ":" desliga o editor
+func gitEditor() string {
+	if editor := os.Getenv("GIT_EDITOR"); editor != "" {
+		return editor
+	}
+	if editor, ok := config.Get("core.editor"); ok && editor != "" {
+		return editor
+	}
+
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if editor := os.Getenv(name); editor != "" {
+			return editor
+		}
+	}
+
+	return "vi"
+}
+
+func sequenceEditor() string {
+	if editor := os.Getenv("GIT_SEQUENCE_EDITOR"); editor != "" {
+		return editor
+	}
+	if editor, ok := config.Get("sequence.editor"); ok && editor != "" {
+		return editor
+	}
+
+	return gitEditor()
+}
+
+// o editor passa pelo shell, então "sed -i ..." ou um script com argumentos funcionam
+func launchEditor(editor string, path string) error {
+	if editor == ":" {
+		return nil
+	}
+
+	cmd := exec.Command("sh", "-c", editor+` "$@"`, editor, path)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("there was a problem with the editor '%s'", editor)
+	}
+
+	return nil
+}
+
+// remove comentários, espaços no fim das linhas e linhas em branco repetidas
+func cleanupMessage(message string) string {
+	return stripSpace(message, true)
+}
+
+// como o stripspace do git; sem stripComments as linhas com "#" ficam
+func stripSpace(message string, stripComments bool) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(message, "\n") {
+		if stripComments && strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
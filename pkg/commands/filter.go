@@ -0,0 +1,376 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/config"
+	"github.com/codecrafters-io/git-starter-go/pkg/utils"
+)
+
+// filter.<nome>.clean/smudge/process/required, escolhido pelo atributo filter
+type filterDriver struct {
+	name     string
+	clean    string
+	smudge   string
+	process  string
+	required bool
+}
+
+func filterDriverFor(path string) *filterDriver {
+	value := worktreeAttributes().Get(path, "filter")
+	if value == utils.AttrUnspecified || value == utils.AttrSet || value == utils.AttrUnset {
+		return nil
+	}
+
+	// só existe driver se a seção filter.<nome> tiver alguma dessas chaves
+	name := string(value)
+	driver := &filterDriver{name: name}
+	var found bool
+	for _, key := range []string{"clean", "smudge", "process", "required"} {
+		if _, ok := config.Get("filter." + name + "." + key); ok {
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	driver.clean, _ = config.Get("filter." + name + ".clean")
+	driver.smudge, _ = config.Get("filter." + name + ".smudge")
+	driver.process, _ = config.Get("filter." + name + ".process")
+	driver.required = config.GetBool("filter."+name+".required", false)
+
+	return driver
+}
+
+// roda o clean antes de o conteúdo ir para o repositório
+func cleanFilter(path string, content []byte) []byte {
+	driver := filterDriverFor(path)
+	if driver == nil {
+		return content
+	}
+
+	filtered, ok := driver.apply(path, content, "clean", driver.clean)
+	if !ok && driver.required {
+		fmt.Fprintf(os.Stderr, "fatal: %s: clean filter '%s' failed\n", path, driver.name)
+		os.Exit(128)
+	}
+
+	return filtered
+}
+
+// roda o smudge depois da conversão de finais de linha, na escrita do arquivo
+func smudgeFilter(path string, content []byte) []byte {
+	driver := filterDriverFor(path)
+	if driver == nil {
+		return content
+	}
+
+	filtered, ok := driver.apply(path, content, "smudge", driver.smudge)
+	if !ok && driver.required {
+		fmt.Fprintf(os.Stderr, "fatal: %s: smudge filter %s failed\n", path, driver.name)
+		os.Exit(128)
+	}
+
+	return filtered
+}
+
+// o comando de um arquivo só tem preferência sobre o process; sem nenhum dos
+// dois, ou se o filtro falha, o conteúdo segue como estava
+func (d *filterDriver) apply(path string, content []byte, command string, single string) ([]byte, bool) {
+	switch {
+	case single != "":
+		return runSingleFilter(path, content, single)
+	case d.process != "":
+		return runProcessFilter(path, content, command, d.process)
+	}
+
+	return content, false
+}
+
+func runSingleFilter(path string, content []byte, command string) ([]byte, bool) {
+	expanded := strings.ReplaceAll(command, "%f", shellQuote(path))
+
+	var out bytes.Buffer
+	cmd := exec.Command("sh", "-c", expanded, expanded)
+	cmd.Stdin = bytes.NewReader(content)
+	cmd.Stdout, cmd.Stderr = &out, os.Stderr
+
+	err := cmd.Run()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			fmt.Fprintf(os.Stderr, "error: external filter '%s' failed %d\n", command, exitErr.ExitCode())
+		} else {
+			fmt.Fprintf(os.Stderr, "error: cannot fork to run external filter '%s'\n", command)
+		}
+		fmt.Fprintf(os.Stderr, "error: external filter '%s' failed\n", command)
+		return content, false
+	}
+
+	return out.Bytes(), true
+}
+
+// aspas simples do shell, com ' e ! fora delas como no sq_quote do git
+func shellQuote(text string) string {
+	var sb strings.Builder
+	sb.WriteByte('\'')
+	for _, c := range text {
+		if c == '\'' || c == '!' {
+			sb.WriteString("'\\" + string(c) + "'")
+			continue
+		}
+		sb.WriteRune(c)
+	}
+	sb.WriteByte('\'')
+
+	return sb.String()
+}
+
+// filtro de longa duração (filter.<nome>.process): um processo por comando,
+// reaproveitado para todos os arquivos e conversado em pkt-line, versão 2
+type filterProcess struct {
+	cmd          *exec.Cmd
+	in           io.WriteCloser
+	out          *bufio.Reader
+	capabilities map[string]bool
+}
+
+var filterProcesses = map[string]*filterProcess{}
+
+const maxPacketData = 65516
+
+func runProcessFilter(path string, content []byte, command string, process string) ([]byte, bool) {
+	proc, ok := filterProcesses[process]
+	if !ok {
+		var err error
+		if proc, err = startFilterProcess(process); err != nil {
+			return content, false
+		}
+		filterProcesses[process] = proc
+	}
+	if !proc.capabilities[command] {
+		return content, false
+	}
+
+	filtered, status, err := proc.filter(path, content, command)
+	switch {
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "error: external filter '%s' failed\n", process)
+		proc.kill()
+		delete(filterProcesses, process)
+		return content, false
+	case status == "abort":
+		// o filtro desistiu desse comando para o resto da execução
+		proc.capabilities[command] = false
+		return content, false
+	case status != "success":
+		return content, false
+	}
+
+	return filtered, true
+}
+
+func startFilterProcess(process string) (*filterProcess, error) {
+	cmd := exec.Command("sh", "-c", process, process)
+	cmd.Stderr = os.Stderr
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: cannot fork to run subprocess '%s'\n", process)
+		return nil, err
+	}
+
+	proc := &filterProcess{cmd: cmd, in: in, out: bufio.NewReader(out), capabilities: map[string]bool{}}
+	if err := proc.handshake(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		fmt.Fprintf(os.Stderr, "error: initialization for subprocess '%s' failed\n", process)
+		proc.kill()
+		return nil, err
+	}
+
+	return proc, nil
+}
+
+func (p *filterProcess) handshake() error {
+	for _, line := range []string{"git-filter-client", "version=2"} {
+		if err := p.writeText(line); err != nil {
+			return err
+		}
+	}
+	if err := p.flush(); err != nil {
+		return err
+	}
+
+	if line, err := p.readText(); err != nil || line != "git-filter-server" {
+		return fmt.Errorf("Unexpected line '%s', expected git-filter-server", line)
+	}
+	if line, err := p.readText(); err != nil || line != "version=2" {
+		return fmt.Errorf("Unexpected line '%s', expected version", line)
+	}
+	if _, err := p.readList(); err != nil {
+		return fmt.Errorf("Unexpected line, expected flush")
+	}
+
+	for _, capability := range []string{"clean", "smudge"} {
+		if err := p.writeText("capability=" + capability); err != nil {
+			return err
+		}
+	}
+	if err := p.flush(); err != nil {
+		return err
+	}
+
+	lines, err := p.readList()
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if capability, ok := strings.CutPrefix(line, "capability="); ok {
+			p.capabilities[capability] = true
+		}
+	}
+
+	return nil
+}
+
+// envia o arquivo e lê a resposta: status, conteúdo e o status final opcional
+func (p *filterProcess) filter(path string, content []byte, command string) ([]byte, string, error) {
+	for _, line := range []string{"command=" + command, "pathname=" + path} {
+		if err := p.writeText(line); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := p.flush(); err != nil {
+		return nil, "", err
+	}
+	for start := 0; start < len(content); start += maxPacketData {
+		if err := p.writePacket(content[start:min(start+maxPacketData, len(content))]); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := p.flush(); err != nil {
+		return nil, "", err
+	}
+
+	status, err := p.readStatus("")
+	if err != nil || status != "success" {
+		return nil, status, err
+	}
+
+	var filtered []byte
+	for {
+		data, err := p.readPacket()
+		if err != nil {
+			return nil, "", err
+		}
+		if data == nil {
+			break
+		}
+		filtered = append(filtered, data...)
+	}
+
+	// uma lista vazia mantém o status anterior
+	status, err = p.readStatus(status)
+	return filtered, status, err
+}
+
+func (p *filterProcess) readStatus(status string) (string, error) {
+	lines, err := p.readList()
+	if err != nil {
+		return "", err
+	}
+	for _, line := range lines {
+		if value, ok := strings.CutPrefix(line, "status="); ok {
+			status = value
+		}
+	}
+
+	return status, nil
+}
+
+func (p *filterProcess) kill() {
+	p.in.Close()
+	p.cmd.Process.Kill()
+	p.cmd.Wait()
+}
+
+func (p *filterProcess) writePacket(data []byte) error {
+	_, err := fmt.Fprintf(p.in, "%04x%s", len(data)+4, data)
+	return err
+}
+
+func (p *filterProcess) writeText(line string) error {
+	return p.writePacket([]byte(line + "\n"))
+}
+
+func (p *filterProcess) flush() error {
+	_, err := io.WriteString(p.in, "0000")
+	return err
+}
+
+// nil indica o flush-pkt
+func (p *filterProcess) readPacket() ([]byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(p.out, header); err != nil {
+		return nil, err
+	}
+	length, err := strconv.ParseUint(string(header), 16, 16)
+	if err != nil {
+		return nil, fmt.Errorf("protocol error: bad line length character: %s", header)
+	}
+	if length == 0 {
+		return nil, nil
+	}
+	if length < 4 {
+		return nil, fmt.Errorf("protocol error: bad line length %d", length)
+	}
+
+	data := make([]byte, length-4)
+	if _, err := io.ReadFull(p.out, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (p *filterProcess) readText() (string, error) {
+	data, err := p.readPacket()
+	if err != nil {
+		return "", err
+	}
+	if data == nil {
+		return "", io.ErrUnexpectedEOF
+	}
+
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+// linhas de texto até o flush-pkt
+func (p *filterProcess) readList() ([]string, error) {
+	var lines []string
+	for {
+		data, err := p.readPacket()
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			return lines, nil
+		}
+		lines = append(lines, strings.TrimSuffix(string(data), "\n"))
+	}
+}
@@ -0,0 +1,637 @@
+package commands
+
+import (
+	"io"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/types"
+)
+
+type graphState int
+
+const (
+	graphPadding graphState = iota
+	graphSkip
+	graphPreCommit
+	graphCommit
+	graphPostMerge
+	graphCollapsing
+)
+
+var graphColumnColors = []string{
+	"\033[31m", "\033[32m", "\033[33m", "\033[34m", "\033[35m", "\033[36m",
+	"\033[1;31m", "\033[1;32m", "\033[1;33m", "\033[1;34m", "\033[1;35m", "\033[1;36m",
+}
+
+var graphMergeChars = []byte{'/', '|', '\\'}
+
+type graphColumn struct {
+	commit string
+	color  int
+}
+
+type graphLine struct {
+	sb    strings.Builder
+	width int
+}
+
+// porte do graph.c do git: cada commit passa por estados que desenham as linhas antes e depois dele
+type CommitGraph struct {
+	writer          io.Writer
+	interesting     map[string]bool
+	firstParent     bool
+	color           bool
+	commit          *types.CommitObject
+	parents         []string
+	width           int
+	expansionRow    int
+	state           graphState
+	prevState       graphState
+	commitIndex     int
+	prevCommitIndex int
+	mergeLayout     int
+	edgesAdded      int
+	prevEdgesAdded  int
+	columns         []graphColumn
+	newColumns      []graphColumn
+	mapping         []int
+	oldMapping      []int
+	mappingSize     int
+	defaultColor    int
+}
+
+func NewCommitGraph(writer io.Writer, interesting map[string]bool, firstParent bool, color bool) *CommitGraph {
+	return &CommitGraph{
+		writer:       writer,
+		interesting:  interesting,
+		firstParent:  firstParent,
+		color:        color,
+		state:        graphPadding,
+		prevState:    graphPadding,
+		defaultColor: len(graphColumnColors) - 1,
+	}
+}
+
+func (g *CommitGraph) Update(commit *types.CommitObject) {
+	g.commit = commit
+	g.parents = nil
+	for _, parent := range commit.Parents {
+		if g.interesting[parent] && !containsString(g.parents, parent) {
+			g.parents = append(g.parents, parent)
+		}
+		if g.firstParent {
+			break
+		}
+	}
+
+	g.prevCommitIndex = g.commitIndex
+	g.updateColumns()
+	g.expansionRow = 0
+
+	if g.state != graphPadding {
+		g.state = graphSkip
+	} else if g.needsPreCommitLine() {
+		g.state = graphPreCommit
+	} else {
+		g.state = graphCommit
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (g *CommitGraph) updateState(state graphState) {
+	g.prevState = g.state
+	g.state = state
+}
+
+func (g *CommitGraph) currentColor() int {
+	if !g.color {
+		return len(graphColumnColors)
+	}
+
+	return g.defaultColor
+}
+
+func (g *CommitGraph) incrementColor() {
+	g.defaultColor = (g.defaultColor + 1) % len(graphColumnColors)
+}
+
+func (g *CommitGraph) findCommitColor(commit string) int {
+	for _, column := range g.columns {
+		if column.commit == commit {
+			return column.color
+		}
+	}
+
+	return g.currentColor()
+}
+
+func (g *CommitGraph) findNewColumn(commit string) int {
+	for i, column := range g.newColumns {
+		if column.commit == commit {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (g *CommitGraph) insertIntoNewColumns(commit string, idx int) {
+	i := g.findNewColumn(commit)
+	if i < 0 {
+		i = len(g.newColumns)
+		g.newColumns = append(g.newColumns, graphColumn{commit: commit, color: g.findCommitColor(commit)})
+	}
+
+	var mappingIdx int
+	if len(g.parents) > 1 && idx > -1 && g.mergeLayout == -1 {
+		// primeiro pai de um merge: escolhe o layout conforme o pai está à esquerda ou não
+		dist := idx - i
+		shift := 1
+		if dist > 1 {
+			shift = 2*dist - 3
+		}
+
+		g.mergeLayout = 1
+		if dist > 0 {
+			g.mergeLayout = 0
+		}
+		g.edgesAdded = len(g.parents) + g.mergeLayout - 2
+
+		mappingIdx = g.width + (g.mergeLayout-1)*shift
+		g.width += 2 * g.mergeLayout
+	} else if g.edgesAdded > 0 && g.width >= 2 && i == g.mapping[g.width-2] {
+		mappingIdx = g.width - 2
+		g.edgesAdded = -1
+	} else {
+		mappingIdx = g.width
+		g.width += 2
+	}
+
+	g.mapping[mappingIdx] = i
+}
+
+func (g *CommitGraph) updateColumns() {
+	g.columns, g.newColumns = g.newColumns, g.columns[:0]
+
+	maxNewColumns := len(g.columns) + len(g.parents)
+	g.mappingSize = 2 * maxNewColumns
+	if len(g.mapping) < g.mappingSize {
+		g.mapping = make([]int, g.mappingSize)
+		old := make([]int, g.mappingSize)
+		copy(old, g.oldMapping)
+		g.oldMapping = old
+	}
+	for i := range g.mappingSize {
+		g.mapping[i] = -1
+	}
+
+	g.width = 0
+	g.prevEdgesAdded = g.edgesAdded
+	g.edgesAdded = 0
+
+	seenThis := false
+	inColumns := true
+	for i := 0; i <= len(g.columns); i++ {
+		var colCommit string
+		if i == len(g.columns) {
+			if seenThis {
+				break
+			}
+			inColumns = false
+			colCommit = g.commit.Hash
+		} else {
+			colCommit = g.columns[i].commit
+		}
+
+		if colCommit == g.commit.Hash {
+			seenThis = true
+			g.commitIndex = i
+			g.mergeLayout = -1
+			for _, parent := range g.parents {
+				if len(g.parents) > 1 || !inColumns {
+					g.incrementColor()
+				}
+				g.insertIntoNewColumns(parent, i)
+			}
+			if len(g.parents) == 0 {
+				g.width += 2
+			}
+		} else {
+			g.insertIntoNewColumns(colCommit, -1)
+		}
+	}
+
+	for g.mappingSize > 1 && g.mapping[g.mappingSize-1] < 0 {
+		g.mappingSize--
+	}
+}
+
+func (g *CommitGraph) numDashedParents() int {
+	return len(g.parents) + g.mergeLayout - 3
+}
+
+func (g *CommitGraph) needsPreCommitLine() bool {
+	return len(g.parents) >= 3 &&
+		g.commitIndex < len(g.columns)-1 &&
+		g.expansionRow < g.numDashedParents()*2
+}
+
+func (g *CommitGraph) isMappingCorrect() bool {
+	for i := range g.mappingSize {
+		target := g.mapping[i]
+		if target >= 0 && target != i/2 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (g *CommitGraph) isCommitFinished() bool {
+	return g.state == graphPadding
+}
+
+func (l *graphLine) addChar(c byte) {
+	l.sb.WriteByte(c)
+	l.width++
+}
+
+func (l *graphLine) addChars(c byte, n int) {
+	for range n {
+		l.addChar(c)
+	}
+}
+
+func (g *CommitGraph) writeColumn(line *graphLine, column graphColumn, c byte) {
+	if column.color < len(graphColumnColors) {
+		line.sb.WriteString(graphColumnColors[column.color])
+	}
+	line.addChar(c)
+	if column.color < len(graphColumnColors) {
+		line.sb.WriteString("\033[0m")
+	}
+}
+
+func (g *CommitGraph) nextLine() (string, bool) {
+	var line graphLine
+	shownCommitLine := false
+
+	switch g.state {
+	case graphPadding:
+		for _, column := range g.newColumns {
+			g.writeColumn(&line, column, '|')
+			line.addChar(' ')
+		}
+	case graphSkip:
+		line.sb.WriteString("...")
+		line.width += 3
+		if g.needsPreCommitLine() {
+			g.updateState(graphPreCommit)
+		} else {
+			g.updateState(graphCommit)
+		}
+	case graphPreCommit:
+		g.outputPreCommitLine(&line)
+	case graphCommit:
+		g.outputCommitLine(&line)
+		shownCommitLine = true
+	case graphPostMerge:
+		g.outputPostMergeLine(&line)
+	case graphCollapsing:
+		g.outputCollapsingLine(&line)
+	}
+
+	line.addChars(' ', g.width-line.width)
+	return line.sb.String(), shownCommitLine
+}
+
+func (g *CommitGraph) outputPreCommitLine(line *graphLine) {
+	seenThis := false
+	for i, column := range g.columns {
+		switch {
+		case column.commit == g.commit.Hash:
+			seenThis = true
+			g.writeColumn(line, column, '|')
+			line.addChars(' ', g.expansionRow)
+		case seenThis && g.expansionRow == 0:
+			if g.prevState == graphPostMerge && g.prevCommitIndex < i {
+				g.writeColumn(line, column, '\\')
+			} else {
+				g.writeColumn(line, column, '|')
+			}
+		case seenThis && g.expansionRow > 0:
+			g.writeColumn(line, column, '\\')
+		default:
+			g.writeColumn(line, column, '|')
+		}
+		line.addChar(' ')
+	}
+
+	g.expansionRow++
+	if !g.needsPreCommitLine() {
+		g.updateState(graphCommit)
+	}
+}
+
+func (g *CommitGraph) outputCommitLine(line *graphLine) {
+	seenThis := false
+	for i := 0; i <= len(g.columns); i++ {
+		var column graphColumn
+		if i == len(g.columns) {
+			if seenThis {
+				break
+			}
+			column = graphColumn{commit: g.commit.Hash}
+		} else {
+			column = g.columns[i]
+		}
+
+		switch {
+		case column.commit == g.commit.Hash:
+			seenThis = true
+			line.addChar('*')
+			if len(g.parents) > 2 {
+				dashed := g.numDashedParents()
+				for j := range dashed {
+					target := g.newColumns[g.mapping[(g.commitIndex+j+2)*2]]
+					g.writeColumn(line, target, '-')
+					if j == dashed-1 {
+						g.writeColumn(line, target, '.')
+					} else {
+						g.writeColumn(line, target, '-')
+					}
+				}
+			}
+		case seenThis && g.edgesAdded > 1:
+			g.writeColumn(line, column, '\\')
+		case seenThis && g.edgesAdded == 1:
+			if g.prevState == graphPostMerge && g.prevEdgesAdded > 0 && g.prevCommitIndex < i {
+				g.writeColumn(line, column, '\\')
+			} else {
+				g.writeColumn(line, column, '|')
+			}
+		case g.prevState == graphCollapsing && 2*i+1 < len(g.oldMapping) &&
+			g.oldMapping[2*i+1] == i && g.mapping[2*i] < i:
+			g.writeColumn(line, column, '/')
+		default:
+			g.writeColumn(line, column, '|')
+		}
+		line.addChar(' ')
+	}
+
+	if len(g.parents) > 1 {
+		g.updateState(graphPostMerge)
+	} else if g.isMappingCorrect() {
+		g.updateState(graphPadding)
+	} else {
+		g.updateState(graphCollapsing)
+	}
+}
+
+func (g *CommitGraph) outputPostMergeLine(line *graphLine) {
+	seenThis := false
+	var parentColumn *graphColumn
+
+	for i := 0; i <= len(g.columns); i++ {
+		var column graphColumn
+		if i == len(g.columns) {
+			if seenThis {
+				break
+			}
+			column = graphColumn{commit: g.commit.Hash}
+		} else {
+			column = g.columns[i]
+		}
+
+		switch {
+		case column.commit == g.commit.Hash:
+			seenThis = true
+			idx := g.mergeLayout
+			for j, parent := range g.parents {
+				g.writeColumn(line, g.newColumns[g.findNewColumn(parent)], graphMergeChars[idx])
+				if idx == 2 {
+					if g.edgesAdded > 0 || j < len(g.parents)-1 {
+						line.addChar(' ')
+					}
+				} else {
+					idx++
+				}
+			}
+			if g.edgesAdded == 0 {
+				line.addChar(' ')
+			}
+		case seenThis:
+			if g.edgesAdded > 0 {
+				g.writeColumn(line, column, '\\')
+			} else {
+				g.writeColumn(line, column, '|')
+			}
+			line.addChar(' ')
+		default:
+			g.writeColumn(line, column, '|')
+			if g.mergeLayout != 0 || i != g.commitIndex-1 {
+				if parentColumn != nil {
+					g.writeColumn(line, *parentColumn, '_')
+				} else {
+					line.addChar(' ')
+				}
+			}
+		}
+
+		if len(g.parents) > 0 && column.commit == g.parents[0] {
+			parentColumn = &column
+		}
+	}
+
+	if g.isMappingCorrect() {
+		g.updateState(graphPadding)
+	} else {
+		g.updateState(graphCollapsing)
+	}
+}
+
+func (g *CommitGraph) outputCollapsingLine(line *graphLine) {
+	usedHorizontal := false
+	horizontalEdge := -1
+	horizontalEdgeTarget := -1
+
+	g.mapping, g.oldMapping = g.oldMapping, g.mapping
+	for i := range g.mappingSize {
+		g.mapping[i] = -1
+	}
+
+	for i := range g.mappingSize {
+		target := g.oldMapping[i]
+		if target < 0 {
+			continue
+		}
+
+		switch {
+		case target*2 == i:
+			g.mapping[i] = target
+		case g.mapping[i-1] < 0:
+			// nada à esquerda: anda uma posição
+			g.mapping[i-1] = target
+			if horizontalEdge == -1 {
+				horizontalEdge = i
+				horizontalEdgeTarget = target
+				for j := target*2 + 3; j < i-2; j += 2 {
+					g.mapping[j] = target
+				}
+			}
+		case g.mapping[i-1] == target:
+			// já existe uma linha à esquerda indo para o mesmo pai
+		default:
+			// cruza a linha da esquerda
+			g.mapping[i-2] = target
+			if horizontalEdge == -1 {
+				horizontalEdgeTarget = target
+				horizontalEdge = i - 1
+				for j := target*2 + 3; j < i-2; j += 2 {
+					g.mapping[j] = target
+				}
+			}
+		}
+	}
+
+	copy(g.oldMapping, g.mapping[:g.mappingSize])
+
+	if g.mapping[g.mappingSize-1] < 0 {
+		g.mappingSize--
+	}
+
+	for i := range g.mappingSize {
+		target := g.mapping[i]
+		switch {
+		case target < 0:
+			line.addChar(' ')
+		case target*2 == i:
+			g.writeColumn(line, g.newColumns[target], '|')
+		case target == horizontalEdgeTarget && i != horizontalEdge-1:
+			if i != target*2+3 {
+				g.mapping[i] = -1
+			}
+			usedHorizontal = true
+			g.writeColumn(line, g.newColumns[target], '_')
+		default:
+			if usedHorizontal && i < horizontalEdge {
+				g.mapping[i] = -1
+			}
+			g.writeColumn(line, g.newColumns[target], '/')
+		}
+	}
+
+	if g.isMappingCorrect() {
+		g.updateState(graphPadding)
+	}
+}
+
+func (g *CommitGraph) paddingLine() string {
+	if g.state != graphCommit {
+		line, _ := g.nextLine()
+		return line
+	}
+
+	var line graphLine
+	for _, column := range g.columns {
+		g.writeColumn(&line, column, '|')
+		if column.commit == g.commit.Hash && len(g.parents) > 2 {
+			line.addChars(' ', (len(g.parents)-2)*2)
+		} else {
+			line.addChar(' ')
+		}
+	}
+	line.addChars(' ', g.width-line.width)
+
+	g.prevState = graphPadding
+	return line.sb.String()
+}
+
+func (g *CommitGraph) ShowCommit() {
+	if g == nil {
+		return
+	}
+
+	if g.isCommitFinished() {
+		io.WriteString(g.writer, g.paddingLine())
+		return
+	}
+
+	for !g.isCommitFinished() {
+		line, shownCommitLine := g.nextLine()
+		io.WriteString(g.writer, line)
+		if shownCommitLine {
+			return
+		}
+		io.WriteString(g.writer, "\n")
+	}
+}
+
+func (g *CommitGraph) ShowOneline() {
+	if g == nil {
+		return
+	}
+
+	line, _ := g.nextLine()
+	io.WriteString(g.writer, line)
+}
+
+func (g *CommitGraph) ShowPadding() {
+	if g == nil {
+		return
+	}
+
+	io.WriteString(g.writer, g.paddingLine())
+}
+
+func (g *CommitGraph) ShowRemainder() {
+	if g == nil || g.isCommitFinished() {
+		return
+	}
+
+	for {
+		line, _ := g.nextLine()
+		io.WriteString(g.writer, line)
+		if g.isCommitFinished() {
+			return
+		}
+		io.WriteString(g.writer, "\n")
+	}
+}
+
+func (g *CommitGraph) ShowCommitMessage(writer io.Writer, message string) {
+	for rest := message; rest != ""; {
+		line, next, found := strings.Cut(rest, "\n")
+		io.WriteString(writer, line)
+		if !found {
+			break
+		}
+		io.WriteString(writer, "\n")
+		if next != "" {
+			g.ShowOneline()
+		}
+		rest = next
+	}
+
+	if g == nil || g.isCommitFinished() {
+		return
+	}
+
+	newlineTerminated := strings.HasSuffix(message, "\n")
+	if !newlineTerminated {
+		io.WriteString(writer, "\n")
+	}
+	g.ShowRemainder()
+	if newlineTerminated {
+		io.WriteString(writer, "\n")
+	}
+}
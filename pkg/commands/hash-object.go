@@ -3,7 +3,7 @@ package commands
 import (
 	"fmt"
 	"os"
-	"slices"
+	"strings"
 
 	"github.com/codecrafters-io/git-starter-go/pkg/utils"
 )
@@ -15,33 +15,50 @@ func GetHashObjects(args ...string) {
 		os.Exit(1)
 	}
 
-	var path string
-	if args[2] == "-w" {
-		path = args[3]
-	} else {
-		path = args[2]
+	var path, attrPath string
+	kind := "blob"
+	write, filters := false, true
+	for i := 2; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "-w":
+			write = true
+		case arg == "-t" && i+1 < len(args):
+			i++
+			kind = args[i]
+		case arg == "--no-filters":
+			filters = false
+		case strings.HasPrefix(arg, "--path="):
+			attrPath = strings.TrimPrefix(arg, "--path=")
+		default:
+			path = arg
+		}
 	}
 
-	var kind string
-	if slices.Contains(args, "-t") {
-		pos := utils.GetSlicePosition(args, "-t")
-		if pos >= 0 {
-			kind = args[pos+1]
-		}
-	} else {
-		kind = "blob"
+	if attrPath != "" && !filters {
+		fmt.Fprintf(os.Stderr, "error: Can't use --path with --no-filters\n")
+		fmt.Fprintf(os.Stderr, "usage: ccgit hash-object [-t <type>] [-w] [--path=<file> | --no-filters] <file>\n")
+		os.Exit(129)
+	}
+	if attrPath == "" {
+		attrPath = path
 	}
 
 	var hash [20]byte
 	var object []byte
 	switch kind {
 	case "blob":
-		hash, object, _ = utils.GetBlobHashObject(path)
+		if filters {
+			// os mesmos atributos do add valem para o caminho informado
+			content, _ := os.ReadFile(path)
+			hash, object = blobObject(convertToGit(attrPath, content, write))
+		} else {
+			hash, object, _ = utils.GetBlobHashObject(path)
+		}
 	// case "tree":
 	// 	hash, object, _ = utils.GetTreeHashObject(path)
 	}
 
-	if slices.Contains(args, "-w") {
+	if write {
 		utils.SaveHashedObject(hash, object)
 	}
 
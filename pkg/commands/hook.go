@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/config"
+)
+
+// core.hooksPath substitui o .git/hooks que o init cria
+func hooksDir() string {
+	if path, ok := config.GetPath("core.hookspath"); ok && path != "" {
+		return path
+	}
+
+	return filepath.Join(".git", "hooks")
+}
+
+// um hook sem permissão de execução é ignorado com um aviso, como no git
+func findHook(name string) string {
+	path := filepath.Join(hooksDir(), name)
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return ""
+	}
+
+	if info.Mode()&0111 == 0 {
+		if config.GetBool("advice.ignoredhook", true) {
+			fmt.Fprintf(os.Stderr, "hint: The '%s' hook was ignored because it's not set as executable.\n", path)
+			fmt.Fprintf(os.Stderr, "hint: You can disable this warning with `git config advice.ignoredHook false`.\n")
+		}
+		return ""
+	}
+
+	return path
+}
+
+// roda o hook com a saída padrão indo para o stderr; sem o hook o resultado é 0
+func runHook(name string, stdin string, env []string, args ...string) int {
+	path := findHook(name)
+	if path == "" {
+		return 0
+	}
+
+	run := func(command string, commandArgs ...string) error {
+		cmd := exec.Command(command, commandArgs...)
+		cmd.Env = append(os.Environ(), env...)
+		cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+		if stdin != "" {
+			cmd.Stdin = strings.NewReader(stdin)
+		}
+		return cmd.Run()
+	}
+
+	// caminhos relativos sem "/" seriam procurados no PATH
+	if !strings.Contains(path, "/") {
+		path = "./" + path
+	}
+
+	err := run(path, args...)
+	if errors.Is(err, syscall.ENOEXEC) {
+		// script sem shebang: o git também tenta pelo shell
+		err = run("sh", append([]string{path}, args...)...)
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		return max(exitErr.ExitCode(), 1)
+	default:
+		fmt.Fprintf(os.Stderr, "error: cannot run %s: %s\n", path, errnoText(err))
+		return 1
+	}
+}
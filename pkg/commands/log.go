@@ -0,0 +1,954 @@
+package commands
+
+import (
+	"bytes"
+	"container/heap"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/types"
+	"github.com/codecrafters-io/git-starter-go/pkg/utils"
+)
+
+const (
+	flagSeen = 1 << iota
+	flagUninteresting
+)
+
+type LogOptions struct {
+	Format      string
+	UserFormat  string
+	Terminator  bool
+	Abbrev      bool
+	DateFormat  string
+	MaxCount    int
+	Skip        int
+	Authors     []*regexp.Regexp
+	Committers  []*regexp.Regexp
+	Greps       []*regexp.Regexp
+	Since       time.Time
+	Until       time.Time
+	FirstParent bool
+	Reverse     bool
+	Color       bool
+	Graph       bool
+	TopoOrder   bool
+	DateOrder   bool
+	Decorate    string
+	All         bool
+	Patch       bool
+	Combined    bool
+}
+
+type queueItem struct {
+	commit *types.CommitObject
+	date   time.Time
+	seq    int
+}
+
+type commitQueue []*queueItem
+
+func (q commitQueue) Len() int { return len(q) }
+
+func (q commitQueue) Less(i, j int) bool {
+	if q[i].date.Equal(q[j].date) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].date.After(q[j].date)
+}
+
+func (q commitQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *commitQueue) Push(x any) { *q = append(*q, x.(*queueItem)) }
+
+func (q *commitQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+type revWalker struct {
+	flags       map[string]int
+	queue       commitQueue
+	seq         int
+	firstParent bool
+}
+
+func Log(args ...string) {
+	err := utils.CheckGitRepo(".", false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	options, revs := ParseLogArgs(args, LogOptions{Format: "medium", MaxCount: -1})
+
+	positive, negative, err := ParseRevisionRange(revs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(128)
+	}
+
+	if options.All {
+		positive = append(positive, allRefTips()...)
+	}
+
+	if len(positive) == 0 && len(revs) == 0 && !options.All {
+		headHash := utils.GetHeadHash()
+		if len(headHash) == 0 {
+			branch := utils.GetHeadBranch()
+			fmt.Fprintf(os.Stderr, "fatal: your current branch '%s' does not have any commits yet\n", branch)
+			os.Exit(128)
+		}
+		positive = append(positive, string(headHash))
+	}
+
+	commits := WalkCommits(positive, negative, options.FirstParent)
+	if options.Graph || options.TopoOrder {
+		commits = SortTopological(commits, options.DateOrder)
+	}
+
+	var matched []*types.CommitObject
+	interesting := map[string]bool{}
+	for _, commit := range commits {
+		if matchCommit(commit, options) {
+			matched = append(matched, commit)
+			interesting[commit.Hash] = true
+		}
+	}
+
+	printer := NewLogPrinter(os.Stdout, options)
+	if options.Graph {
+		printer.graph = NewCommitGraph(os.Stdout, interesting, options.FirstParent, options.Color)
+	}
+
+	for _, commit := range limitCommits(matched, options) {
+		if options.Graph {
+			printer.graph.Update(commit)
+		}
+		printer.Print(commit)
+	}
+}
+
+func ParseLogArgs(args []string, options LogOptions) (LogOptions, []string) {
+	noColor := false
+	ignoreCase := false
+	decorate := "auto"
+	var authors, committers, greps []string
+	var revs []string
+
+	optionValue := func(i *int, arg string, name string) string {
+		if value, ok := strings.CutPrefix(arg, name+"="); ok {
+			return value
+		}
+		if *i+1 >= len(args) {
+			fmt.Fprintf(os.Stderr, "fatal: option '%s' requires a value\n", strings.TrimLeft(name, "-"))
+			os.Exit(128)
+		}
+		*i++
+		return args[*i]
+	}
+
+	for i := 2; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			i = len(args)
+		case arg == "--oneline":
+			options.Format, options.Abbrev = "oneline", true
+		case arg == "--abbrev-commit":
+			options.Abbrev = true
+		case arg == "--no-abbrev-commit":
+			options.Abbrev = false
+		case arg == "--first-parent":
+			options.FirstParent = true
+		case arg == "--reverse":
+			options.Reverse = true
+		case arg == "-p" || arg == "-u" || arg == "--patch":
+			options.Patch = true
+		case arg == "-s" || arg == "--no-patch":
+			options.Patch = false
+		case arg == "--cc":
+			options.Patch, options.Combined = true, true
+		case arg == "--graph":
+			options.Graph = true
+		case arg == "--topo-order":
+			options.TopoOrder, options.DateOrder = true, false
+		case arg == "--date-order":
+			options.TopoOrder, options.DateOrder = true, true
+		case arg == "--all":
+			options.All = true
+		case arg == "--decorate":
+			decorate = "short"
+		case arg == "--no-decorate":
+			decorate = "no"
+		case strings.HasPrefix(arg, "--decorate="):
+			decorate = strings.TrimPrefix(arg, "--decorate=")
+			if !slices.Contains([]string{"short", "full", "auto", "no"}, decorate) {
+				fmt.Fprintf(os.Stderr, "fatal: invalid --decorate option: %s\n", decorate)
+				os.Exit(128)
+			}
+		case arg == "--no-color":
+			noColor = true
+		case arg == "-i" || arg == "--regexp-ignore-case":
+			ignoreCase = true
+		case arg == "-n":
+			options.MaxCount = parseLogCount(optionValue(&i, arg, "-n"))
+		case strings.HasPrefix(arg, "--max-count"):
+			options.MaxCount = parseLogCount(optionValue(&i, arg, "--max-count"))
+		case strings.HasPrefix(arg, "-n") && len(arg) > 2:
+			options.MaxCount = parseLogCount(arg[2:])
+		case regexp.MustCompile(`^-\d+$`).MatchString(arg):
+			options.MaxCount = parseLogCount(arg[1:])
+		case strings.HasPrefix(arg, "--skip"):
+			options.Skip = parseLogCount(optionValue(&i, arg, "--skip"))
+		case strings.HasPrefix(arg, "--author"):
+			authors = append(authors, optionValue(&i, arg, "--author"))
+		case strings.HasPrefix(arg, "--committer"):
+			committers = append(committers, optionValue(&i, arg, "--committer"))
+		case strings.HasPrefix(arg, "--grep"):
+			greps = append(greps, optionValue(&i, arg, "--grep"))
+		case strings.HasPrefix(arg, "--since"), strings.HasPrefix(arg, "--after"):
+			name := "--since"
+			if strings.HasPrefix(arg, "--after") {
+				name = "--after"
+			}
+			options.Since = parseLogDate(optionValue(&i, arg, name))
+		case strings.HasPrefix(arg, "--until"), strings.HasPrefix(arg, "--before"):
+			name := "--until"
+			if strings.HasPrefix(arg, "--before") {
+				name = "--before"
+			}
+			options.Until = parseLogDate(optionValue(&i, arg, name))
+		case strings.HasPrefix(arg, "--date"):
+			options.DateFormat = optionValue(&i, arg, "--date")
+		case strings.HasPrefix(arg, "--pretty"), strings.HasPrefix(arg, "--format"):
+			value := "medium"
+			if _, v, found := strings.Cut(arg, "="); found {
+				value = v
+			}
+			setLogFormat(&options, value, strings.HasPrefix(arg, "--format"))
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			fmt.Fprintf(os.Stderr, "fatal: unrecognized argument: %s\n", arg)
+			os.Exit(128)
+		default:
+			revs = append(revs, arg)
+		}
+	}
+
+	compileAll := func(patterns []string) []*regexp.Regexp {
+		var compiled []*regexp.Regexp
+		for _, pattern := range patterns {
+			if ignoreCase {
+				pattern = "(?i)" + pattern
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "fatal: invalid regex '%s': %v\n", pattern, err)
+				os.Exit(128)
+			}
+			compiled = append(compiled, re)
+		}
+		return compiled
+	}
+	options.Authors = compileAll(authors)
+	options.Committers = compileAll(committers)
+	options.Greps = compileAll(greps)
+	options.Color = !noColor && utils.IsTerminal()
+
+	switch decorate {
+	case "short", "full":
+		options.Decorate = decorate
+	case "auto":
+		if utils.IsTerminal() {
+			options.Decorate = "short"
+		}
+	}
+
+	if options.Graph && options.Reverse {
+		fmt.Fprintf(os.Stderr, "fatal: options '--reverse' and '--graph' cannot be used together\n")
+		os.Exit(128)
+	}
+
+	return options, revs
+}
+
+func allRefTips() []string {
+	var tips []string
+	refs := utils.ReadAllRefs()
+	names := slices.Sorted(maps.Keys(refs))
+	for _, name := range names {
+		if hash, err := peelToCommit(refs[name]); err == nil {
+			tips = append(tips, hash)
+		}
+	}
+
+	if headHash := utils.GetHeadHash(); len(headHash) > 0 {
+		tips = append(tips, string(headHash))
+	}
+
+	return tips
+}
+
+func setLogFormat(options *LogOptions, value string, tformat bool) {
+	switch {
+	case strings.HasPrefix(value, "format:"):
+		options.Format, options.UserFormat, options.Terminator = "format", strings.TrimPrefix(value, "format:"), false
+	case strings.HasPrefix(value, "tformat:"):
+		options.Format, options.UserFormat, options.Terminator = "format", strings.TrimPrefix(value, "tformat:"), true
+	case slices.Contains([]string{"oneline", "short", "medium", "full", "fuller", "raw"}, value):
+		options.Format = value
+	case strings.Contains(value, "%") || tformat:
+		options.Format, options.UserFormat, options.Terminator = "format", value, true
+	default:
+		fmt.Fprintf(os.Stderr, "fatal: invalid --pretty format: %s\n", value)
+		os.Exit(128)
+	}
+}
+
+func parseLogCount(value string) int {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: '%s': not an integer\n", value)
+		os.Exit(128)
+	}
+
+	return n
+}
+
+func parseLogDate(value string) time.Time {
+	when, err := utils.ParseDate(value)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(128)
+	}
+
+	return when
+}
+
+func ParseRevisionRange(revs []string) ([]string, []string, error) {
+	var positive, negative []string
+
+	resolve := func(rev string) (string, error) {
+		if rev == "" {
+			rev = "HEAD"
+		}
+		return ResolveCommit(rev)
+	}
+
+	for _, rev := range revs {
+		if left, right, found := strings.Cut(rev, "..."); found {
+			a, err := resolve(left)
+			if err != nil {
+				return nil, nil, err
+			}
+			b, err := resolve(right)
+			if err != nil {
+				return nil, nil, err
+			}
+			positive = append(positive, a, b)
+			negative = append(negative, MergeBases(a, b)...)
+			continue
+		}
+
+		if left, right, found := strings.Cut(rev, ".."); found {
+			a, err := resolve(left)
+			if err != nil {
+				return nil, nil, err
+			}
+			b, err := resolve(right)
+			if err != nil {
+				return nil, nil, err
+			}
+			positive = append(positive, b)
+			negative = append(negative, a)
+			continue
+		}
+
+		if excluded, ok := strings.CutPrefix(rev, "^"); ok {
+			hash, err := resolve(excluded)
+			if err != nil {
+				return nil, nil, err
+			}
+			negative = append(negative, hash)
+			continue
+		}
+
+		hash, err := resolve(rev)
+		if err != nil {
+			return nil, nil, err
+		}
+		positive = append(positive, hash)
+	}
+
+	return positive, negative, nil
+}
+
+func WalkCommits(positive []string, negative []string, firstParent bool) []*types.CommitObject {
+	walker := &revWalker{flags: map[string]int{}, firstParent: firstParent}
+
+	for _, hash := range negative {
+		walker.push(hash, flagUninteresting)
+	}
+	for _, hash := range positive {
+		walker.push(hash, 0)
+	}
+
+	var list []*types.CommitObject
+	slop := 5
+	for walker.queue.Len() > 0 {
+		item := heap.Pop(&walker.queue).(*queueItem)
+		commit := item.commit
+
+		if walker.flags[commit.Hash]&flagUninteresting != 0 {
+			walker.markParentsUninteresting(commit)
+			for _, parent := range commit.Parents {
+				walker.push(parent, flagUninteresting)
+			}
+
+			if walker.everybodyUninteresting() {
+				slop--
+				if slop == 0 {
+					break
+				}
+			} else {
+				slop = 5
+			}
+			continue
+		}
+
+		parents := commit.Parents
+		if firstParent && len(parents) > 1 {
+			parents = parents[:1]
+		}
+		for _, parent := range parents {
+			walker.push(parent, 0)
+		}
+		list = append(list, commit)
+	}
+
+	var result []*types.CommitObject
+	for _, commit := range list {
+		if walker.flags[commit.Hash]&flagUninteresting == 0 {
+			result = append(result, commit)
+		}
+	}
+
+	return result
+}
+
+func SortTopological(commits []*types.CommitObject, byDate bool) []*types.CommitObject {
+	indegree := map[string]int{}
+	byHash := map[string]*types.CommitObject{}
+	for _, commit := range commits {
+		indegree[commit.Hash] = 1
+		byHash[commit.Hash] = commit
+	}
+	for _, commit := range commits {
+		for _, parent := range commit.Parents {
+			if indegree[parent] > 0 {
+				indegree[parent]++
+			}
+		}
+	}
+
+	// sem ordenação por data a fila funciona como pilha, igual ao --topo-order do git
+	var stack []*types.CommitObject
+	var queue commitQueue
+	seq := 0
+	put := func(commit *types.CommitObject) {
+		if !byDate {
+			stack = append(stack, commit)
+			return
+		}
+		seq++
+		heap.Push(&queue, &queueItem{commit: commit, date: utils.ParseSignature(commit.Committer).When, seq: seq})
+	}
+	get := func() *types.CommitObject {
+		if !byDate {
+			if len(stack) == 0 {
+				return nil
+			}
+			commit := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			return commit
+		}
+		if queue.Len() == 0 {
+			return nil
+		}
+		return heap.Pop(&queue).(*queueItem).commit
+	}
+
+	for _, commit := range commits {
+		if indegree[commit.Hash] == 1 {
+			put(commit)
+		}
+	}
+	slices.Reverse(stack)
+
+	var sorted []*types.CommitObject
+	for commit := get(); commit != nil; commit = get() {
+		for _, parent := range commit.Parents {
+			if indegree[parent] == 0 {
+				continue
+			}
+			indegree[parent]--
+			if indegree[parent] == 1 {
+				put(byHash[parent])
+			}
+		}
+		indegree[commit.Hash] = 0
+		sorted = append(sorted, commit)
+	}
+
+	return sorted
+}
+
+func (w *revWalker) push(hash string, flags int) {
+	current, known := w.flags[hash]
+	if current&flagSeen != 0 {
+		if flags&flagUninteresting != 0 && current&flagUninteresting == 0 {
+			w.flags[hash] |= flagUninteresting
+			if commit, err := ReadCommit(hash); err == nil {
+				w.markParentsUninteresting(commit)
+			}
+		}
+		return
+	}
+
+	commit, err := ReadCommit(hash)
+	if err != nil {
+		return
+	}
+
+	if !known {
+		current = 0
+	}
+	w.flags[hash] = current | flags | flagSeen
+	w.seq++
+	heap.Push(&w.queue, &queueItem{
+		commit: commit,
+		date:   utils.ParseSignature(commit.Committer).When,
+		seq:    w.seq,
+	})
+}
+
+func (w *revWalker) markParentsUninteresting(commit *types.CommitObject) {
+	stack := slices.Clone(commit.Parents)
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		flags := w.flags[hash]
+		if flags&flagUninteresting != 0 {
+			continue
+		}
+		w.flags[hash] = flags | flagUninteresting
+
+		// só desce por commits já visitados; os demais recebem a marca ao entrar na fila
+		if flags&flagSeen != 0 {
+			if parent, err := ReadCommit(hash); err == nil {
+				stack = append(stack, parent.Parents...)
+			}
+		}
+	}
+}
+
+func (w *revWalker) everybodyUninteresting() bool {
+	for _, item := range w.queue {
+		if w.flags[item.commit.Hash]&flagUninteresting == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchCommit(commit *types.CommitObject, options LogOptions) bool {
+	matchAny := func(patterns []*regexp.Regexp, value string) bool {
+		if len(patterns) == 0 {
+			return true
+		}
+		for _, re := range patterns {
+			if re.MatchString(value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	committerDate := utils.ParseSignature(commit.Committer).When
+	if !options.Since.IsZero() && committerDate.Before(options.Since) {
+		return false
+	}
+	if !options.Until.IsZero() && committerDate.After(options.Until) {
+		return false
+	}
+
+	authorLine, _, _ := strings.Cut(commit.Author, ">")
+	committerLine, _, _ := strings.Cut(commit.Committer, ">")
+	if !matchAny(options.Authors, authorLine+">") || !matchAny(options.Committers, committerLine+">") {
+		return false
+	}
+
+	return matchAny(options.Greps, commit.Message)
+}
+
+func limitCommits(commits []*types.CommitObject, options LogOptions) []*types.CommitObject {
+	var limited []*types.CommitObject
+	skipped := 0
+	for _, commit := range commits {
+		if options.MaxCount >= 0 && len(limited) >= options.MaxCount {
+			break
+		}
+
+		if skipped < options.Skip {
+			skipped++
+			continue
+		}
+
+		limited = append(limited, commit)
+	}
+
+	if options.Reverse {
+		slices.Reverse(limited)
+	}
+
+	return limited
+}
+
+type LogPrinter struct {
+	writer         io.Writer
+	options        LogOptions
+	graph          *CommitGraph
+	decorations    map[string][]refDecoration
+	shownOne       bool
+	missingNewline bool
+}
+
+func NewLogPrinter(w io.Writer, options LogOptions) *LogPrinter {
+	printer := &LogPrinter{writer: w, options: options}
+	if options.Decorate != "" || strings.Contains(options.UserFormat, "%d") || strings.Contains(options.UserFormat, "%D") {
+		printer.decorations = loadDecorations(options.Decorate == "full")
+	}
+
+	return printer
+}
+
+func (p *LogPrinter) Print(commit *types.CommitObject) {
+	w := p.writer
+	options := p.options
+	useTerminator := options.Format == "oneline" || (options.Format == "format" && options.Terminator)
+
+	if p.shownOne && !useTerminator {
+		if !p.missingNewline {
+			p.graph.ShowPadding()
+		}
+		fmt.Fprint(w, "\n")
+	}
+	p.shownOne = true
+
+	p.graph.ShowCommit()
+
+	hash := commit.Hash
+	if options.Abbrev {
+		hash = hash[:7]
+	}
+
+	yellow, reset := "", ""
+	if options.Color {
+		yellow, reset = "\033[33m", "\033[0m"
+	}
+
+	decoration := ""
+	if options.Decorate != "" {
+		decoration = formatDecorations(p.decorations[commit.Hash], " (", ")", options.Color)
+	}
+
+	author := utils.ParseSignature(commit.Author)
+	committer := utils.ParseSignature(commit.Committer)
+	message := strings.TrimRight(commit.Message, "\n")
+
+	var sb strings.Builder
+	switch options.Format {
+	case "oneline":
+		fmt.Fprintf(w, "%s%s%s%s ", yellow, hash, reset, decoration)
+		sb.WriteString(formatSubject(commit.Message))
+	case "format":
+		sb.WriteString(p.FormatCommit(commit, options.UserFormat))
+	case "raw":
+		fmt.Fprintf(w, "%scommit %s%s%s\n", yellow, commit.Hash, reset, decoration)
+		p.graph.ShowOneline()
+		fmt.Fprintf(&sb, "tree %s\n", commit.TreeHash)
+		for _, parent := range commit.Parents {
+			fmt.Fprintf(&sb, "parent %s\n", parent)
+		}
+		fmt.Fprintf(&sb, "author %s\n", commit.Author)
+		fmt.Fprintf(&sb, "committer %s\n\n", commit.Committer)
+		printIndentedMessage(&sb, message)
+	default:
+		fmt.Fprintf(w, "%scommit %s%s%s\n", yellow, hash, reset, decoration)
+		p.graph.ShowOneline()
+
+		if len(commit.Parents) > 1 {
+			var abbrevParents []string
+			for _, parent := range commit.Parents {
+				abbrevParents = append(abbrevParents, parent[:7])
+			}
+			fmt.Fprintf(&sb, "Merge: %s\n", strings.Join(abbrevParents, " "))
+		}
+
+		switch options.Format {
+		case "short":
+			fmt.Fprintf(&sb, "Author: %s <%s>\n\n", author.Name, author.Email)
+			subject, _, _ := strings.Cut(message, "\n\n")
+			printIndentedMessage(&sb, subject)
+		case "full":
+			fmt.Fprintf(&sb, "Author: %s <%s>\n", author.Name, author.Email)
+			fmt.Fprintf(&sb, "Commit: %s <%s>\n\n", committer.Name, committer.Email)
+			printIndentedMessage(&sb, message)
+		case "fuller":
+			fmt.Fprintf(&sb, "Author:     %s <%s>\n", author.Name, author.Email)
+			fmt.Fprintf(&sb, "AuthorDate: %s\n", utils.FormatDate(author.When, options.DateFormat))
+			fmt.Fprintf(&sb, "Commit:     %s <%s>\n", committer.Name, committer.Email)
+			fmt.Fprintf(&sb, "CommitDate: %s\n\n", utils.FormatDate(committer.When, options.DateFormat))
+			printIndentedMessage(&sb, message)
+		default:
+			fmt.Fprintf(&sb, "Author: %s <%s>\n", author.Name, author.Email)
+			fmt.Fprintf(&sb, "Date:   %s\n\n", utils.FormatDate(author.When, options.DateFormat))
+			printIndentedMessage(&sb, message)
+		}
+	}
+
+	msg := sb.String()
+	p.missingNewline = !strings.HasSuffix(msg, "\n")
+	p.graph.ShowCommitMessage(w, msg)
+
+	if useTerminator {
+		if !p.missingNewline {
+			p.graph.ShowPadding()
+		}
+		fmt.Fprint(w, "\n")
+	}
+
+	if options.Patch {
+		p.printPatch(commit)
+	}
+}
+
+func (p *LogPrinter) printPatch(commit *types.CommitObject) {
+	var buf bytes.Buffer
+	tree := readTreeEntries(commit.TreeHash)
+	combined := len(commit.Parents) > 1 && !p.options.FirstParent
+
+	if combined {
+		if !p.options.Combined {
+			return
+		}
+
+		var parentTrees []map[string]types.TreeEntry
+		for _, parent := range commit.Parents {
+			parentTrees = append(parentTrees, readCommitTreeEntries(parent))
+		}
+		WriteCombinedPatch(&buf, parentTrees, tree, p.options.Color)
+	} else {
+		parentTree := map[string]types.TreeEntry{}
+		if len(commit.Parents) > 0 {
+			parentTree = readCommitTreeEntries(commit.Parents[0])
+		}
+		WritePatch(&buf, DiffTrees(parentTree, tree), p.options.Color)
+	}
+
+	// o diff combinado sempre separa o cabeçalho, mesmo quando não há trechos
+	if buf.Len() == 0 && !combined {
+		return
+	}
+
+	if p.options.Format != "oneline" {
+		p.graph.ShowPadding()
+		fmt.Fprint(p.writer, "\n")
+	}
+
+	if buf.Len() == 0 {
+		return
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		p.graph.ShowPadding()
+		fmt.Fprintf(p.writer, "%s\n", line)
+	}
+}
+
+func formatSubject(message string) string {
+	paragraph, _, _ := strings.Cut(strings.TrimLeft(message, "\n"), "\n\n")
+
+	var lines []string
+	for _, line := range strings.Split(paragraph, "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			break
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, " ")
+}
+
+func printIndentedMessage(w io.Writer, message string) {
+	for _, line := range strings.Split(message, "\n") {
+		fmt.Fprintf(w, "    %s\n", line)
+	}
+}
+
+func (p *LogPrinter) FormatCommit(commit *types.CommitObject, format string) string {
+	var sb strings.Builder
+	options := p.options
+
+	author := utils.ParseSignature(commit.Author)
+	committer := utils.ParseSignature(commit.Committer)
+	subject := formatSubject(commit.Message)
+	_, body, _ := strings.Cut(commit.Message, "\n\n")
+	body = strings.TrimLeft(body, "\n")
+
+	abbrevList := func(hashes []string) string {
+		var abbrev []string
+		for _, hash := range hashes {
+			abbrev = append(abbrev, hash[:7])
+		}
+		return strings.Join(abbrev, " ")
+	}
+
+	personField := func(sig types.Signature, field byte) (string, bool) {
+		switch field {
+		case 'n':
+			return sig.Name, true
+		case 'e':
+			return sig.Email, true
+		case 'l':
+			local, _, _ := strings.Cut(sig.Email, "@")
+			return local, true
+		case 'd':
+			return utils.FormatDate(sig.When, options.DateFormat), true
+		case 'D':
+			return utils.FormatDate(sig.When, "rfc"), true
+		case 'r':
+			return utils.FormatDate(sig.When, "relative"), true
+		case 't':
+			return utils.FormatDate(sig.When, "unix"), true
+		case 'i':
+			return utils.FormatDate(sig.When, "iso"), true
+		case 'I':
+			return utils.FormatDate(sig.When, "iso-strict"), true
+		case 's':
+			return utils.FormatDate(sig.When, "short"), true
+		}
+		return "", false
+	}
+
+	colors := map[string]string{
+		"red": "\033[31m", "green": "\033[32m", "yellow": "\033[33m", "blue": "\033[34m",
+		"magenta": "\033[35m", "cyan": "\033[36m", "white": "\033[37m", "bold": "\033[1m",
+		"reset": "\033[0m",
+	}
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 >= len(format) {
+			sb.WriteByte(format[i])
+			continue
+		}
+
+		i++
+		switch c := format[i]; c {
+		case '%':
+			sb.WriteByte('%')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'H':
+			sb.WriteString(commit.Hash)
+		case 'h':
+			sb.WriteString(commit.Hash[:7])
+		case 'T':
+			sb.WriteString(commit.TreeHash)
+		case 't':
+			sb.WriteString(commit.TreeHash[:7])
+		case 'P':
+			sb.WriteString(strings.Join(commit.Parents, " "))
+		case 'p':
+			sb.WriteString(abbrevList(commit.Parents))
+		case 's':
+			sb.WriteString(subject)
+		case 'f':
+			sb.WriteString(strings.Trim(regexp.MustCompile(`[^A-Za-z0-9._]+`).ReplaceAllString(subject, "-"), "-."))
+		case 'b':
+			sb.WriteString(body)
+		case 'B':
+			sb.WriteString(commit.Message)
+		case 'd':
+			sb.WriteString(formatDecorations(p.decorations[commit.Hash], " (", ")", options.Color))
+		case 'D':
+			sb.WriteString(formatDecorations(p.decorations[commit.Hash], "", "", options.Color))
+		case 'a', 'c':
+			sig := author
+			if c == 'c' {
+				sig = committer
+			}
+			if i+1 < len(format) {
+				if value, ok := personField(sig, format[i+1]); ok {
+					sb.WriteString(value)
+					i++
+					continue
+				}
+			}
+			sb.WriteByte('%')
+			sb.WriteByte(c)
+		case 'x':
+			if i+2 < len(format) {
+				if b, err := strconv.ParseUint(format[i+1:i+3], 16, 8); err == nil {
+					sb.WriteByte(byte(b))
+					i += 2
+					continue
+				}
+			}
+			sb.WriteString("%x")
+		case 'C':
+			rest := format[i+1:]
+			matched := false
+			if strings.HasPrefix(rest, "(") {
+				if end := strings.IndexByte(rest, ')'); end > 0 {
+					if code, ok := colors[rest[1:end]]; ok && options.Color {
+						sb.WriteString(code)
+					}
+					i += end + 1
+					matched = true
+				}
+			} else {
+				for name, code := range colors {
+					if strings.HasPrefix(rest, name) && slices.Contains([]string{"red", "green", "blue", "reset"}, name) {
+						if options.Color {
+							sb.WriteString(code)
+						}
+						i += len(name)
+						matched = true
+						break
+					}
+				}
+			}
+			if !matched {
+				sb.WriteString("%C")
+			}
+		default:
+			sb.WriteByte('%')
+			sb.WriteByte(c)
+		}
+	}
+
+	return sb.String()
+}
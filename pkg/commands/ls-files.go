@@ -0,0 +1,168 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/types"
+	"github.com/codecrafters-io/git-starter-go/pkg/utils"
+)
+
+func lsFilesUsage(message string) {
+	if message != "" {
+		fmt.Fprintf(os.Stderr, "%s\n", message)
+	}
+
+	fmt.Fprintf(os.Stderr, "usage: ccgit ls-files [-c | --cached] [-d | --deleted] [-m | --modified] [-o | --others]\n")
+	fmt.Fprintf(os.Stderr, "                      [-i | --ignored] [-s | --stage] [-z] [--exclude-standard]\n")
+	fmt.Fprintf(os.Stderr, "                      [-x <pattern> | --exclude=<pattern>] [-X <file> | --exclude-from=<file>]\n")
+	fmt.Fprintf(os.Stderr, "                      [--exclude-per-directory=<file>] [--] [<file>...]\n")
+	os.Exit(129)
+}
+
+func LsFiles(args ...string) {
+	var cached, deleted, modified, others, ignored, stage bool
+	terminator := "\n"
+	ignore := utils.NewIgnore()
+	var pathspecs []string
+
+	for i := 2; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-c" || arg == "--cached":
+			cached = true
+		case arg == "-d" || arg == "--deleted":
+			deleted = true
+		case arg == "-m" || arg == "--modified":
+			modified = true
+		case arg == "-o" || arg == "--others":
+			others = true
+		case arg == "-i" || arg == "--ignored":
+			ignored = true
+		case arg == "-s" || arg == "--stage":
+			stage = true
+		case arg == "-z":
+			terminator = "\x00"
+		case arg == "--exclude-standard":
+			ignore.AddStandard()
+		case arg == "-x" || arg == "--exclude", arg == "-X" || arg == "--exclude-from":
+			if i+1 >= len(args) {
+				lsFilesUsage(fmt.Sprintf("error: option `%s' requires a value", strings.TrimLeft(arg, "-")))
+			}
+			i++
+			addExclude(ignore, arg == "-x" || arg == "--exclude", args[i])
+		case strings.HasPrefix(arg, "--exclude="):
+			addExclude(ignore, true, strings.TrimPrefix(arg, "--exclude="))
+		case strings.HasPrefix(arg, "--exclude-from="):
+			addExclude(ignore, false, strings.TrimPrefix(arg, "--exclude-from="))
+		case strings.HasPrefix(arg, "--exclude-per-directory="):
+			ignore.PerDirectory = strings.TrimPrefix(arg, "--exclude-per-directory=")
+		case arg == "--":
+			pathspecs = append(pathspecs, args[i+1:]...)
+			i = len(args)
+		case strings.HasPrefix(arg, "-"):
+			lsFilesUsage(fmt.Sprintf("error: unknown option `%s'", strings.TrimLeft(arg, "-")))
+		default:
+			pathspecs = append(pathspecs, arg)
+		}
+	}
+
+	if ignored && !cached && !others {
+		fmt.Fprintf(os.Stderr, "fatal: ls-files -i must be used with either -o or -c\n")
+		os.Exit(128)
+	}
+	if !cached && !deleted && !modified && !others || stage {
+		cached = true
+	}
+	if ignored && ignore.Empty() {
+		fmt.Fprintf(os.Stderr, "fatal: ls-files --ignored needs some exclude pattern\n")
+		os.Exit(128)
+	}
+
+	matches := func(path string) bool {
+		if len(pathspecs) == 0 {
+			return true
+		}
+		return slices.ContainsFunc(pathspecs, func(spec string) bool {
+			spec = strings.TrimSuffix(filepath.ToSlash(filepath.Clean(spec)), "/")
+			return spec == "." || path == spec || strings.HasPrefix(path, spec+"/")
+		})
+	}
+
+	entries := ReadIndex().Entries
+
+	// os não rastreados vêm antes, como no git
+	if others {
+		tracked := map[string]bool{}
+		for _, entry := range entries {
+			tracked[entry.Path] = true
+		}
+
+		var skip func(string, bool) bool
+		if !ignored {
+			skip = skipIgnored(ignore, entries)
+		}
+		dirTree, _ := utils.GetDirTree(".", skip, false)
+		slices.Sort(dirTree)
+		for _, path := range dirTree {
+			path = filepath.ToSlash(path)
+			if tracked[path] || !matches(path) {
+				continue
+			}
+			if ignored && !ignore.IsIgnored(path, false) {
+				continue
+			}
+			fmt.Printf("%s%s", path, terminator)
+		}
+	}
+
+	for _, entry := range entries {
+		if !matches(entry.Path) {
+			continue
+		}
+
+		if cached && (!ignored || ignore.IsIgnored(entry.Path, false)) {
+			printLsFilesEntry(entry, stage, terminator)
+		}
+
+		if !deleted && !modified {
+			continue
+		}
+		_, err := os.Lstat(entry.Path)
+		if deleted && err != nil {
+			printLsFilesEntry(entry, stage, terminator)
+		}
+		if modified && (err != nil || worktreeChanged(entry)) {
+			printLsFilesEntry(entry, stage, terminator)
+		}
+	}
+}
+
+func addExclude(ignore *utils.Ignore, pattern bool, value string) {
+	if pattern {
+		ignore.AddPattern(value)
+		return
+	}
+
+	if err := ignore.AddFile(value); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: cannot use %s as an exclude file\n", value)
+		os.Exit(128)
+	}
+}
+
+func printLsFilesEntry(entry types.Entry, stage bool, terminator string) {
+	if stage {
+		fmt.Printf("%06o %x %d\t%s%s", entry.Mode, entry.SHA1[:], entry.Stage, entry.Path, terminator)
+		return
+	}
+
+	fmt.Printf("%s%s", entry.Path, terminator)
+}
+
+func worktreeChanged(entry types.Entry) bool {
+	hash, _, _ := worktreeBlob(entry.Path, false)
+	return fmt.Sprintf("%x", hash[:]) != fmt.Sprintf("%x", entry.SHA1[:])
+}
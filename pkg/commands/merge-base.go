@@ -0,0 +1,346 @@
+package commands
+
+import (
+	"container/heap"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/utils"
+)
+
+const (
+	flagParent1 = 1 << iota
+	flagParent2
+	flagStale
+	flagResult
+)
+
+func MergeBase(args ...string) {
+	err := utils.CheckGitRepo(".", false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	var all, isAncestor, octopus, forkPoint, independent bool
+	var revs []string
+
+	for _, arg := range args[2:] {
+		switch arg {
+		case "-a", "--all":
+			all = true
+		case "--is-ancestor":
+			isAncestor = true
+		case "--octopus":
+			octopus = true
+		case "--fork-point":
+			forkPoint = true
+		case "--independent":
+			independent = true
+		default:
+			if strings.HasPrefix(arg, "-") {
+				fmt.Fprintf(os.Stderr, "error: unknown option `%s'\n", strings.TrimLeft(arg, "-"))
+				fmt.Fprintf(os.Stderr, "usage: ccgit merge-base [-a | --all] <commit> <commit>...\n")
+				fmt.Fprintf(os.Stderr, "   or: ccgit merge-base [-a | --all] --octopus <commit>...\n")
+				fmt.Fprintf(os.Stderr, "   or: ccgit merge-base --independent <commit>...\n")
+				fmt.Fprintf(os.Stderr, "   or: ccgit merge-base --is-ancestor <commit> <commit>\n")
+				fmt.Fprintf(os.Stderr, "   or: ccgit merge-base --fork-point <ref> [<commit>]\n")
+				os.Exit(129)
+			}
+			revs = append(revs, arg)
+		}
+	}
+
+	modes := 0
+	for _, mode := range []bool{isAncestor, octopus, forkPoint, independent} {
+		if mode {
+			modes++
+		}
+	}
+	if modes > 1 {
+		fmt.Fprintf(os.Stderr, "fatal: options '--is-ancestor', '--octopus', '--fork-point' and '--independent' cannot be used together\n")
+		os.Exit(128)
+	}
+
+	if forkPoint {
+		if len(revs) < 1 || len(revs) > 2 {
+			fmt.Fprintf(os.Stderr, "usage: ccgit merge-base --fork-point <ref> [<commit>]\n")
+			os.Exit(129)
+		}
+		commit := "HEAD"
+		if len(revs) == 2 {
+			commit = revs[1]
+		}
+		base, ok := ForkPoint(revs[0], mustResolveCommit(commit))
+		if !ok {
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stdout, "%s\n", base)
+		return
+	}
+
+	var hashes []string
+	for _, rev := range revs {
+		hashes = append(hashes, mustResolveCommit(rev))
+	}
+
+	var bases []string
+	switch {
+	case isAncestor:
+		if len(hashes) != 2 {
+			fmt.Fprintf(os.Stderr, "fatal: --is-ancestor takes exactly two commits\n")
+			os.Exit(128)
+		}
+		if !IsAncestor(hashes[0], hashes[1]) {
+			os.Exit(1)
+		}
+		return
+	case independent:
+		bases = ReduceHeads(hashes)
+		all = true
+	case octopus:
+		bases = ReduceHeads(OctopusMergeBases(hashes))
+	default:
+		if len(hashes) < 2 {
+			fmt.Fprintf(os.Stderr, "usage: ccgit merge-base [-a | --all] <commit> <commit>...\n")
+			os.Exit(129)
+		}
+		bases = MergeBases(hashes[0], hashes[1:]...)
+	}
+
+	if len(bases) == 0 {
+		os.Exit(1)
+	}
+
+	if !all {
+		bases = bases[:1]
+	}
+	for _, base := range bases {
+		fmt.Fprintf(os.Stdout, "%s\n", base)
+	}
+}
+
+func mustResolveCommit(rev string) string {
+	hash, err := ResolveCommit(rev)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: Not a valid object name %s\n", rev)
+		os.Exit(128)
+	}
+
+	return hash
+}
+
+// pinta os ancestrais de one e de twos; quem recebe as duas marcas sem estar obsoleto é candidato
+func paintDownToCommon(one string, twos []string) (map[string]int, []string) {
+	flags := map[string]int{}
+	var queue commitQueue
+	seq := 0
+
+	push := func(hash string) {
+		commit, err := ReadCommit(hash)
+		if err != nil {
+			return
+		}
+		seq++
+		heap.Push(&queue, &queueItem{commit: commit, date: utils.ParseSignature(commit.Committer).When, seq: seq})
+	}
+
+	flags[one] |= flagParent1
+	push(one)
+	for _, two := range twos {
+		flags[two] |= flagParent2
+		push(two)
+	}
+
+	queueHasNonStale := func() bool {
+		for _, item := range queue {
+			if flags[item.commit.Hash]&flagStale == 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	var result []string
+	for queueHasNonStale() {
+		commit := heap.Pop(&queue).(*queueItem).commit
+		current := flags[commit.Hash] & (flagParent1 | flagParent2 | flagStale)
+
+		if current == flagParent1|flagParent2 {
+			if flags[commit.Hash]&flagResult == 0 {
+				flags[commit.Hash] |= flagResult
+				result = append(result, commit.Hash)
+			}
+			current |= flagStale
+		}
+
+		for _, parent := range commit.Parents {
+			if flags[parent]&current == current {
+				continue
+			}
+			flags[parent] |= current
+			push(parent)
+		}
+	}
+
+	return flags, result
+}
+
+func mergeBasesDirty(one string, twos []string) []string {
+	if slices.Contains(twos, one) {
+		return []string{one}
+	}
+
+	flags, candidates := paintDownToCommon(one, twos)
+
+	var result []string
+	for _, hash := range candidates {
+		if flags[hash]&flagStale == 0 {
+			result = append(result, hash)
+		}
+	}
+
+	return sortByCommitDate(result)
+}
+
+func MergeBases(one string, twos ...string) []string {
+	return removeRedundant(mergeBasesDirty(one, twos))
+}
+
+func removeRedundant(hashes []string) []string {
+	if len(hashes) <= 1 {
+		return hashes
+	}
+
+	redundant := make([]bool, len(hashes))
+	for i := range hashes {
+		if redundant[i] {
+			continue
+		}
+
+		var others []string
+		var otherIndexes []int
+		for j := range hashes {
+			if i != j && !redundant[j] {
+				others = append(others, hashes[j])
+				otherIndexes = append(otherIndexes, j)
+			}
+		}
+
+		flags, _ := paintDownToCommon(hashes[i], others)
+		if flags[hashes[i]]&flagParent2 != 0 {
+			redundant[i] = true
+		}
+		for k, j := range otherIndexes {
+			if flags[others[k]]&flagParent1 != 0 {
+				redundant[j] = true
+			}
+		}
+	}
+
+	var result []string
+	for i, hash := range hashes {
+		if !redundant[i] {
+			result = append(result, hash)
+		}
+	}
+
+	return result
+}
+
+func sortByCommitDate(hashes []string) []string {
+	dates := map[string]int64{}
+	for _, hash := range hashes {
+		if commit, err := ReadCommit(hash); err == nil {
+			dates[hash] = utils.ParseSignature(commit.Committer).When.Unix()
+		}
+	}
+
+	slices.SortStableFunc(hashes, func(a, b string) int {
+		switch {
+		case dates[a] > dates[b]:
+			return -1
+		case dates[a] < dates[b]:
+			return 1
+		}
+		return 0
+	})
+
+	return hashes
+}
+
+func IsAncestor(ancestor string, descendant string) bool {
+	if ancestor == descendant {
+		return true
+	}
+
+	flags, _ := paintDownToCommon(ancestor, []string{descendant})
+	return flags[ancestor]&flagParent2 != 0
+}
+
+func OctopusMergeBases(hashes []string) []string {
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	result := []string{hashes[0]}
+	for _, hash := range hashes[1:] {
+		var next []string
+		for _, base := range result {
+			next = append(next, MergeBases(hash, base)...)
+		}
+		result = next
+	}
+
+	return result
+}
+
+func ReduceHeads(hashes []string) []string {
+	var unique []string
+	for _, hash := range hashes {
+		if !slices.Contains(unique, hash) {
+			unique = append(unique, hash)
+		}
+	}
+
+	return removeRedundant(unique)
+}
+
+func ForkPoint(ref string, commit string) (string, bool) {
+	refName := dwimRef(ref)
+	if refName == "" {
+		fmt.Fprintf(os.Stderr, "fatal: Not a valid object name: '%s'\n", ref)
+		os.Exit(128)
+	}
+
+	var candidates []string
+	addCandidate := func(hash string) {
+		if hash == "" || strings.Trim(hash, "0") == "" || slices.Contains(candidates, hash) {
+			return
+		}
+		if ObjectKind(hash) == "commit" {
+			candidates = append(candidates, hash)
+		}
+	}
+
+	for i, entry := range utils.ReadReflog(refName) {
+		if i == 0 {
+			addCandidate(entry.Old)
+		}
+		addCandidate(entry.New)
+	}
+	if len(candidates) == 0 {
+		if hash, err := utils.ResolveRef(refName); err == nil {
+			addCandidate(hash)
+		}
+	}
+
+	bases := mergeBasesDirty(commit, candidates)
+	if len(bases) != 1 || !slices.Contains(candidates, bases[0]) {
+		return "", false
+	}
+
+	return bases[0], true
+}
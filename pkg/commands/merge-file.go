@@ -0,0 +1,557 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	mergeFavorOurs   = 1
+	mergeFavorTheirs = 2
+	mergeFavorUnion  = 3
+	mergeIdentical   = 4
+)
+
+type MergeFileOptions struct {
+	OursLabel   string
+	BaseLabel   string
+	TheirsLabel string
+	Style       string
+	Favor       int
+	MarkerSize  int
+}
+
+type diffHunk struct {
+	i1, chg1, i2, chg2 int
+}
+
+// i0 = base, i1 = ours, i2 = theirs; mode 0 é conflito, 1/2 lado que mudou
+type mergeChunk struct {
+	mode                         int
+	i0, chg0, i1, chg1, i2, chg2 int
+}
+
+func MergeFile(args ...string) {
+	options := MergeFileOptions{MarkerSize: 7}
+	var labels, files []string
+	stdout := false
+	quiet := false
+
+	for i := 2; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-L":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "error: switch `L' requires a value\n")
+				os.Exit(129)
+			}
+			labels = append(labels, args[i+1])
+			i++
+		case arg == "-p" || arg == "--stdout":
+			stdout = true
+		case arg == "-q" || arg == "--quiet":
+			quiet = true
+		case arg == "--diff3":
+			options.Style = "diff3"
+		case arg == "--zdiff3":
+			options.Style = "zdiff3"
+		case arg == "--no-diff3":
+			options.Style = ""
+		case arg == "--ours":
+			options.Favor = mergeFavorOurs
+		case arg == "--theirs":
+			options.Favor = mergeFavorTheirs
+		case arg == "--union":
+			options.Favor = mergeFavorUnion
+		case strings.HasPrefix(arg, "--marker-size="):
+			size, err := strconv.Atoi(strings.TrimPrefix(arg, "--marker-size="))
+			if err != nil || size <= 0 {
+				fmt.Fprintf(os.Stderr, "error: option `marker-size' expects a numerical value\n")
+				os.Exit(129)
+			}
+			options.MarkerSize = size
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			fmt.Fprintf(os.Stderr, "error: unknown option `%s'\n", strings.TrimLeft(arg, "-"))
+			fmt.Fprintf(os.Stderr, "usage: ccgit merge-file [<options>] [-L <name1> [-L <orig> [-L <name2>]]] <file1> <orig-file> <file2>\n")
+			os.Exit(129)
+		default:
+			files = append(files, arg)
+		}
+	}
+
+	if len(files) != 3 || len(labels) > 3 {
+		fmt.Fprintf(os.Stderr, "usage: ccgit merge-file [<options>] [-L <name1> [-L <orig> [-L <name2>]]] <file1> <orig-file> <file2>\n")
+		os.Exit(129)
+	}
+
+	if quiet {
+		os.Stderr, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	}
+
+	names := []*string{&options.OursLabel, &options.BaseLabel, &options.TheirsLabel}
+	var contents [][]byte
+	for i, file := range files {
+		*names[i] = file
+		if i < len(labels) {
+			*names[i] = labels[i]
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: Could not stat %s\n", file)
+			os.Exit(255)
+		}
+		if isBinaryContent(content) {
+			fmt.Fprintf(os.Stderr, "error: Cannot merge binary files: %s\n", file)
+			os.Exit(255)
+		}
+		contents = append(contents, content)
+	}
+
+	result, conflicts := MergeContent(contents[0], contents[1], contents[2], options)
+
+	if stdout {
+		os.Stdout.Write(result)
+	} else if err := os.WriteFile(files[0], result, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "error: Could not open %s for writing\n", files[0])
+		os.Exit(255)
+	}
+
+	os.Exit(min(conflicts, 127))
+}
+
+func MergeContent(ours []byte, base []byte, theirs []byte, options MergeFileOptions) ([]byte, int) {
+	if options.MarkerSize == 0 {
+		options.MarkerSize = 7
+	}
+
+	baseLines := splitLines(base)
+	oursLines := splitLines(ours)
+	theirsLines := splitLines(theirs)
+
+	chunks := mergeChunks(baseLines, oursLines, theirsLines)
+
+	switch options.Style {
+	case "zdiff3":
+		refineZdiff3Conflicts(chunks, oursLines, theirsLines)
+	case "diff3":
+		// o diff3 precisa da base inteira de cada conflito, então não refina
+	default:
+		chunks = refineConflicts(chunks, oursLines, theirsLines)
+		chunks = simplifyNonConflicts(chunks, oursLines)
+	}
+
+	var out bytes.Buffer
+	conflicts := 0
+	i := 0
+
+	copyLines := func(lines []string, addNewline bool) {
+		for j, line := range lines {
+			out.WriteString(line)
+			if addNewline && j == len(lines)-1 && !strings.HasSuffix(line, "\n") {
+				out.WriteString("\n")
+			}
+		}
+	}
+	marker := func(c byte, label string) {
+		out.WriteString(strings.Repeat(string(c), options.MarkerSize))
+		if label != "" {
+			out.WriteString(" " + label)
+		}
+		out.WriteString("\n")
+	}
+
+	for _, chunk := range chunks {
+		if options.Favor != 0 && chunk.mode == 0 {
+			chunk.mode = options.Favor
+		}
+
+		switch {
+		case chunk.mode == 0:
+			conflicts++
+			copyLines(oursLines[i:chunk.i1], false)
+			marker('<', options.OursLabel)
+			copyLines(oursLines[chunk.i1:chunk.i1+chunk.chg1], true)
+			if options.Style == "diff3" || options.Style == "zdiff3" {
+				marker('|', options.BaseLabel)
+				copyLines(baseLines[chunk.i0:chunk.i0+chunk.chg0], true)
+			}
+			marker('=', "")
+			copyLines(theirsLines[chunk.i2:chunk.i2+chunk.chg2], true)
+			marker('>', options.TheirsLabel)
+		case chunk.mode&mergeFavorUnion != 0:
+			copyLines(oursLines[i:chunk.i1], false)
+			if chunk.mode&mergeFavorOurs != 0 {
+				copyLines(oursLines[chunk.i1:chunk.i1+chunk.chg1], chunk.mode&mergeFavorTheirs != 0)
+			}
+			if chunk.mode&mergeFavorTheirs != 0 {
+				copyLines(theirsLines[chunk.i2:chunk.i2+chunk.chg2], false)
+			}
+		default:
+			continue
+		}
+		i = chunk.i1 + chunk.chg1
+	}
+	copyLines(oursLines[i:], false)
+
+	return out.Bytes(), conflicts
+}
+
+func diffHunks(a []string, b []string) []diffHunk {
+	// marcas de mudança com sentinelas nas pontas, como no xdiff
+	changedA := make([]bool, len(a)+2)
+	changedB := make([]bool, len(b)+2)
+	i1, i2 := 0, 0
+	for _, edit := range myersDiff(a, b) {
+		switch edit.Type {
+		case "equal":
+			i1++
+			i2++
+		case "delete":
+			changedA[i1+1] = true
+			i1++
+		default:
+			changedB[i2+1] = true
+			i2++
+		}
+	}
+
+	compactChanges(a, changedA, changedB)
+	compactChanges(b, changedB, changedA)
+
+	var hunks []diffHunk
+	i1, i2 = 0, 0
+	for i1 < len(a) || i2 < len(b) {
+		if !changedA[i1+1] && !changedB[i2+1] {
+			i1++
+			i2++
+			continue
+		}
+
+		hunk := diffHunk{i1: i1, i2: i2}
+		for i1 < len(a) && changedA[i1+1] {
+			i1++
+		}
+		for i2 < len(b) && changedB[i2+1] {
+			i2++
+		}
+		hunk.chg1 = i1 - hunk.i1
+		hunk.chg2 = i2 - hunk.i2
+		hunks = append(hunks, hunk)
+	}
+
+	return hunks
+}
+
+type changeGroup struct {
+	start, end int
+}
+
+func (g *changeGroup) init(changed []bool) {
+	g.start, g.end = 0, 0
+	for changed[g.end+1] {
+		g.end++
+	}
+}
+
+func (g *changeGroup) next(changed []bool) bool {
+	if g.end == len(changed)-2 {
+		return false
+	}
+	g.start = g.end + 1
+	for g.end = g.start; changed[g.end+1]; g.end++ {
+	}
+	return true
+}
+
+func (g *changeGroup) previous(changed []bool) bool {
+	if g.start == 0 {
+		return false
+	}
+	g.end = g.start - 1
+	for g.start = g.end; changed[g.start]; g.start-- {
+	}
+	return true
+}
+
+func (g *changeGroup) slideDown(lines []string, changed []bool) bool {
+	if g.end >= len(lines) || lines[g.start] != lines[g.end] {
+		return false
+	}
+	changed[g.start+1] = false
+	changed[g.end+1] = true
+	g.start++
+	g.end++
+	for changed[g.end+1] {
+		g.end++
+	}
+	return true
+}
+
+func (g *changeGroup) slideUp(lines []string, changed []bool) bool {
+	if g.start == 0 || lines[g.start-1] != lines[g.end-1] {
+		return false
+	}
+	g.start--
+	g.end--
+	changed[g.start+1] = true
+	changed[g.end+1] = false
+	for changed[g.start] {
+		g.start--
+	}
+	return true
+}
+
+// desliza cada grupo de mudanças para baixo o quanto der, ou até alinhar com uma mudança do outro lado
+func compactChanges(lines []string, changed []bool, otherChanged []bool) {
+	var g, other changeGroup
+	g.init(changed)
+	other.init(otherChanged)
+
+	for {
+		if g.end != g.start {
+			var earliestEnd int
+			endMatchingOther := -1
+			for {
+				size := g.end - g.start
+				endMatchingOther = -1
+
+				for g.slideUp(lines, changed) {
+					other.previous(otherChanged)
+				}
+				earliestEnd = g.end
+				if other.end > other.start {
+					endMatchingOther = g.end
+				}
+
+				for g.slideDown(lines, changed) {
+					other.next(otherChanged)
+					if other.end > other.start {
+						endMatchingOther = g.end
+					}
+				}
+
+				if size == g.end-g.start {
+					break
+				}
+			}
+
+			if g.end != earliestEnd && endMatchingOther != -1 {
+				for other.end == other.start {
+					g.slideUp(lines, changed)
+					other.previous(otherChanged)
+				}
+			}
+		}
+
+		if !g.next(changed) {
+			break
+		}
+		other.next(otherChanged)
+	}
+}
+
+func appendMergeChunk(chunks []mergeChunk, chunk mergeChunk) []mergeChunk {
+	if len(chunks) > 0 {
+		last := &chunks[len(chunks)-1]
+		if chunk.i1 <= last.i1+last.chg1 || chunk.i2 <= last.i2+last.chg2 {
+			if chunk.mode != last.mode {
+				last.mode = 0
+			}
+			last.chg0 = chunk.i0 + chunk.chg0 - last.i0
+			last.chg1 = chunk.i1 + chunk.chg1 - last.i1
+			last.chg2 = chunk.i2 + chunk.chg2 - last.i2
+			return chunks
+		}
+	}
+
+	return append(chunks, chunk)
+}
+
+func equalLines(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func mergeChunks(base []string, ours []string, theirs []string) []mergeChunk {
+	hunks1 := diffHunks(base, ours)
+	hunks2 := diffHunks(base, theirs)
+
+	var chunks []mergeChunk
+	for len(hunks1) > 0 && len(hunks2) > 0 {
+		x1, x2 := hunks1[0], hunks2[0]
+
+		if x1.i1+x1.chg1 < x2.i1 {
+			chunks = appendMergeChunk(chunks, mergeChunk{
+				mode: mergeFavorOurs,
+				i0:   x1.i1, chg0: x1.chg1,
+				i1: x1.i2, chg1: x1.chg2,
+				i2: x2.i2 - x2.i1 + x1.i1, chg2: x1.chg1,
+			})
+			hunks1 = hunks1[1:]
+			continue
+		}
+
+		if x2.i1+x2.chg1 < x1.i1 {
+			chunks = appendMergeChunk(chunks, mergeChunk{
+				mode: mergeFavorTheirs,
+				i0:   x2.i1, chg0: x2.chg1,
+				i1: x1.i2 - x1.i1 + x2.i1, chg1: x2.chg1,
+				i2: x2.i2, chg2: x2.chg2,
+			})
+			hunks2 = hunks2[1:]
+			continue
+		}
+
+		if x1.i1 != x2.i1 || x1.chg1 != x2.chg1 || x1.chg2 != x2.chg2 ||
+			!equalLines(ours[x1.i2:x1.i2+x1.chg2], theirs[x2.i2:x2.i2+x2.chg2]) {
+			// as mudanças se sobrepõem: o conflito cobre a união das duas regiões
+			off := x1.i1 - x2.i1
+			ffo := off + x1.chg1 - x2.chg1
+
+			i0, i1, i2 := x1.i1, x1.i2, x2.i2
+			if off > 0 {
+				i0 -= off
+				i1 -= off
+			} else {
+				i2 += off
+			}
+			chg0 := x1.i1 + x1.chg1 - i0
+			chg1 := x1.i2 + x1.chg2 - i1
+			chg2 := x2.i2 + x2.chg2 - i2
+			if ffo < 0 {
+				chg0 -= ffo
+				chg1 -= ffo
+			} else {
+				chg2 += ffo
+			}
+
+			chunks = appendMergeChunk(chunks, mergeChunk{mode: 0, i0: i0, chg0: chg0, i1: i1, chg1: chg1, i2: i2, chg2: chg2})
+		}
+
+		end1 := x1.i1 + x1.chg1
+		end2 := x2.i1 + x2.chg1
+		if end1 >= end2 {
+			hunks2 = hunks2[1:]
+		}
+		if end2 >= end1 {
+			hunks1 = hunks1[1:]
+		}
+	}
+
+	for _, x1 := range hunks1 {
+		chunks = appendMergeChunk(chunks, mergeChunk{
+			mode: mergeFavorOurs,
+			i0:   x1.i1, chg0: x1.chg1,
+			i1: x1.i2, chg1: x1.chg2,
+			i2: x1.i1 + len(theirs) - len(base), chg2: x1.chg1,
+		})
+	}
+	for _, x2 := range hunks2 {
+		chunks = appendMergeChunk(chunks, mergeChunk{
+			mode: mergeFavorTheirs,
+			i0:   x2.i1, chg0: x2.chg1,
+			i1: x2.i1 + len(ours) - len(base), chg1: x2.chg1,
+			i2: x2.i2, chg2: x2.chg2,
+		})
+	}
+
+	return chunks
+}
+
+func refineConflicts(chunks []mergeChunk, ours []string, theirs []string) []mergeChunk {
+	var refined []mergeChunk
+	for _, chunk := range chunks {
+		if chunk.mode != 0 || chunk.chg1 == 0 || chunk.chg2 == 0 {
+			refined = append(refined, chunk)
+			continue
+		}
+
+		hunks := diffHunks(ours[chunk.i1:chunk.i1+chunk.chg1], theirs[chunk.i2:chunk.i2+chunk.chg2])
+		if len(hunks) == 0 {
+			chunk.mode = mergeIdentical
+			refined = append(refined, chunk)
+			continue
+		}
+
+		for _, hunk := range hunks {
+			piece := chunk
+			piece.i1 = chunk.i1 + hunk.i1
+			piece.chg1 = hunk.chg1
+			piece.i2 = chunk.i2 + hunk.i2
+			piece.chg2 = hunk.chg2
+			refined = append(refined, piece)
+		}
+	}
+
+	return refined
+}
+
+func simplifyNonConflicts(chunks []mergeChunk, ours []string) []mergeChunk {
+	if len(chunks) == 0 {
+		return chunks
+	}
+
+	simplified := []mergeChunk{chunks[0]}
+	for _, next := range chunks[1:] {
+		last := &simplified[len(simplified)-1]
+		begin := last.i1 + last.chg1
+		end := next.i1
+
+		if last.mode != 0 || next.mode != 0 || (end-begin > 3 && linesContainAlnum(ours[begin:end])) {
+			simplified = append(simplified, next)
+			continue
+		}
+
+		// conflitos separados por poucas linhas viram um só
+		last.chg0 = next.i0 + next.chg0 - last.i0
+		last.chg1 = next.i1 + next.chg1 - last.i1
+		last.chg2 = next.i2 + next.chg2 - last.i2
+	}
+
+	return simplified
+}
+
+func linesContainAlnum(lines []string) bool {
+	for _, line := range lines {
+		for _, r := range line {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func refineZdiff3Conflicts(chunks []mergeChunk, ours []string, theirs []string) {
+	for i := range chunks {
+		chunk := &chunks[i]
+		if chunk.mode != 0 {
+			continue
+		}
+
+		for chunk.chg1 > 0 && chunk.chg2 > 0 && ours[chunk.i1] == theirs[chunk.i2] {
+			chunk.i1++
+			chunk.i2++
+			chunk.chg1--
+			chunk.chg2--
+		}
+		for chunk.chg1 > 0 && chunk.chg2 > 0 && ours[chunk.i1+chunk.chg1-1] == theirs[chunk.i2+chunk.chg2-1] {
+			chunk.chg1--
+			chunk.chg2--
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/utils"
+)
+
+func MergeTree(args ...string) {
+	err := utils.CheckGitRepo(".", false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	nameOnly := false
+	showMessages := -1
+	allowUnrelated := false
+	terminator := "\n"
+	var revs []string
+
+	for _, arg := range args[2:] {
+		switch arg {
+		case "--write-tree":
+		case "--name-only":
+			nameOnly = true
+		case "--messages":
+			showMessages = 1
+		case "--no-messages":
+			showMessages = 0
+		case "-z":
+			terminator = "\x00"
+		case "--allow-unrelated-histories":
+			allowUnrelated = true
+		default:
+			if strings.HasPrefix(arg, "-") {
+				fmt.Fprintf(os.Stderr, "error: unknown option `%s'\n", strings.TrimLeft(arg, "-"))
+				fmt.Fprintf(os.Stderr, "usage: ccgit merge-tree [--write-tree] [<options>] <branch1> <branch2>\n")
+				os.Exit(129)
+			}
+			revs = append(revs, arg)
+		}
+	}
+
+	if len(revs) != 2 {
+		fmt.Fprintf(os.Stderr, "usage: ccgit merge-tree [--write-tree] [<options>] <branch1> <branch2>\n")
+		os.Exit(129)
+	}
+
+	ours := mustResolveCommit(revs[0])
+	theirs := mustResolveCommit(revs[1])
+	if !allowUnrelated && len(MergeBases(ours, theirs)) == 0 {
+		fmt.Fprintf(os.Stderr, "fatal: refusing to merge unrelated histories\n")
+		os.Exit(128)
+	}
+
+	result := MergeCommits(ours, theirs, MergeOptions{OursLabel: revs[0], TheirsLabel: revs[1], Style: mergeConflictStyle()})
+	treeHash := BuildTree(result.Tree)
+
+	if showMessages == -1 {
+		showMessages = 1
+		if result.Clean() {
+			showMessages = 0
+		}
+	}
+
+	fmt.Fprintf(os.Stdout, "%x%s", treeHash, terminator)
+	for _, path := range result.UnmergedPaths() {
+		if nameOnly {
+			fmt.Fprintf(os.Stdout, "%s%s", path, terminator)
+			continue
+		}
+
+		for i, stage := range result.Unmerged[path] {
+			if stage != nil {
+				fmt.Fprintf(os.Stdout, "%s %x %d\t%s%s", stage.Mode, stage.Hash, i+1, path, terminator)
+			}
+		}
+	}
+
+	if showMessages == 1 {
+		fmt.Fprint(os.Stdout, terminator)
+		if terminator == "\n" {
+			result.PrintMessages(os.Stdout)
+		} else {
+			// formato estruturado: quantidade de caminhos, caminhos, tipo e mensagem
+			for _, message := range result.sortedMessages() {
+				fmt.Fprintf(os.Stdout, "%d\x00%s\x00%s\x00%s\n\x00", len(message.paths), strings.Join(message.paths, "\x00"), message.kind, message.text)
+			}
+		}
+	}
+
+	if !result.Clean() {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,1100 @@
+package commands
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/config"
+	"github.com/codecrafters-io/git-starter-go/pkg/types"
+	"github.com/codecrafters-io/git-starter-go/pkg/utils"
+)
+
+const (
+	mergeOriginOurs   = 1
+	mergeOriginTheirs = 2
+	mergeOriginBoth   = 3
+)
+
+type MergeOptions struct {
+	OursLabel   string
+	TheirsLabel string
+	BaseLabel   string
+	Style       string
+	Favor       int
+	NoRenames   bool
+	RenameScore int
+	virtual     bool
+}
+
+type MergeResult struct {
+	Tree     map[string]types.TreeEntry
+	Unmerged map[string][3]*types.TreeEntry
+	messages []mergeMessage
+}
+
+type mergeMessage struct {
+	kind  string
+	paths []string
+	text  string
+}
+
+func Merge(args ...string) {
+	err := utils.CheckGitRepo(".", false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	fastForward := "allow"
+	commit := true
+	quiet := false
+	allowUnrelated := false
+	options := MergeOptions{OursLabel: "HEAD", Style: mergeConflictStyle()}
+	var message string
+	var names []string
+	var action string
+
+	for i := 2; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "--abort", "--continue", "--quit":
+			action = strings.TrimPrefix(arg, "--")
+		case "--ff":
+			fastForward = "allow"
+		case "--no-ff":
+			fastForward = "never"
+		case "--ff-only":
+			fastForward = "only"
+		case "--commit":
+			commit = true
+		case "--no-commit":
+			commit = false
+		case "--edit", "-e", "--no-edit":
+		case "-q", "--quiet":
+			quiet = true
+		case "--allow-unrelated-histories":
+			allowUnrelated = true
+		case "-m":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "error: switch `m' requires a value\n")
+				os.Exit(129)
+			}
+			message = args[i+1]
+			i++
+		case "-X", "--strategy-option":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "error: switch `X' requires a value\n")
+				os.Exit(129)
+			}
+			parseStrategyOption(&options, args[i+1])
+			i++
+		default:
+			if value, ok := strings.CutPrefix(arg, "--strategy-option="); ok {
+				parseStrategyOption(&options, value)
+				continue
+			}
+			if value, ok := strings.CutPrefix(arg, "-X"); ok {
+				parseStrategyOption(&options, value)
+				continue
+			}
+			if strings.HasPrefix(arg, "-") {
+				fmt.Fprintf(os.Stderr, "error: unknown option `%s'\n", strings.TrimLeft(arg, "-"))
+				fmt.Fprintf(os.Stderr, "usage: ccgit merge [<options>] [<commit>...]\n")
+				fmt.Fprintf(os.Stderr, "   or: ccgit merge --abort\n")
+				fmt.Fprintf(os.Stderr, "   or: ccgit merge --quit\n")
+				fmt.Fprintf(os.Stderr, "   or: ccgit merge --continue\n")
+				os.Exit(129)
+			}
+			names = append(names, arg)
+		}
+	}
+
+	mergeHeads := readMergeHeads()
+	switch action {
+	case "abort":
+		if len(mergeHeads) == 0 {
+			fmt.Fprintf(os.Stderr, "fatal: There is no merge to abort (MERGE_HEAD missing).\n")
+			os.Exit(128)
+		}
+		abortMerge()
+		return
+	case "quit":
+		removeMergeState()
+		return
+	case "continue":
+		if len(mergeHeads) == 0 {
+			fmt.Fprintf(os.Stderr, "fatal: There is no merge in progress (MERGE_HEAD missing).\n")
+			os.Exit(128)
+		}
+		exitIfUnmerged("Committing")
+		commitIndex(readMergeMessage(), mergeHeads, "")
+		return
+	}
+
+	if len(mergeHeads) > 0 {
+		fmt.Fprintf(os.Stderr, "fatal: You have not concluded your merge (MERGE_HEAD exists).\n")
+		fmt.Fprintf(os.Stderr, "Please, commit your changes before you merge.\n")
+		os.Exit(128)
+	}
+
+	if len(names) == 0 {
+		fmt.Fprintf(os.Stderr, "fatal: No remote for the current branch.\n")
+		os.Exit(128)
+	}
+
+	var remotes []string
+	for _, name := range names {
+		hash, err := ResolveCommit(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "merge: %s - not something we can merge\n", name)
+			os.Exit(1)
+		}
+		remotes = append(remotes, hash)
+	}
+
+	reflogPrefix := fmt.Sprintf("merge %s", strings.Join(names, " "))
+
+	head := string(utils.GetHeadHash())
+	if head == "" {
+		if len(remotes) > 1 {
+			fmt.Fprintf(os.Stderr, "fatal: Can merge only exactly one commit into empty head\n")
+			os.Exit(128)
+		}
+		if !checkoutTree(remotes[0], false, "merge") {
+			os.Exit(1)
+		}
+		if err := utils.UpdateHead(remotes[0], "initial pull"); err != nil {
+			fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+			os.Exit(128)
+		}
+		return
+	}
+
+	_ = os.WriteFile(filepath.Join(".git", "ORIG_HEAD"), fmt.Appendf(nil, "%s\n", head), 0644)
+
+	// commits repetidos ou já contidos em HEAD não entram no merge
+	reduced := ReduceHeads(append([]string{head}, remotes...))
+	var heads, headNames []string
+	for i, remote := range remotes {
+		if remote != head && slices.Contains(reduced, remote) && !slices.Contains(heads, remote) {
+			heads = append(heads, remote)
+			headNames = append(headNames, names[i])
+		}
+	}
+	if len(heads) == 0 {
+		fmt.Fprintf(os.Stdout, "Already up to date.\n")
+		return
+	}
+	remotes, names = heads, headNames
+
+	if message == "" {
+		message = defaultMergeMessage(names)
+	}
+
+	if len(remotes) == 1 && fastForward != "never" && IsAncestor(head, remotes[0]) {
+		fastForwardTo(head, remotes[0], reflogPrefix, quiet)
+		runHook("post-merge", "", nil, "0")
+		return
+	}
+
+	if fastForward == "only" {
+		fmt.Fprintf(os.Stderr, "fatal: Not possible to fast-forward, aborting.\n")
+		os.Exit(128)
+	}
+
+	if !allowUnrelated {
+		for _, remote := range remotes {
+			if len(MergeBases(head, remote)) == 0 {
+				fmt.Fprintf(os.Stderr, "fatal: refusing to merge unrelated histories\n")
+				os.Exit(128)
+			}
+		}
+	}
+
+	strategy := "ort"
+	if len(remotes) > 1 {
+		strategy = "octopus"
+	}
+
+	if staged := stagedChanges(head); len(staged) > 0 {
+		fmt.Fprintf(os.Stderr, "error: Your local changes to the following files would be overwritten by merge:\n  %s\n", strings.Join(staged, " "))
+		fmt.Fprintf(os.Stderr, "Merge with strategy %s failed.\n", strategy)
+		os.Exit(2)
+	}
+
+	oursTree := readCommitTreeEntries(head)
+	var result *MergeResult
+	if strategy == "octopus" {
+		result = octopusMerge(head, remotes, names)
+		if result == nil {
+			fmt.Fprintf(os.Stderr, "Merge with strategy octopus failed.\n")
+			os.Exit(2)
+		}
+	} else {
+		options.TheirsLabel = names[0]
+		result = MergeCommits(head, remotes[0], options)
+	}
+
+	if !checkMergeWorktree(oursTree, result) {
+		fmt.Fprintf(os.Stderr, "Merge with strategy %s failed.\n", strategy)
+		os.Exit(2)
+	}
+
+	applyMergeResult(oursTree, result)
+	if !quiet {
+		result.PrintMessages(os.Stdout)
+	}
+
+	if !result.Clean() || !commit {
+		writeMergeState(remotes, message, result, fastForward == "never")
+		if !result.Clean() {
+			fmt.Fprintf(os.Stdout, "Automatic merge failed; fix conflicts and then commit the result.\n")
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stdout, "Automatic merge went well; stopped before committing as requested\n")
+		return
+	}
+
+	treeHash := BuildTree(result.Tree)
+	hash, object := utils.GetCommitHashObject(treeHash, append([]string{head}, remotes...), message)
+	utils.SaveHashedObject(hash, object)
+
+	if err := utils.UpdateHead(fmt.Sprintf("%x", hash), fmt.Sprintf("%s: Merge made by the '%s' strategy.", reflogPrefix, strategy)); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(128)
+	}
+
+	if !quiet {
+		fmt.Fprintf(os.Stdout, "Merge made by the '%s' strategy.\n", strategy)
+		changes := DiffTreesWithRenames(oursTree, result.Tree)
+		WriteDiffStat(os.Stdout, changes, 80)
+		WriteSummary(os.Stdout, changes)
+	}
+	// o argumento diz se foi um merge --squash, que o ccgit não faz
+	runHook("post-merge", "", nil, "0")
+}
+
+func parseStrategyOption(options *MergeOptions, value string) {
+	switch value {
+	case "ours":
+		options.Favor = mergeFavorOurs
+		return
+	case "theirs":
+		options.Favor = mergeFavorTheirs
+		return
+	case "no-renames":
+		options.NoRenames = true
+		return
+	case "find-renames":
+		options.NoRenames = false
+		options.RenameScore = 0
+		return
+	}
+
+	for _, prefix := range []string{"find-renames=", "rename-threshold="} {
+		if score, ok := strings.CutPrefix(value, prefix); ok {
+			if n, valid := parseRenameScore(score); valid {
+				options.NoRenames = false
+				options.RenameScore = n
+				return
+			}
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "fatal: unknown strategy option: -X%s\n", value)
+	os.Exit(128)
+}
+
+func fastForwardTo(head string, target string, reflogPrefix string, quiet bool) {
+	fmt.Fprintf(os.Stdout, "Updating %s..%s\n", head[:7], target[:7])
+	if !checkoutTree(target, false, "merge") {
+		os.Exit(1)
+	}
+
+	if err := utils.UpdateHead(target, fmt.Sprintf("%s: Fast-forward", reflogPrefix)); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(128)
+	}
+
+	fmt.Fprintf(os.Stdout, "Fast-forward\n")
+	if !quiet {
+		changes := DiffTreesWithRenames(readCommitTreeEntries(head), readCommitTreeEntries(target))
+		WriteDiffStat(os.Stdout, changes, 80)
+		WriteSummary(os.Stdout, changes)
+	}
+}
+
+func defaultMergeMessage(names []string) string {
+	kinds := []string{"branch", "remote-tracking branch", "tag", "commit"}
+	groups := map[string][]string{}
+
+	for _, name := range names {
+		kind := "commit"
+		switch {
+		case utils.RefExists("refs/heads/" + name):
+			kind = "branch"
+		case utils.RefExists("refs/remotes/" + name):
+			kind = "remote-tracking branch"
+		case utils.RefExists("refs/tags/" + name):
+			kind = "tag"
+		}
+		groups[kind] = append(groups[kind], fmt.Sprintf("'%s'", name))
+	}
+
+	var parts []string
+	for _, kind := range kinds {
+		group := groups[kind]
+		switch len(group) {
+		case 0:
+			continue
+		case 1:
+			parts = append(parts, fmt.Sprintf("%s %s", kind, group[0]))
+		default:
+			list := strings.Join(group[:len(group)-1], ", ") + " and " + group[len(group)-1]
+			parts = append(parts, fmt.Sprintf("%s%s %s", kind, kindPlural(kind), list))
+		}
+	}
+
+	message := "Merge " + strings.Join(parts, ", ")
+
+	branch := utils.GetHeadBranch()
+	if utils.IsHeadDetached() {
+		branch = "HEAD"
+	}
+	if branch != "main" && branch != "master" {
+		message += " into " + branch
+	}
+
+	return message
+}
+
+func kindPlural(kind string) string {
+	if strings.HasSuffix(kind, "h") {
+		return "es"
+	}
+
+	return "s"
+}
+
+func mergeConflictStyle() string {
+	style, _ := config.Get("merge.conflictstyle")
+	return style
+}
+
+func readMergeHeads() []string {
+	data, err := os.ReadFile(filepath.Join(".git", "MERGE_HEAD"))
+	if err != nil {
+		return nil
+	}
+
+	return strings.Fields(string(data))
+}
+
+func readMergeMessage() string {
+	data, _ := os.ReadFile(filepath.Join(".git", "MERGE_MSG"))
+
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, strings.TrimRight(line, " \t"))
+		}
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func writeMergeState(remotes []string, message string, result *MergeResult, noFastForward bool) {
+	var heads strings.Builder
+	for _, remote := range remotes {
+		fmt.Fprintf(&heads, "%s\n", remote)
+	}
+	_ = os.WriteFile(filepath.Join(".git", "MERGE_HEAD"), []byte(heads.String()), 0644)
+
+	writeMergeMessage(message, result)
+
+	mode := ""
+	if noFastForward {
+		mode = "no-ff"
+	}
+	_ = os.WriteFile(filepath.Join(".git", "MERGE_MODE"), []byte(mode), 0644)
+}
+
+// MERGE_MSG com a lista de conflitos comentada no fim
+func writeMergeMessage(message string, result *MergeResult) {
+	msg := message + "\n"
+	if !result.Clean() {
+		msg += "\n# Conflicts:\n"
+		for _, path := range result.UnmergedPaths() {
+			msg += fmt.Sprintf("#\t%s\n", path)
+		}
+	}
+	_ = os.WriteFile(filepath.Join(".git", "MERGE_MSG"), []byte(msg), 0644)
+}
+
+func hasUnmergedEntries() bool {
+	for _, entry := range ReadIndex().Entries {
+		if entry.Stage != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func stagedChanges(head string) []string {
+	headTree := readCommitTreeEntries(head)
+	indexPaths := map[string]bool{}
+
+	var staged []string
+	for _, entry := range ReadIndex().Entries {
+		indexPaths[entry.Path] = true
+		treeEntry, ok := headTree[entry.Path]
+		if entry.Stage != 0 || !ok || !indexMatchesTreeEntry(entry, treeEntry) {
+			if !slices.Contains(staged, entry.Path) {
+				staged = append(staged, entry.Path)
+			}
+		}
+	}
+	for path := range headTree {
+		if !indexPaths[path] {
+			staged = append(staged, path)
+		}
+	}
+
+	slices.Sort(staged)
+	return staged
+}
+
+func abortMerge() {
+	head := string(utils.GetHeadHash())
+	headTree := readCommitTreeEntries(head)
+
+	indexEntries := map[string]types.Entry{}
+	reset := map[string]bool{}
+	for _, entry := range ReadIndex().Entries {
+		treeEntry, inHead := headTree[entry.Path]
+		if entry.Stage == 0 && inHead && indexMatchesTreeEntry(entry, treeEntry) {
+			indexEntries[entry.Path] = entry
+			continue
+		}
+		reset[entry.Path] = true
+	}
+	for path := range headTree {
+		if _, ok := indexEntries[path]; !ok {
+			reset[path] = true
+		}
+	}
+
+	paths := slices.Sorted(maps.Keys(reset))
+	for _, path := range paths {
+		if _, inHead := headTree[path]; !inHead {
+			removeWorktreeFile(path)
+		}
+	}
+
+	for _, path := range paths {
+		treeEntry, inHead := headTree[path]
+		if !inHead {
+			continue
+		}
+
+		if err := writeWorktreeFile(path, treeEntry.Mode, treeEntry.Hash); err != nil {
+			fmt.Fprintf(os.Stderr, "error: unable to write file %s: %v\n", path, err)
+			os.Exit(128)
+		}
+		indexEntries[path] = NewIndexEntry(path, [20]byte(treeEntry.Hash))
+	}
+
+	WriteIndex(sortedIndexEntries(indexEntries))
+	removeMergeState()
+}
+
+func checkMergeWorktree(oursTree map[string]types.TreeEntry, result *MergeResult) bool {
+	indexEntries := map[string]types.Entry{}
+	for _, entry := range ReadIndex().Entries {
+		indexEntries[entry.Path] = entry
+	}
+
+	var dirty, untracked []string
+	for _, path := range sortedPaths(oursTree, result.Tree) {
+		oursEntry, inOurs := oursTree[path]
+		resultEntry, inResult := result.Tree[path]
+		if inOurs && inResult && sameTreeEntry(oursEntry, resultEntry) {
+			continue
+		}
+
+		if entry, inIndex := indexEntries[path]; inIndex {
+			if pathExists(path) && !worktreeMatchesIndex(entry) {
+				dirty = append(dirty, path)
+			}
+			continue
+		}
+
+		if inResult && pathExists(path) && !worktreeMatchesTreeEntry(path, resultEntry) {
+			untracked = append(untracked, path)
+		}
+	}
+
+	if len(dirty) > 0 {
+		fmt.Fprintf(os.Stderr, "error: Your local changes to the following files would be overwritten by merge:\n")
+		for _, path := range dirty {
+			fmt.Fprintf(os.Stderr, "\t%s\n", path)
+		}
+		fmt.Fprintf(os.Stderr, "Please commit your changes or stash them before you merge.\n")
+	}
+	if len(untracked) > 0 {
+		fmt.Fprintf(os.Stderr, "error: The following untracked working tree files would be overwritten by merge:\n")
+		for _, path := range untracked {
+			fmt.Fprintf(os.Stderr, "\t%s\n", path)
+		}
+		fmt.Fprintf(os.Stderr, "Please move or remove them before you merge.\n")
+	}
+	if len(dirty)+len(untracked) > 0 {
+		fmt.Fprintf(os.Stderr, "Aborting\n")
+		return false
+	}
+
+	return true
+}
+
+func applyMergeResult(oursTree map[string]types.TreeEntry, result *MergeResult) {
+	indexEntries := map[string]types.Entry{}
+	for _, entry := range ReadIndex().Entries {
+		if entry.Stage == 0 {
+			indexEntries[entry.Path] = entry
+		}
+	}
+
+	for path := range oursTree {
+		if _, ok := result.Tree[path]; !ok {
+			removeWorktreeFile(path)
+			delete(indexEntries, path)
+		}
+	}
+
+	for _, path := range sortedPaths(result.Tree) {
+		entry := result.Tree[path]
+		if oursEntry, ok := oursTree[path]; !ok || !sameTreeEntry(oursEntry, entry) {
+			if err := writeWorktreeFile(path, entry.Mode, entry.Hash); err != nil {
+				fmt.Fprintf(os.Stderr, "error: unable to write file %s: %v\n", path, err)
+				os.Exit(128)
+			}
+			indexEntries[path] = NewIndexEntry(path, [20]byte(entry.Hash))
+		}
+	}
+
+	var entries []types.Entry
+	for path, entry := range indexEntries {
+		if _, unmerged := result.Unmerged[path]; !unmerged {
+			entries = append(entries, entry)
+		}
+	}
+	for path, stages := range result.Unmerged {
+		for i, stage := range stages {
+			if stage != nil {
+				entries = append(entries, unmergedIndexEntry(path, *stage, uint8(i+1)))
+			}
+		}
+	}
+
+	WriteIndex(sortIndexEntries(entries))
+}
+
+func unmergedIndexEntry(path string, treeEntry types.TreeEntry, stage uint8) types.Entry {
+	mode, _ := strconv.ParseUint(treeEntry.Mode, 8, 32)
+	return types.Entry{
+		SHA1:       [20]byte(treeEntry.Hash),
+		Mode:       uint32(mode),
+		ObjectType: uint16(mode>>12) & 0xF,
+		Perms:      uint16(mode) & 0x1FF,
+		NameLength: uint16(len(path)),
+		Path:       path,
+		Stage:      stage,
+	}
+}
+
+func sortedPaths(trees ...map[string]types.TreeEntry) []string {
+	seen := map[string]bool{}
+	for _, tree := range trees {
+		for path := range tree {
+			seen[path] = true
+		}
+	}
+
+	return slices.Sorted(maps.Keys(seen))
+}
+
+func (r *MergeResult) Clean() bool {
+	return len(r.Unmerged) == 0
+}
+
+func (r *MergeResult) UnmergedPaths() []string {
+	var paths []string
+	for path := range r.Unmerged {
+		paths = append(paths, path)
+	}
+
+	slices.Sort(paths)
+	return paths
+}
+
+func (r *MergeResult) sortedMessages() []mergeMessage {
+	messages := slices.Clone(r.messages)
+	slices.SortStableFunc(messages, func(a, b mergeMessage) int {
+		return strings.Compare(a.paths[0], b.paths[0])
+	})
+
+	return messages
+}
+
+func (r *MergeResult) PrintMessages(w io.Writer) {
+	for _, message := range r.sortedMessages() {
+		fmt.Fprintf(w, "%s\n", message.text)
+	}
+}
+
+func (r *MergeResult) addMessage(kind string, paths []string, format string, args ...any) {
+	r.messages = append(r.messages, mergeMessage{kind: kind, paths: paths, text: fmt.Sprintf(format, args...)})
+}
+
+func MergeCommits(ours string, theirs string, options MergeOptions) *MergeResult {
+	bases := MergeBases(ours, theirs)
+
+	baseTree := map[string]types.TreeEntry{}
+	switch len(bases) {
+	case 0:
+		options.BaseLabel = "empty tree"
+	case 1:
+		baseTree = readCommitTreeEntries(bases[0])
+		options.BaseLabel = bases[0][:7]
+	default:
+		baseTree = virtualMergeBase(bases, options)
+		options.BaseLabel = "merged common ancestors"
+	}
+
+	return MergeTrees(baseTree, readCommitTreeEntries(ours), readCommitTreeEntries(theirs), options)
+}
+
+// várias bases viram uma base virtual, fundindo as bases da mais antiga para a mais nova
+func virtualMergeBase(bases []string, outer MergeOptions) map[string]types.TreeEntry {
+	bases = slices.Clone(bases)
+	slices.Reverse(bases)
+
+	merged := readCommitTreeEntries(bases[0])
+	merging := []string{bases[0]}
+	for _, next := range bases[1:] {
+		options := MergeOptions{
+			OursLabel:   "Temporary merge branch 1",
+			TheirsLabel: "Temporary merge branch 2",
+			Style:       outer.Style,
+			NoRenames:   outer.NoRenames,
+			RenameScore: outer.RenameScore,
+			virtual:     true,
+		}
+
+		innerBases := MergeBases(next, merging...)
+		innerTree := map[string]types.TreeEntry{}
+		switch len(innerBases) {
+		case 0:
+			options.BaseLabel = "empty tree"
+		case 1:
+			innerTree = readCommitTreeEntries(innerBases[0])
+			options.BaseLabel = innerBases[0][:7]
+		default:
+			innerTree = virtualMergeBase(innerBases, outer)
+			options.BaseLabel = "merged common ancestors"
+		}
+
+		merged = MergeTrees(innerTree, merged, readCommitTreeEntries(next), options).Tree
+		merging = append(merging, next)
+	}
+
+	return merged
+}
+
+func MergeTrees(base map[string]types.TreeEntry, ours map[string]types.TreeEntry, theirs map[string]types.TreeEntry, options MergeOptions) *MergeResult {
+	result := &MergeResult{Tree: map[string]types.TreeEntry{}, Unmerged: map[string][3]*types.TreeEntry{}}
+	origin := map[string]int{}
+
+	base, ours, theirs = maps.Clone(base), maps.Clone(ours), maps.Clone(theirs)
+	renamed := map[string][3]string{}
+	forced := map[string][3]*types.TreeEntry{}
+	if !options.NoRenames {
+		result.followRenames(base, ours, theirs, renamed, forced, options)
+	}
+
+	for _, path := range sortedPaths(base, ours, theirs) {
+		o, inBase := base[path]
+		a, inOurs := ours[path]
+		b, inTheirs := theirs[path]
+
+		switch {
+		case inOurs == inTheirs && (!inOurs || sameTreeEntry(a, b)):
+			if inOurs {
+				result.Tree[path] = a
+				origin[path] = mergeOriginBoth
+			}
+		case inBase == inOurs && (!inBase || sameTreeEntry(o, a)):
+			if inTheirs {
+				result.Tree[path] = b
+				origin[path] = mergeOriginTheirs
+			}
+		case inBase == inTheirs && (!inBase || sameTreeEntry(o, b)):
+			if inOurs {
+				result.Tree[path] = a
+				origin[path] = mergeOriginOurs
+			}
+		case inOurs && inTheirs:
+			origin[path] = mergeOriginBoth
+			var basePtr *types.TreeEntry
+			if inBase {
+				basePtr = &o
+			}
+			result.mergeEntry(path, basePtr, a, b, renamedLabels(options, renamed[path]))
+		case inOurs:
+			if options.virtual {
+				result.Tree[path] = o
+				continue
+			}
+			result.Tree[path] = a
+			origin[path] = mergeOriginOurs
+			result.Unmerged[path] = [3]*types.TreeEntry{&o, &a, nil}
+			result.addMessage("CONFLICT (modify/delete)", []string{path}, "CONFLICT (modify/delete): %s deleted in %s and modified in %s.  Version %s of %s left in tree.", path, options.TheirsLabel, options.OursLabel, options.OursLabel, path)
+		default:
+			if options.virtual {
+				result.Tree[path] = o
+				continue
+			}
+			result.Tree[path] = b
+			origin[path] = mergeOriginTheirs
+			result.Unmerged[path] = [3]*types.TreeEntry{&o, nil, &b}
+			result.addMessage("CONFLICT (modify/delete)", []string{path}, "CONFLICT (modify/delete): %s deleted in %s and modified in %s.  Version %s of %s left in tree.", path, options.OursLabel, options.TheirsLabel, options.TheirsLabel, path)
+		}
+	}
+
+	// conflitos de renomeação só valem se o caminho não entrou em outro conflito
+	for path, stages := range forced {
+		if _, ok := result.Unmerged[path]; !ok {
+			result.Unmerged[path] = stages
+		}
+	}
+
+	result.resolveDirectoryConflicts(origin, options)
+	return result
+}
+
+// move as entradas renomeadas para o novo caminho, para o conteúdo acompanhar a renomeação
+func (r *MergeResult) followRenames(base map[string]types.TreeEntry, ours map[string]types.TreeEntry, theirs map[string]types.TreeEntry, renamed map[string][3]string, forced map[string][3]*types.TreeEntry, options MergeOptions) {
+	minScore := options.RenameScore
+	if minScore == 0 {
+		minScore = renameDefaultScore
+	}
+
+	// só vale procurar renomeações inexatas de arquivos que o outro lado alterou
+	changedIn := func(side map[string]types.TreeEntry) func(path string) bool {
+		return func(path string) bool {
+			entry, ok := side[path]
+			return !ok || !sameTreeEntry(entry, base[path])
+		}
+	}
+	oursRenames := DetectRenames(base, ours, minScore, changedIn(theirs))
+	theirsRenames := DetectRenames(base, theirs, minScore, changedIn(ours))
+
+	for _, path := range sortedPaths(base) {
+		oursRename, inOurs := oursRenames[path]
+		theirsRename, inTheirs := theirsRenames[path]
+		oursPath, theirsPath := oursRename.Path, theirsRename.Path
+		o := base[path]
+
+		switch {
+		case inOurs && inTheirs && oursPath == theirsPath:
+			base[oursPath] = o
+			renamed[oursPath] = [3]string{path, oursPath, theirsPath}
+		case inOurs && inTheirs:
+			paths := [3]string{path, oursPath, theirsPath}
+			merged := r.mergeRenamedEntry(path, &o, ours[oursPath], theirs[theirsPath], renamedLabels(options, paths))
+			ours[oursPath] = merged
+			theirs[theirsPath] = merged
+			if !options.virtual {
+				forced[path] = [3]*types.TreeEntry{&o, nil, nil}
+				forced[oursPath] = [3]*types.TreeEntry{nil, &merged, nil}
+				forced[theirsPath] = [3]*types.TreeEntry{nil, nil, &merged}
+				r.addMessage("CONFLICT (rename/rename)", paths[:], "CONFLICT (rename/rename): %s renamed to %s in %s and to %s in %s.", path, oursPath, options.OursLabel, theirsPath, options.TheirsLabel)
+			}
+		case inOurs:
+			paths := [3]string{path, oursPath, path}
+			moved := r.followRename(path, oursPath, base, ours, theirs, forced, 1, options, func(a types.TreeEntry, b types.TreeEntry) types.TreeEntry {
+				return r.mergeRenamedEntry(path, &o, a, b, renamedLabels(options, paths))
+			})
+			if moved {
+				renamed[oursPath] = paths
+			}
+		case inTheirs:
+			paths := [3]string{path, path, theirsPath}
+			moved := r.followRename(path, theirsPath, base, theirs, ours, forced, 2, options, func(b types.TreeEntry, a types.TreeEntry) types.TreeEntry {
+				return r.mergeRenamedEntry(path, &o, a, b, renamedLabels(options, paths))
+			})
+			if moved {
+				renamed[theirsPath] = paths
+			}
+		default:
+			continue
+		}
+
+		delete(base, path)
+	}
+}
+
+// side renomeou path para newPath; other pode ter alterado ou removido path, ou adicionado newPath
+func (r *MergeResult) followRename(path string, newPath string, base map[string]types.TreeEntry, side map[string]types.TreeEntry, other map[string]types.TreeEntry, forced map[string][3]*types.TreeEntry, stage int, options MergeOptions, merge func(sideEntry types.TreeEntry, otherEntry types.TreeEntry) types.TreeEntry) bool {
+	o := base[path]
+	otherEntry, inOther := other[path]
+	_, collides := other[newPath]
+
+	switch {
+	case !inOther && collides:
+	case !inOther:
+		if options.virtual {
+			side[newPath] = o
+			return false
+		}
+
+		sideLabel, otherLabel := options.OursLabel, options.TheirsLabel
+		if stage == 2 {
+			sideLabel, otherLabel = otherLabel, sideLabel
+		}
+		entry := side[newPath]
+		stages := [3]*types.TreeEntry{&o, nil, nil}
+		stages[stage] = &entry
+		forced[newPath] = stages
+		r.addMessage("CONFLICT (rename/delete)", []string{newPath, path}, "CONFLICT (rename/delete): %s renamed to %s in %s, but deleted in %s.", path, newPath, sideLabel, otherLabel)
+		if !sameTreeEntry(o, entry) {
+			r.addMessage("CONFLICT (modify/delete)", []string{newPath}, "CONFLICT (modify/delete): %s deleted in %s and modified in %s.  Version %s of %s left in tree.", newPath, otherLabel, sideLabel, sideLabel, newPath)
+		}
+	case collides:
+		// renomeação por cima de um arquivo novo do outro lado vira add/add com o conteúdo já mesclado
+		side[newPath] = merge(side[newPath], otherEntry)
+		delete(other, path)
+	default:
+		base[newPath] = o
+		other[newPath] = otherEntry
+		delete(other, path)
+		return true
+	}
+
+	return false
+}
+
+func renamedLabels(options MergeOptions, paths [3]string) MergeOptions {
+	if paths[1] == paths[2] {
+		return options
+	}
+
+	options.BaseLabel += ":" + paths[0]
+	options.OursLabel += ":" + paths[1]
+	options.TheirsLabel += ":" + paths[2]
+	return options
+}
+
+func (r *MergeResult) mergeEntry(path string, base *types.TreeEntry, a types.TreeEntry, b types.TreeEntry, options MergeOptions) {
+	kindA, kindB := utils.ModeStringToKind(a.Mode), utils.ModeStringToKind(b.Mode)
+
+	if !isRegularMode(a.Mode) || !isRegularMode(b.Mode) {
+		if kindA != kindB || isRegularMode(a.Mode) != isRegularMode(b.Mode) {
+			if options.virtual {
+				r.Tree[path] = a
+				return
+			}
+
+			// tipos diferentes: cada lado fica registrado com outro nome
+			oursPath := path + "~" + options.OursLabel
+			theirsPath := path + "~" + options.TheirsLabel
+			r.Tree[oursPath] = a
+			r.Tree[theirsPath] = b
+			r.Unmerged[oursPath] = [3]*types.TreeEntry{nil, &a, nil}
+			r.Unmerged[theirsPath] = [3]*types.TreeEntry{nil, nil, &b}
+			r.addMessage("CONFLICT (distinct types)", []string{path, oursPath, theirsPath}, "CONFLICT (distinct types): %s had different types on each side; renamed both of them so each can be recorded somewhere.", path)
+			return
+		}
+
+		// symlinks ou submódulos alterados dos dois lados: fica a versão de HEAD, a não ser que -X escolha um lado
+		switch {
+		case options.virtual && base != nil:
+			r.Tree[path] = *base
+			return
+		case options.Favor == mergeFavorOurs:
+			r.Tree[path] = a
+			return
+		case options.Favor == mergeFavorTheirs:
+			r.Tree[path] = b
+			return
+		}
+		r.Tree[path] = a
+		r.Unmerged[path] = [3]*types.TreeEntry{base, &a, &b}
+		r.addMessage("CONFLICT (contents)", []string{path}, "CONFLICT (content): Merge conflict in %s", path)
+		return
+	}
+
+	entry, clean := r.mergeFileEntry(path, base, a, b, options)
+	r.Tree[path] = entry
+	if !clean && !options.virtual {
+		r.Unmerged[path] = [3]*types.TreeEntry{base, &a, &b}
+	}
+}
+
+// conteúdo de um arquivo renomeado; o resultado ainda pode colidir com outro caminho
+func (r *MergeResult) mergeRenamedEntry(path string, base *types.TreeEntry, a types.TreeEntry, b types.TreeEntry, options MergeOptions) types.TreeEntry {
+	if !isRegularMode(a.Mode) || !isRegularMode(b.Mode) {
+		if sameTreeEntry(*base, a) {
+			return b
+		}
+		return a
+	}
+
+	entry, _ := r.mergeFileEntry(path, base, a, b, options)
+	return entry
+}
+
+func (r *MergeResult) mergeFileEntry(path string, base *types.TreeEntry, a types.TreeEntry, b types.TreeEntry, options MergeOptions) (types.TreeEntry, bool) {
+	baseMode := ""
+	var baseContent []byte
+	if base != nil {
+		baseMode = base.Mode
+		baseContent = readBlob(fmt.Sprintf("%x", base.Hash))
+	}
+
+	clean := true
+	mode := b.Mode
+	if a.Mode != b.Mode && a.Mode != baseMode {
+		mode = a.Mode
+		clean = b.Mode == baseMode
+	}
+
+	hash := b.Hash
+	switch {
+	case sameHash(a, b) || (base != nil && sameHash(a, *base)):
+	case base != nil && sameHash(b, *base):
+		hash = a.Hash
+	default:
+		merged, conflicts := MergeContent(
+			readBlob(fmt.Sprintf("%x", a.Hash)), baseContent, readBlob(fmt.Sprintf("%x", b.Hash)),
+			MergeFileOptions{OursLabel: options.OursLabel, BaseLabel: options.BaseLabel, TheirsLabel: options.TheirsLabel, Style: options.Style, Favor: options.Favor},
+		)
+		hash = writeBlob(merged)
+		clean = clean && conflicts == 0
+
+		if !options.virtual {
+			r.addMessage("Auto-merging", []string{path}, "Auto-merging %s", path)
+			if conflicts > 0 {
+				kind := "content"
+				if base == nil {
+					kind = "add/add"
+				}
+				r.addMessage("CONFLICT (contents)", []string{path}, "CONFLICT (%s): Merge conflict in %s", kind, path)
+			}
+		}
+	}
+
+	return types.TreeEntry{Name: filepath.Base(path), Mode: mode, Hash: hash}, clean
+}
+
+func (r *MergeResult) resolveDirectoryConflicts(origin map[string]int, options MergeOptions) {
+	dirs := map[string]bool{}
+	for path := range r.Tree {
+		for dir := filepath.Dir(path); dir != "."; dir = filepath.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+
+	for _, path := range sortedPaths(r.Tree) {
+		if !dirs[path] {
+			continue
+		}
+
+		entry := r.Tree[path]
+		delete(r.Tree, path)
+		if options.virtual {
+			continue
+		}
+
+		label, stage := options.OursLabel, 1
+		if origin[path] == mergeOriginTheirs {
+			label, stage = options.TheirsLabel, 2
+		}
+		newPath := path + "~" + label
+
+		r.Tree[newPath] = entry
+		stages, ok := r.Unmerged[path]
+		if ok {
+			delete(r.Unmerged, path)
+		} else {
+			stages[stage] = &entry
+		}
+		r.Unmerged[newPath] = stages
+		r.addMessage("CONFLICT (file/directory)", []string{newPath, path}, "CONFLICT (file/directory): directory in the way of %s from %s; moving it to %s instead.", path, label, newPath)
+	}
+}
+
+func sameHash(a types.TreeEntry, b types.TreeEntry) bool {
+	return string(a.Hash) == string(b.Hash)
+}
+
+func writeBlob(content []byte) []byte {
+	object := append(fmt.Appendf(nil, "blob %d\x00", len(content)), content...)
+	hash := sha1.Sum(object)
+	utils.SaveHashedObject(hash, object)
+
+	return hash[:]
+}
+
+func octopusMerge(head string, remotes []string, names []string) *MergeResult {
+	current := []string{head}
+	currentTree := readCommitTreeEntries(head)
+	var result *MergeResult
+	nonFastForward := false
+
+	for i, remote := range remotes {
+		if result != nil && !result.Clean() {
+			fmt.Fprintf(os.Stdout, "Automated merge did not work.\n")
+			fmt.Fprintf(os.Stdout, "Should not be doing an octopus.\n")
+			return nil
+		}
+
+		bases := MergeBases(remote, current...)
+		if slices.Contains(bases, remote) {
+			fmt.Fprintf(os.Stdout, "Already up to date with %s\n", names[i])
+			continue
+		}
+		if !nonFastForward && len(bases) == 1 && slices.Equal(current, []string{bases[0]}) {
+			fmt.Fprintf(os.Stdout, "Fast-forwarding to: %s\n", names[i])
+			current = []string{remote}
+			currentTree = readCommitTreeEntries(remote)
+			result = &MergeResult{Tree: currentTree, Unmerged: map[string][3]*types.TreeEntry{}}
+			continue
+		}
+
+		nonFastForward = true
+		fmt.Fprintf(os.Stdout, "Trying simple merge with %s\n", names[i])
+
+		baseTree := map[string]types.TreeEntry{}
+		if len(bases) > 0 {
+			baseTree = readCommitTreeEntries(bases[0])
+		}
+		result = MergeTrees(baseTree, currentTree, readCommitTreeEntries(remote), MergeOptions{OursLabel: ".merge_file_ours", TheirsLabel: ".merge_file_theirs"})
+		if len(result.messages) > 0 {
+			fmt.Fprintf(os.Stdout, "Simple merge did not work, trying automatic merge.\n")
+		}
+
+		current = append(current, remote)
+		currentTree = result.Tree
+	}
+
+	return result
+}
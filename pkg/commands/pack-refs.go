@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/utils"
+)
+
+func PackRefs(args ...string) {
+	err := utils.CheckGitRepo(".", false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	all := slices.Contains(args, "--all")
+	prune := !slices.Contains(args, "--no-prune")
+
+	for _, arg := range args[2:] {
+		if arg != "--all" && arg != "--prune" && arg != "--no-prune" {
+			fmt.Fprintf(os.Stderr, "usage: ccgit pack-refs [--all] [--prune | --no-prune]\n")
+			os.Exit(1)
+		}
+	}
+
+	packed := map[string]utils.PackedRef{}
+	for _, ref := range utils.ReadPackedRefs() {
+		packed[ref.Name] = ref
+	}
+
+	var pruned []string
+	for name, hash := range utils.ReadLooseRefs() {
+		_, alreadyPacked := packed[name]
+		if !all && !alreadyPacked && !strings.HasPrefix(name, "refs/tags/") {
+			continue
+		}
+
+		packed[name] = utils.PackedRef{Name: name, Hash: hash}
+		pruned = append(pruned, name)
+	}
+
+	refs := make([]utils.PackedRef, 0, len(packed))
+	for _, ref := range packed {
+		ref.Peeled = ""
+		if peeled, isTag := peelTag(ref.Hash); isTag {
+			ref.Peeled = peeled
+		}
+		refs = append(refs, ref)
+	}
+
+	if err := utils.WritePackedRefs(refs); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !prune {
+		return
+	}
+
+	for _, name := range pruned {
+		path := filepath.Join(".git", name)
+		if err := os.Remove(path); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: unable to prune %s: %v\n", name, err)
+			continue
+		}
+		utils.RemoveEmptyRefDirs(filepath.Dir(path))
+	}
+}
+
+func peelTag(hash string) (string, bool) {
+	if !objectExists(hash) {
+		return "", false
+	}
+
+	target := hash
+	isTag := false
+	for range 100 {
+		data := CatFileReadObject(target[0:2], target[2:])
+		if CatFileExtractKind(data) != "tag" {
+			break
+		}
+
+		isTag = true
+		nulIndex := bytes.IndexByte(data, 0)
+		object, _, _ := strings.Cut(string(data[nulIndex+1:]), "\n")
+		target = strings.TrimPrefix(object, "object ")
+		if !objectExists(target) {
+			break
+		}
+	}
+
+	return target, isTag
+}
@@ -0,0 +1,720 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/types"
+)
+
+const patchContext = 3
+
+type FileChange struct {
+	Path       string
+	OldPath    string
+	OldMode    string
+	NewMode    string
+	OldHash    string
+	NewHash    string
+	Similarity int
+}
+
+type patchColors struct {
+	meta, frag, old, new, reset string
+}
+
+type patchHunk struct {
+	start, end int
+}
+
+type lostLine struct {
+	text string
+	mask int
+}
+
+func newPatchColors(color bool) patchColors {
+	if !color {
+		return patchColors{}
+	}
+
+	return patchColors{meta: "\033[1m", frag: "\033[36m", old: "\033[31m", new: "\033[32m", reset: "\033[0m"}
+}
+
+func DiffTrees(oldTree map[string]types.TreeEntry, newTree map[string]types.TreeEntry) []FileChange {
+	var changes []FileChange
+
+	for path, oldEntry := range oldTree {
+		newEntry, ok := newTree[path]
+		if !ok {
+			changes = append(changes, FileChange{Path: path, OldMode: oldEntry.Mode, OldHash: fmt.Sprintf("%x", oldEntry.Hash)})
+			continue
+		}
+
+		if oldEntry.Mode == newEntry.Mode && bytes.Equal(oldEntry.Hash, newEntry.Hash) {
+			continue
+		}
+
+		changes = append(changes, FileChange{
+			Path:    path,
+			OldMode: oldEntry.Mode,
+			NewMode: newEntry.Mode,
+			OldHash: fmt.Sprintf("%x", oldEntry.Hash),
+			NewHash: fmt.Sprintf("%x", newEntry.Hash),
+		})
+	}
+
+	for path, newEntry := range newTree {
+		if _, ok := oldTree[path]; !ok {
+			changes = append(changes, FileChange{Path: path, NewMode: newEntry.Mode, NewHash: fmt.Sprintf("%x", newEntry.Hash)})
+		}
+	}
+
+	slices.SortFunc(changes, func(a, b FileChange) int { return strings.Compare(a.Path, b.Path) })
+	return changes
+}
+
+func blobContent(hash string) []byte {
+	if hash == "" {
+		return nil
+	}
+
+	return readBlob(hash)
+}
+
+func abbrevHash(hash string) string {
+	if hash == "" {
+		return "0000000"
+	}
+
+	return hash[:7]
+}
+
+func isBinaryContent(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0
+}
+
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+func WritePatch(w io.Writer, changes []FileChange, color bool) {
+	c := newPatchColors(color)
+
+	for _, change := range changes {
+		oldName, newName := "a/"+change.Path, "b/"+change.Path
+
+		fmt.Fprintf(w, "%sdiff --git %s %s%s\n", c.meta, oldName, newName, c.reset)
+		switch {
+		case change.OldHash == "":
+			fmt.Fprintf(w, "%snew file mode %s%s\n", c.meta, change.NewMode, c.reset)
+			oldName = "/dev/null"
+		case change.NewHash == "":
+			fmt.Fprintf(w, "%sdeleted file mode %s%s\n", c.meta, change.OldMode, c.reset)
+			newName = "/dev/null"
+		case change.OldMode != change.NewMode:
+			fmt.Fprintf(w, "%sold mode %s%s\n", c.meta, change.OldMode, c.reset)
+			fmt.Fprintf(w, "%snew mode %s%s\n", c.meta, change.NewMode, c.reset)
+		}
+
+		if change.OldHash == change.NewHash {
+			continue
+		}
+
+		if change.OldHash != "" && change.NewHash != "" && change.OldMode == change.NewMode {
+			fmt.Fprintf(w, "%sindex %s..%s %s%s\n", c.meta, abbrevHash(change.OldHash), abbrevHash(change.NewHash), change.NewMode, c.reset)
+		} else {
+			fmt.Fprintf(w, "%sindex %s..%s%s\n", c.meta, abbrevHash(change.OldHash), abbrevHash(change.NewHash), c.reset)
+		}
+
+		oldContent := blobContent(change.OldHash)
+		newContent := blobContent(change.NewHash)
+		if isBinaryContent(oldContent) || isBinaryContent(newContent) {
+			fmt.Fprintf(w, "Binary files %s and %s differ\n", oldName, newName)
+			continue
+		}
+
+		if len(oldContent) == 0 && len(newContent) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "%s--- %s%s\n", c.meta, oldName, c.reset)
+		fmt.Fprintf(w, "%s+++ %s%s\n", c.meta, newName, c.reset)
+		WriteHunks(w, splitLines(oldContent), splitLines(newContent), c)
+	}
+}
+
+func WriteHunks(w io.Writer, oldLines []string, newLines []string, c patchColors) {
+	edits := myersDiff(oldLines, newLines)
+
+	// posição de cada edição nos dois lados
+	oldPos := make([]int, len(edits)+1)
+	newPos := make([]int, len(edits)+1)
+	for i, edit := range edits {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if edit.Type != "insert" {
+			oldPos[i+1]++
+		}
+		if edit.Type != "delete" {
+			newPos[i+1]++
+		}
+	}
+
+	var hunks []patchHunk
+	for i := 0; i < len(edits); i++ {
+		if !isChange(edits[i]) {
+			continue
+		}
+
+		start := max(0, i-patchContext)
+		end := i
+		for j := i; j < len(edits); j++ {
+			if isChange(edits[j]) {
+				end = j + 1
+			} else if j-end >= 2*patchContext {
+				break
+			}
+		}
+		end = min(len(edits), end+patchContext)
+
+		hunks = append(hunks, patchHunk{start: start, end: end})
+		i = end - 1
+	}
+
+	funcLine := ""
+	funcLinePrev := -1
+	for _, hunk := range hunks {
+		oldStart, oldCount := oldPos[hunk.start], oldPos[hunk.end]-oldPos[hunk.start]
+		newStart, newCount := newPos[hunk.start], newPos[hunk.end]-newPos[hunk.start]
+
+		for l := oldStart - 1; l > funcLinePrev && l >= 0; l-- {
+			if line := oldLines[l]; line != "" && (isAlpha(line[0]) || line[0] == '_' || line[0] == '$') {
+				funcLine = strings.TrimRight(line[:min(len(line), 80)], " \t\r\n")
+				break
+			}
+		}
+		funcLinePrev = oldStart - 1
+
+		header := fmt.Sprintf("@@ -%s +%s @@", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+		if funcLine != "" {
+			fmt.Fprintf(w, "%s%s%s %s\n", c.frag, header, c.reset, funcLine)
+		} else {
+			fmt.Fprintf(w, "%s%s%s\n", c.frag, header, c.reset)
+		}
+
+		for _, edit := range edits[hunk.start:hunk.end] {
+			prefix, color := " ", ""
+			switch edit.Type {
+			case "delete":
+				prefix, color = "-", c.old
+			case "insert":
+				prefix, color = "+", c.new
+			}
+			writePatchLine(w, color, prefix, edit.Line, c)
+		}
+	}
+}
+
+func writePatchLine(w io.Writer, color string, prefix string, line string, c patchColors) {
+	text, hasNewline := strings.CutSuffix(line, "\n")
+	if color != "" {
+		fmt.Fprintf(w, "%s%s%s%s\n", color, prefix, text, c.reset)
+	} else {
+		fmt.Fprintf(w, "%s%s\n", prefix, text)
+	}
+	if !hasNewline {
+		fmt.Fprint(w, "\\ No newline at end of file\n")
+	}
+}
+
+func hunkRange(start int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func isAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func WriteCombinedPatch(w io.Writer, parentTrees []map[string]types.TreeEntry, tree map[string]types.TreeEntry, color bool) {
+	c := newPatchColors(color)
+
+	paths := map[string]bool{}
+	for _, parentTree := range parentTrees {
+		for path := range parentTree {
+			paths[path] = true
+		}
+	}
+	for path := range tree {
+		paths[path] = true
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(paths)) {
+		entry, inResult := tree[path]
+
+		// só entra no diff combinado o que difere de todos os pais
+		differsFromAll := true
+		for _, parentTree := range parentTrees {
+			parentEntry, ok := parentTree[path]
+			if ok == inResult && (!ok || parentEntry.Mode == entry.Mode && bytes.Equal(parentEntry.Hash, entry.Hash)) {
+				differsFromAll = false
+				break
+			}
+		}
+		if !differsFromAll {
+			continue
+		}
+
+		var parentHashes, parentModes []string
+		var parentLines [][]string
+		for _, parentTree := range parentTrees {
+			parentEntry, ok := parentTree[path]
+			hash := ""
+			mode := "000000"
+			if ok {
+				hash = fmt.Sprintf("%x", parentEntry.Hash)
+				mode = parentEntry.Mode
+			}
+			parentHashes = append(parentHashes, abbrevHash(hash))
+			parentModes = append(parentModes, mode)
+			parentLines = append(parentLines, splitLines(blobContent(hash)))
+		}
+
+		resultHash := ""
+		resultMode := "000000"
+		if inResult {
+			resultHash = fmt.Sprintf("%x", entry.Hash)
+			resultMode = entry.Mode
+		}
+		resultLines := splitLines(blobContent(resultHash))
+
+		hunks, flags, lost := combineDiff(parentLines, resultLines)
+		if len(hunks) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "%sdiff --cc %s%s\n", c.meta, path, c.reset)
+		sameModes := true
+		for _, mode := range parentModes {
+			if mode != resultMode {
+				sameModes = false
+			}
+		}
+		if sameModes {
+			fmt.Fprintf(w, "%sindex %s..%s%s\n", c.meta, strings.Join(parentHashes, ","), abbrevHash(resultHash), c.reset)
+		} else {
+			fmt.Fprintf(w, "%smode %s..%s%s\n", c.meta, strings.Join(parentModes, ","), resultMode, c.reset)
+			fmt.Fprintf(w, "%sindex %s..%s%s\n", c.meta, strings.Join(parentHashes, ","), abbrevHash(resultHash), c.reset)
+		}
+		if !inResult {
+			fmt.Fprintf(w, "%sdeleted file mode %s%s\n", c.meta, parentModes[0], c.reset)
+		}
+		fmt.Fprintf(w, "%s--- a/%s%s\n", c.meta, path, c.reset)
+		if inResult {
+			fmt.Fprintf(w, "%s+++ b/%s%s\n", c.meta, path, c.reset)
+		} else {
+			fmt.Fprintf(w, "%s+++ /dev/null%s\n", c.meta, c.reset)
+		}
+
+		writeCombinedHunks(w, hunks, flags, lost, parentLines, resultLines, c)
+	}
+}
+
+func combineDiff(parentLines [][]string, resultLines []string) ([]patchHunk, []int, [][]lostLine) {
+	count := len(resultLines)
+	flags := make([]int, count+1)
+	lost := make([][]lostLine, count+1)
+	allMask := (1 << len(parentLines)) - 1
+
+	for n, lines := range parentLines {
+		mask := 1 << n
+		parentLost := make([][]lostLine, count+1)
+
+		j := 0
+		for _, edit := range myersDiff(lines, resultLines) {
+			switch edit.Type {
+			case "equal":
+				j++
+			case "insert":
+				flags[j] |= mask
+				j++
+			case "delete":
+				parentLost[j] = append(parentLost[j], lostLine{text: edit.Line, mask: mask})
+			}
+		}
+
+		// junta as linhas perdidas deste pai com as dos anteriores
+		for j := range lost {
+			if len(parentLost[j]) == 0 {
+				continue
+			}
+			var base, incoming []string
+			for _, l := range lost[j] {
+				base = append(base, l.text)
+			}
+			for _, l := range parentLost[j] {
+				incoming = append(incoming, l.text)
+			}
+
+			var merged []lostLine
+			b := 0
+			for _, edit := range myersDiff(base, incoming) {
+				switch edit.Type {
+				case "equal":
+					merged = append(merged, lostLine{text: edit.Line, mask: lost[j][b].mask | mask})
+					b++
+				case "delete":
+					merged = append(merged, lost[j][b])
+					b++
+				case "insert":
+					merged = append(merged, lostLine{text: edit.Line, mask: mask})
+				}
+			}
+			lost[j] = merged
+		}
+	}
+
+	interesting := make([]bool, count+1)
+	for j := range interesting {
+		interesting[j] = flags[j]&allMask != 0 || len(lost[j]) > 0
+	}
+
+	// --cc: descarta trechos em que o resultado apenas repete um dos pais
+	for i := 0; i <= count; {
+		if !interesting[i] {
+			i++
+			continue
+		}
+
+		end := i + 1
+		for j := i + 1; j <= count && j-end <= patchContext; j++ {
+			if interesting[j] {
+				end = j + 1
+			}
+		}
+
+		sameDiff := 0
+		hasInteresting := false
+		for j := i; j < end && !hasInteresting; j++ {
+			masks := []int{}
+			if flags[j]&allMask != 0 {
+				masks = append(masks, flags[j]&allMask)
+			}
+			for _, l := range lost[j] {
+				masks = append(masks, l.mask)
+			}
+			for _, m := range masks {
+				if sameDiff == 0 {
+					sameDiff = m
+				} else if sameDiff != m {
+					hasInteresting = true
+				}
+			}
+		}
+
+		if !hasInteresting && sameDiff != allMask {
+			for j := i; j < end; j++ {
+				interesting[j] = false
+			}
+		}
+		i = end
+	}
+
+	var hunks []patchHunk
+	for j := 0; j <= count; j++ {
+		if !interesting[j] {
+			continue
+		}
+
+		start := max(0, j-patchContext)
+		end := j + 1
+		for k := j + 1; k <= count && k-end < 2*patchContext+1; k++ {
+			if interesting[k] {
+				end = k + 1
+			}
+		}
+		end = min(count+1, end+patchContext)
+
+		if len(hunks) > 0 && hunks[len(hunks)-1].end >= start {
+			hunks[len(hunks)-1].end = end
+		} else {
+			hunks = append(hunks, patchHunk{start: start, end: end})
+		}
+		j = end - 1
+	}
+
+	return hunks, flags, lost
+}
+
+func writeCombinedHunks(w io.Writer, hunks []patchHunk, flags []int, lost [][]lostLine, parentLines [][]string, resultLines []string, c patchColors) {
+	count := len(resultLines)
+	parents := len(parentLines)
+
+	// número da linha em cada pai que corresponde a cada linha do resultado
+	parentLno := make([][]int, parents)
+	for n := range parents {
+		parentLno[n] = make([]int, count+2)
+		lno := 1
+		for j := 0; j <= count; j++ {
+			parentLno[n][j] = lno
+			for _, l := range lost[j] {
+				if l.mask&(1<<n) != 0 {
+					lno++
+				}
+			}
+			if j < count && flags[j]&(1<<n) == 0 {
+				lno++
+			}
+		}
+		parentLno[n][count+1] = lno
+	}
+
+	marker := strings.Repeat("@", parents+1)
+	for _, hunk := range hunks {
+		end := min(hunk.end, count)
+
+		var header strings.Builder
+		header.WriteString(marker)
+		for n := range parents {
+			fmt.Fprintf(&header, " -%d,%d", parentLno[n][hunk.start], parentLno[n][hunk.end]-parentLno[n][hunk.start])
+		}
+		fmt.Fprintf(&header, " +%d,%d %s", hunk.start+1, end-hunk.start, marker)
+		fmt.Fprintf(w, "%s%s%s\n", c.frag, header.String(), c.reset)
+
+		for j := hunk.start; j < hunk.end; j++ {
+			for _, l := range lost[j] {
+				var prefix strings.Builder
+				for n := range parents {
+					if l.mask&(1<<n) != 0 {
+						prefix.WriteByte('-')
+					} else {
+						prefix.WriteByte(' ')
+					}
+				}
+				writePatchLine(w, c.old, prefix.String(), l.text, c)
+			}
+
+			if j >= count {
+				continue
+			}
+
+			var prefix strings.Builder
+			for n := range parents {
+				if flags[j]&(1<<n) != 0 {
+					prefix.WriteByte('+')
+				} else {
+					prefix.WriteByte(' ')
+				}
+			}
+			color := ""
+			if strings.Contains(prefix.String(), "+") {
+				color = c.new
+			}
+			writePatchLine(w, color, prefix.String(), resultLines[j], c)
+		}
+	}
+}
+
+func WriteDiffStat(w io.Writer, changes []FileChange, width int) {
+	type fileStat struct {
+		name             string
+		added, deleted   int
+		binary           bool
+		oldSize, newSize int
+	}
+
+	var stats []fileStat
+	maxLen, maxChange, numberWidth, binWidth := 0, 0, 0, 0
+	insertions, deletions := 0, 0
+
+	for _, change := range changes {
+		oldContent := blobContent(change.OldHash)
+		newContent := blobContent(change.NewHash)
+		stat := fileStat{name: change.Path}
+		if change.OldPath != "" {
+			stat.name = renameDisplayName(change.OldPath, change.Path)
+		}
+		maxLen = max(maxLen, len(stat.name))
+
+		if isBinaryContent(oldContent) || isBinaryContent(newContent) {
+			stat.binary = true
+			stat.oldSize, stat.newSize = len(oldContent), len(newContent)
+			binWidth = max(binWidth, 14+len(fmt.Sprint(stat.oldSize))+len(fmt.Sprint(stat.newSize)))
+			numberWidth = 3
+			stats = append(stats, stat)
+			continue
+		}
+
+		for _, edit := range myersDiff(splitLines(oldContent), splitLines(newContent)) {
+			switch edit.Type {
+			case "insert":
+				stat.added++
+			case "delete":
+				stat.deleted++
+			}
+		}
+		insertions += stat.added
+		deletions += stat.deleted
+		maxChange = max(maxChange, stat.added+stat.deleted)
+		stats = append(stats, stat)
+	}
+
+	numberWidth = max(numberWidth, len(fmt.Sprint(maxChange)))
+	width = max(width, 16+6+numberWidth)
+
+	graphWidth := maxChange
+	if maxChange+4 <= binWidth {
+		graphWidth = binWidth - 4
+	}
+	nameWidth := maxLen
+
+	if nameWidth+numberWidth+6+graphWidth > width {
+		if graphWidth > width*3/8-numberWidth-6 {
+			graphWidth = max(width*3/8-numberWidth-6, 6)
+		}
+		if nameWidth > width-numberWidth-6-graphWidth {
+			nameWidth = width - numberWidth - 6 - graphWidth
+		} else {
+			graphWidth = width - numberWidth - 6 - nameWidth
+		}
+	}
+
+	scale := func(n int) int {
+		if n == 0 {
+			return 0
+		}
+		return 1 + n*(graphWidth-1)/maxChange
+	}
+
+	for _, stat := range stats {
+		name, prefix := stat.name, ""
+		if len(name) > nameWidth {
+			// nomes longos perdem o começo, de preferência até uma barra
+			prefix = "..."
+			name = name[len(name)-max(nameWidth-3, 0):]
+			if slash := strings.Index(name, "/"); slash >= 0 {
+				name = name[slash:]
+			}
+		}
+		padding := max(nameWidth-len(prefix)-len(name), 0)
+
+		if stat.binary {
+			fmt.Fprintf(w, " %s%s%*s | %*s", prefix, name, padding, "", numberWidth, "Bin")
+			if stat.oldSize == 0 && stat.newSize == 0 {
+				fmt.Fprint(w, "\n")
+				continue
+			}
+			fmt.Fprintf(w, " %d -> %d bytes\n", stat.oldSize, stat.newSize)
+			continue
+		}
+
+		add, del := stat.added, stat.deleted
+		if graphWidth <= maxChange {
+			total := scale(add + del)
+			if total < 2 && add > 0 && del > 0 {
+				total = 2
+			}
+			if add < del {
+				add = scale(add)
+				del = total - add
+			} else {
+				del = scale(del)
+				add = total - del
+			}
+		}
+
+		separator := ""
+		if stat.added+stat.deleted > 0 {
+			separator = " "
+		}
+		fmt.Fprintf(w, " %s%s%*s | %*d%s%s%s\n", prefix, name, padding, "", numberWidth, stat.added+stat.deleted, separator, strings.Repeat("+", add), strings.Repeat("-", del))
+	}
+
+	writeStatTotals(w, len(stats), insertions, deletions)
+}
+
+func WriteShortStat(w io.Writer, changes []FileChange) {
+	if len(changes) == 0 {
+		return
+	}
+
+	insertions, deletions := 0, 0
+	for _, change := range changes {
+		oldContent := blobContent(change.OldHash)
+		newContent := blobContent(change.NewHash)
+		if isBinaryContent(oldContent) || isBinaryContent(newContent) {
+			continue
+		}
+
+		for _, edit := range myersDiff(splitLines(oldContent), splitLines(newContent)) {
+			switch edit.Type {
+			case "insert":
+				insertions++
+			case "delete":
+				deletions++
+			}
+		}
+	}
+
+	writeStatTotals(w, len(changes), insertions, deletions)
+}
+
+func writeStatTotals(w io.Writer, files int, insertions int, deletions int) {
+	if files == 0 {
+		fmt.Fprint(w, " 0 files changed\n")
+		return
+	}
+
+	fmt.Fprintf(w, " %d file%s changed", files, plural(files))
+	if insertions > 0 || deletions == 0 {
+		fmt.Fprintf(w, ", %d insertion%s(+)", insertions, plural(insertions))
+	}
+	if deletions > 0 || insertions == 0 {
+		fmt.Fprintf(w, ", %d deletion%s(-)", deletions, plural(deletions))
+	}
+	fmt.Fprint(w, "\n")
+}
+
+func WriteSummary(w io.Writer, changes []FileChange) {
+	for _, change := range changes {
+		switch {
+		case change.OldPath != "":
+			fmt.Fprintf(w, " rename %s (%d%%)\n", renameDisplayName(change.OldPath, change.Path), change.Similarity)
+			if change.OldMode != change.NewMode {
+				fmt.Fprintf(w, " mode change %s => %s\n", change.OldMode, change.NewMode)
+			}
+		case change.OldHash == "":
+			fmt.Fprintf(w, " create mode %s %s\n", change.NewMode, change.Path)
+		case change.NewHash == "":
+			fmt.Fprintf(w, " delete mode %s %s\n", change.OldMode, change.Path)
+		case change.OldMode != change.NewMode:
+			fmt.Fprintf(w, " mode change %s => %s %s\n", change.OldMode, change.NewMode, change.Path)
+		}
+	}
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+
+	return "s"
+}
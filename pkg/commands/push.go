@@ -21,7 +21,7 @@ func Push(args ...string) {
 	httpClient := http.DefaultClient
 
 	if len(args) < 8 {
-		fmt.Fprintf(os.Stderr, "usage: ccgit push <remote_url> <remote_branch = main> -u <username> -p <password>\n")
+		fmt.Fprintf(os.Stderr, "usage: ccgit push <remote_url> <remote_branch = main> -u <username> -p <password> [--no-verify]\n")
 		os.Exit(1)
 	}
 
@@ -64,6 +64,23 @@ func Push(args ...string) {
 		os.Exit(1)
 	}
 
+	// o pre-push recebe "<ref local> <sha local> <ref remota> <sha remota>" na entrada
+	if !slices.Contains(args, "--no-verify") {
+		localRef := "HEAD"
+		if !utils.IsHeadDetached() {
+			localRef = "refs/heads/" + utils.GetHeadBranch()
+		}
+		remoteSha := string(remoteHash)
+		if remoteSha == "" {
+			remoteSha = strings.Repeat("0", 40)
+		}
+		refs := fmt.Sprintf("%s %s %s %s\n", localRef, headHash, ref, remoteSha)
+		if runHook("pre-push", refs, nil, args[2], args[2]) != 0 {
+			fmt.Fprintf(os.Stderr, "error: failed to push some refs to '%s'\n", args[2])
+			os.Exit(1)
+		}
+	}
+
 	var object []byte
 	refLine := utils.GetUpdateRefLine(remoteHash, headHash, ref)
 	object = append(object, refLine...)
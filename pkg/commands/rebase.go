@@ -0,0 +1,995 @@
+package commands
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/types"
+	"github.com/codecrafters-io/git-starter-go/pkg/utils"
+)
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+\d+(?:,\d+)? @@`)
+
+var rebaseCommands = map[string]string{
+	"p": "pick", "pick": "pick",
+	"r": "reword", "reword": "reword",
+	"e": "edit", "edit": "edit",
+	"s": "squash", "squash": "squash",
+	"f": "fixup", "fixup": "fixup",
+	"x": "exec", "exec": "exec",
+	"d": "drop", "drop": "drop",
+}
+
+const rebaseTodoHelp = `#
+# Commands:
+# p, pick <commit> = use commit
+# r, reword <commit> = use commit, but edit the commit message
+# e, edit <commit> = use commit, but stop for amending
+# s, squash <commit> = use commit, but meld into previous commit
+# f, fixup <commit> = like "squash", but discard this commit's log message
+# x, exec <command> = run command (the rest of the line) using shell
+# d, drop <commit> = remove commit
+#
+# These lines can be re-ordered; they are executed from top to bottom.
+#
+# If you remove a line here THAT COMMIT WILL BE LOST.
+#
+# However, if you remove everything, the rebase will be aborted.
+#
+`
+
+type rebaseItem struct {
+	command string
+	hash    string
+	rest    string
+}
+
+func (item rebaseItem) String() string {
+	if item.command == "exec" {
+		return fmt.Sprintf("exec %s", item.rest)
+	}
+
+	return strings.TrimRight(fmt.Sprintf("%s %s %s", item.command, item.hash, item.rest), " ")
+}
+
+func Rebase(args ...string) {
+	err := utils.CheckGitRepo(".", false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	var upstream, branch, onto, action string
+	var strategyOptions []string
+	var positional []string
+	interactive := false
+
+	for i := 2; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "--continue", "--skip", "--abort", "--quit", "--edit-todo":
+			action = strings.TrimPrefix(arg, "--")
+		case "-i", "--interactive":
+			interactive = true
+		case "--onto":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "error: option `onto' requires a value\n")
+				os.Exit(129)
+			}
+			onto = args[i+1]
+			i++
+		case "-X", "--strategy-option":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "error: switch `X' requires a value\n")
+				os.Exit(129)
+			}
+			parseStrategyOption(&MergeOptions{}, args[i+1])
+			strategyOptions = append(strategyOptions, args[i+1])
+			i++
+		default:
+			if value, ok := strings.CutPrefix(arg, "--onto="); ok {
+				onto = value
+				continue
+			}
+			if value, ok := strings.CutPrefix(arg, "--strategy-option="); ok {
+				parseStrategyOption(&MergeOptions{}, value)
+				strategyOptions = append(strategyOptions, value)
+				continue
+			}
+			if value, ok := strings.CutPrefix(arg, "-X"); ok {
+				parseStrategyOption(&MergeOptions{}, value)
+				strategyOptions = append(strategyOptions, value)
+				continue
+			}
+			if strings.HasPrefix(arg, "-") {
+				rebaseUsage(fmt.Sprintf("error: unknown option `%s'", strings.TrimLeft(arg, "-")))
+			}
+			positional = append(positional, arg)
+		}
+	}
+
+	if action != "" {
+		if !rebaseInProgress() {
+			fmt.Fprintf(os.Stderr, "fatal: No rebase in progress?\n")
+			os.Exit(128)
+		}
+
+		switch action {
+		case "continue":
+			continueRebase()
+		case "skip":
+			skipRebase()
+		case "abort":
+			abortRebase()
+		case "quit":
+			os.RemoveAll(rebaseDir())
+		case "edit-todo":
+			editRebaseTodo()
+		}
+		return
+	}
+
+	switch len(positional) {
+	case 2:
+		branch = positional[1]
+		fallthrough
+	case 1:
+		upstream = positional[0]
+	case 0:
+	default:
+		rebaseUsage("")
+	}
+
+	startRebase(upstream, branch, onto, interactive, strategyOptions)
+}
+
+func rebaseUsage(message string) {
+	if message != "" {
+		fmt.Fprintf(os.Stderr, "%s\n", message)
+	}
+
+	fmt.Fprintf(os.Stderr, "usage: ccgit rebase [-i] [--onto <newbase>] [-X <option>] [<upstream> [<branch>]]\n")
+	fmt.Fprintf(os.Stderr, "   or: ccgit rebase (--continue | --skip | --abort | --quit | --edit-todo)\n")
+	os.Exit(129)
+}
+
+func rebaseDir() string {
+	return filepath.Join(".git", "rebase-merge")
+}
+
+func rebasePath(name string) string {
+	return filepath.Join(rebaseDir(), name)
+}
+
+func rebaseInProgress() bool {
+	_, err := os.Stat(rebaseDir())
+	return err == nil
+}
+
+func readRebaseFile(name string) string {
+	data, err := os.ReadFile(rebasePath(name))
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(data))
+}
+
+func writeRebaseFile(name string, content string) {
+	_ = os.WriteFile(rebasePath(name), []byte(content), 0644)
+}
+
+func startRebase(upstreamArg string, branchArg string, ontoArg string, interactive bool, strategyOptions []string) {
+	if rebaseInProgress() {
+		fmt.Fprintf(os.Stderr, "fatal: It seems that there is already a rebase-merge directory, and\n")
+		fmt.Fprintf(os.Stderr, "I wonder if you are in the middle of another rebase.  If that is the\n")
+		fmt.Fprintf(os.Stderr, "case, please try\n")
+		fmt.Fprintf(os.Stderr, "\tgit rebase (--continue | --abort | --skip)\n")
+		fmt.Fprintf(os.Stderr, "If that is not the case, please\n")
+		fmt.Fprintf(os.Stderr, "\trm -fr \".git/rebase-merge\"\n")
+		fmt.Fprintf(os.Stderr, "and run me again.  I am stopping in case you still have something\n")
+		fmt.Fprintf(os.Stderr, "valuable there.\n\n")
+		os.Exit(128)
+	}
+
+	head := string(utils.GetHeadHash())
+	headName := "detached HEAD"
+	if branchArg != "" {
+		if hash, err := utils.ResolveRef("refs/heads/" + branchArg); err == nil {
+			head, headName = hash, "refs/heads/"+branchArg
+		} else if hash, err := ResolveCommit(branchArg); err == nil {
+			head = hash
+		} else {
+			fmt.Fprintf(os.Stderr, "fatal: no such branch/commit '%s'\n", branchArg)
+			os.Exit(128)
+		}
+	} else if !utils.IsHeadDetached() {
+		headName = "refs/heads/" + utils.GetHeadBranch()
+	}
+
+	if upstreamArg == "" {
+		upstreamArg = rebaseDefaultUpstream(headName)
+	}
+	upstream, err := ResolveCommit(upstreamArg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: invalid upstream '%s'\n", upstreamArg)
+		os.Exit(128)
+	}
+
+	onto, ontoName := upstream, upstreamArg
+	if ontoArg != "" {
+		if onto, err = ResolveCommit(ontoArg); err != nil {
+			fmt.Fprintf(os.Stderr, "fatal: Does not point to a valid commit '%s'\n", ontoArg)
+			os.Exit(128)
+		}
+		ontoName = ontoArg
+	}
+
+	checkRebaseWorktree()
+
+	// com <branch>, o rebase parte dele como se tivesse feito checkout antes
+	if branchArg != "" && (head != string(utils.GetHeadHash()) || headName != "refs/heads/"+utils.GetHeadBranch()) {
+		if !checkoutTree(head, false, "checkout") {
+			os.Exit(1)
+		}
+
+		message := fmt.Sprintf("rebase: checkout %s", branchArg)
+		if headName == "detached HEAD" {
+			err = utils.DetachHead(head, message)
+		} else {
+			err = utils.SetHeadBranch(branchArg, message)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+			os.Exit(128)
+		}
+	}
+
+	// sem -i, uma base que já é ancestral não tem nada para refazer
+	if bases := MergeBases(upstream, head); !interactive && len(bases) > 0 && bases[0] == onto && IsAncestor(onto, head) {
+		name := strings.TrimPrefix(headName, "refs/heads/")
+		if headName == "detached HEAD" {
+			name = "HEAD"
+		}
+		fmt.Fprintf(os.Stdout, "Current branch %s is up to date.\n", name)
+		return
+	}
+
+	todo := rebaseTodoList(upstream, head)
+
+	if err := os.MkdirAll(rebaseDir(), 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: could not create temporary %s: %v\n", rebaseDir(), err)
+		os.Exit(128)
+	}
+	writeRebaseFile("head-name", headName+"\n")
+	writeRebaseFile("onto", onto+"\n")
+	writeRebaseFile("orig-head", head+"\n")
+	writeRebaseFile("interactive", "")
+	if len(strategyOptions) > 0 {
+		writeRebaseFile("strategy_opts", strings.Join(strategyOptions, "\n")+"\n")
+	}
+	_ = os.WriteFile(filepath.Join(".git", "ORIG_HEAD"), fmt.Appendf(nil, "%s\n", head), 0644)
+
+	var parseErr error
+	if interactive {
+		todo, parseErr = editInitialTodo(todo, upstream, head, onto)
+	}
+
+	// picks que já estão em cima da base entram direto, sem recriar o commit
+	current := onto
+	var done []rebaseItem
+	for parseErr == nil && len(todo) > 0 && todo[0].command == "pick" {
+		commit, err := ReadCommit(todo[0].hash)
+		if err != nil || len(commit.Parents) != 1 || commit.Parents[0] != current {
+			break
+		}
+		current = todo[0].hash
+		done, todo = append(done, todo[0]), todo[1:]
+	}
+
+	if parseErr == nil {
+		writeRebaseTodo("git-rebase-todo", todo)
+	}
+	writeRebaseTodo("done", done)
+	writeRebaseFile("msgnum", fmt.Sprintf("%d\n", len(done)))
+	writeRebaseFile("end", fmt.Sprintf("%d\n", len(done)+len(todo)))
+
+	if current != head || !utils.IsHeadDetached() {
+		if !checkoutTree(current, false, "checkout") {
+			os.RemoveAll(rebaseDir())
+			os.Exit(1)
+		}
+		if err := utils.DetachHead(current, fmt.Sprintf("rebase (start): checkout %s", ontoName)); err != nil {
+			fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+			os.Exit(128)
+		}
+	}
+
+	if parseErr != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", parseErr)
+		printTodoFixHint()
+		os.Exit(1)
+	}
+
+	runRebase()
+}
+
+func rebaseDefaultUpstream(headName string) string {
+	branch, onBranch := strings.CutPrefix(headName, "refs/heads/")
+	if onBranch {
+		if ref, ok := branchUpstreamRef(branch); ok {
+			return ref
+		}
+		fmt.Fprintf(os.Stderr, "There is no tracking information for the current branch.\n")
+	} else {
+		fmt.Fprintf(os.Stderr, "You are not currently on a branch.\n")
+	}
+
+	fmt.Fprintf(os.Stderr, "Please specify which branch you want to rebase against.\n")
+	fmt.Fprintf(os.Stderr, "See git-rebase(1) for details.\n\n")
+	fmt.Fprintf(os.Stderr, "    git rebase '<branch>'\n\n")
+	if onBranch {
+		fmt.Fprintf(os.Stderr, "If you wish to set tracking information for this branch you can do so with:\n\n")
+		fmt.Fprintf(os.Stderr, "    git branch --set-upstream-to=<remote>/<branch> %s\n\n", branch)
+	}
+	os.Exit(1)
+	return ""
+}
+
+func checkRebaseWorktree() {
+	unstaged := false
+	indexFile := ReadIndex()
+	for _, entry := range indexFile.Entries {
+		if entry.Stage != 0 || !pathExists(entry.Path) || !worktreeMatchesIndex(entry) {
+			unstaged = true
+			break
+		}
+	}
+	staged := len(stagedChanges(string(utils.GetHeadHash()))) > 0
+
+	switch {
+	case unstaged:
+		fmt.Fprintf(os.Stderr, "error: cannot rebase: You have unstaged changes.\n")
+		if staged {
+			fmt.Fprintf(os.Stderr, "error: additionally, your index contains uncommitted changes.\n")
+		}
+	case staged:
+		fmt.Fprintf(os.Stderr, "error: cannot rebase: Your index contains uncommitted changes.\n")
+	default:
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "error: Please commit or stash them.\n")
+	os.Exit(1)
+}
+
+// commits de upstream..head do mais antigo para o mais novo, sem merges e sem os que já estão em upstream
+func rebaseTodoList(upstream string, head string) []rebaseItem {
+	commits := SortTopological(WalkCommits([]string{head}, []string{upstream}, false), false)
+	if len(commits) == 0 {
+		return nil
+	}
+
+	upstreamPatches := map[string]bool{}
+	for _, commit := range WalkCommits([]string{upstream}, []string{head}, false) {
+		if len(commit.Parents) == 1 {
+			upstreamPatches[patchID(commit)] = true
+		}
+	}
+
+	var todo []rebaseItem
+	for i := len(commits) - 1; i >= 0; i-- {
+		commit := commits[i]
+		if len(commit.Parents) > 1 || (len(upstreamPatches) > 0 && upstreamPatches[patchID(commit)]) {
+			continue
+		}
+		todo = append(todo, rebaseItem{command: "pick", hash: commit.Hash, rest: formatSubject(commit.Message)})
+	}
+
+	return todo
+}
+
+// identifica a mudança independente da posição: hashes e números de linha ficam de fora
+func patchID(commit *types.CommitObject) string {
+	parent := ""
+	if len(commit.Parents) > 0 {
+		parent = commit.Parents[0]
+	}
+
+	var patch bytes.Buffer
+	WritePatch(&patch, DiffTrees(readCommitTreeEntries(parent), readCommitTreeEntries(commit.Hash)), false)
+
+	h := sha1.New()
+	for _, line := range strings.Split(patch.String(), "\n") {
+		if strings.HasPrefix(line, "index ") {
+			continue
+		}
+		h.Write([]byte(hunkHeaderRegex.ReplaceAllString(line, "@@") + "\n"))
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func editInitialTodo(todo []rebaseItem, upstream string, head string, onto string) ([]rebaseItem, error) {
+	var sb strings.Builder
+	for _, item := range todo {
+		fmt.Fprintf(&sb, "%s %s %s\n", item.command, item.hash[:7], item.rest)
+	}
+	fmt.Fprintf(&sb, "\n# Rebase %s..%s onto %s (%d command%s)\n", upstream[:7], head[:7], onto[:7], len(todo), plural(len(todo)))
+	sb.WriteString(rebaseTodoHelp)
+
+	path := rebasePath("git-rebase-todo")
+	writeRebaseFile("git-rebase-todo", sb.String())
+	writeRebaseFile("git-rebase-todo.backup", sb.String())
+
+	if err := launchEditor(sequenceEditor(), path); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.RemoveAll(rebaseDir())
+		os.Exit(1)
+	}
+
+	content, _ := os.ReadFile(path)
+	items, err := parseRebaseTodo(string(content), true)
+	if err == nil && len(items) == 0 {
+		fmt.Fprintf(os.Stderr, "error: nothing to do\n")
+		os.RemoveAll(rebaseDir())
+		os.Exit(1)
+	}
+
+	return items, err
+}
+
+func parseRebaseTodo(content string, first bool) ([]rebaseItem, error) {
+	var items []rebaseItem
+	var errs []string
+
+	for n, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		word, rest, _ := strings.Cut(line, " ")
+		command, ok := rebaseCommands[word]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("error: invalid line %d: %s", n+1, line))
+			continue
+		}
+
+		rest = strings.TrimSpace(rest)
+		if command == "exec" {
+			items = append(items, rebaseItem{command: command, rest: rest})
+			continue
+		}
+
+		rev, subject, _ := strings.Cut(rest, " ")
+		hash, err := ResolveCommit(rev)
+		if rev == "" || err != nil {
+			if rev != "" {
+				errs = append(errs, fmt.Sprintf("error: could not parse '%s'", rev))
+			}
+			errs = append(errs, fmt.Sprintf("error: invalid line %d: %s", n+1, line))
+			continue
+		}
+		items = append(items, rebaseItem{command: command, hash: hash, rest: strings.TrimSpace(subject)})
+	}
+
+	if len(errs) > 0 {
+		return items, fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+
+	// squash e fixup precisam de um commit anterior feito pelo próprio rebase
+	for _, item := range items {
+		if item.command == "squash" || item.command == "fixup" {
+			if first {
+				return items, fmt.Errorf("error: cannot '%s' without a previous commit", item.command)
+			}
+			break
+		}
+		if item.command != "exec" && item.command != "drop" {
+			break
+		}
+	}
+
+	return items, nil
+}
+
+func printTodoFixHint() {
+	fmt.Fprintf(os.Stderr, "You can fix this with 'git rebase --edit-todo' and then run 'git rebase --continue'.\n")
+	fmt.Fprintf(os.Stderr, "Or you can abort the rebase with 'git rebase --abort'.\n")
+}
+
+func readRebaseTodo(name string) []rebaseItem {
+	data, err := os.ReadFile(rebasePath(name))
+	if err != nil {
+		return nil
+	}
+
+	items, err := parseRebaseTodo(string(data), false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		printTodoFixHint()
+		os.Exit(1)
+	}
+
+	return items
+}
+
+func writeRebaseTodo(name string, items []rebaseItem) {
+	var sb strings.Builder
+	for _, item := range items {
+		fmt.Fprintf(&sb, "%s\n", item)
+	}
+
+	writeRebaseFile(name, sb.String())
+}
+
+func rebaseStrategyOptions() []string {
+	content := readRebaseFile("strategy_opts")
+	if content == "" {
+		return nil
+	}
+
+	return strings.Split(content, "\n")
+}
+
+func runRebase() {
+	for {
+		todo := readRebaseTodo("git-rebase-todo")
+		if len(todo) == 0 {
+			finishRebase()
+			return
+		}
+
+		item := todo[0]
+		writeRebaseTodo("done", append(readRebaseTodo("done"), item))
+		writeRebaseTodo("git-rebase-todo", todo[1:])
+
+		msgnum := 0
+		fmt.Sscanf(readRebaseFile("msgnum"), "%d", &msgnum)
+		msgnum++
+		writeRebaseFile("msgnum", fmt.Sprintf("%d\n", msgnum))
+		fmt.Fprintf(os.Stderr, "Rebasing (%d/%s)\r", msgnum, readRebaseFile("end"))
+
+		switch item.command {
+		case "drop":
+		case "exec":
+			runRebaseExec(item)
+		default:
+			if !rebasePick(item, todo[1:]) {
+				return
+			}
+		}
+	}
+}
+
+// false quando o rebase parou (edit) e deve esperar --continue
+func rebasePick(item rebaseItem, next []rebaseItem) bool {
+	commit, err := ReadCommit(item.hash)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: could not parse '%s'\n", item.hash)
+		os.Exit(1)
+	}
+	if len(commit.Parents) > 1 {
+		fmt.Fprintf(os.Stderr, "error: commit %s is a merge but no -m option was given.\n", item.hash)
+		os.Exit(1)
+	}
+
+	parent := ""
+	if len(commit.Parents) == 1 {
+		parent = commit.Parents[0]
+	}
+	head := string(utils.GetHeadHash())
+
+	if parent == head && item.command != "squash" && item.command != "fixup" {
+		if !checkoutTree(item.hash, false, "checkout") {
+			rescheduleRebaseItem(item)
+		}
+		if err := utils.UpdateHead(item.hash, "rebase: fast-forward"); err != nil {
+			fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+			os.Exit(128)
+		}
+		if item.command == "reword" {
+			return commitRebaseItem(item, commit.Author, commit.Message, readCommitTreeHash(item.hash), []string{parent}, next, false)
+		}
+		return item.command != "edit" || stopForEdit(item, commit.Author, commit.Message)
+	}
+
+	result, ok := pickChanges(item.hash, parent, false, rebaseStrategyOptions())
+	if !ok {
+		rescheduleRebaseItem(item)
+	}
+
+	if !result.Clean() {
+		stopRebase(item, commit, result)
+		os.Exit(1)
+	}
+
+	return commitRebaseItem(item, commit.Author, commit.Message, BuildTree(result.Tree), []string{head}, next, false)
+}
+
+func readCommitTreeHash(hash string) [20]byte {
+	var tree [20]byte
+	decoded, _ := hex.DecodeString(commitTreeHash(hash))
+	copy(tree[:], decoded)
+	return tree
+}
+
+// cria o commit de um item já aplicado no índice; squash e fixup reescrevem o commit anterior
+func commitRebaseItem(item rebaseItem, author string, message string, tree [20]byte, parents []string, next []rebaseItem, continuing bool) bool {
+	message = strings.TrimRight(message, "\n")
+	head := string(utils.GetHeadHash())
+
+	if item.command == "squash" || item.command == "fixup" {
+		return squashRebaseItem(item, message, tree, next, continuing)
+	}
+
+	if !continuing && item.command != "reword" && fmt.Sprintf("%x", tree) == commitTreeHash(head) {
+		fmt.Fprintf(os.Stderr, "dropping %s %s -- patch contents already upstream\n", item.hash, formatSubject(message))
+		return true
+	}
+
+	reflogAction := "pick"
+	switch {
+	case continuing:
+		reflogAction = "continue"
+	case item.command == "reword":
+		reflogAction = "reword"
+		edited, ok := editCommitMessage(message)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Aborting commit due to empty commit message.\n")
+			writeRebaseFile("message", message+"\n")
+			writeRebaseFile("stopped-sha", item.hash+"\n")
+			writeAuthorScript(author)
+			os.Exit(1)
+		}
+		message = edited
+	}
+
+	subject, _, _ := strings.Cut(message, "\n")
+	hash := createRebaseCommit(tree, parents, author, message, fmt.Sprintf("rebase (%s): %s", reflogAction, subject))
+	if continuing || item.command == "reword" {
+		printCommitSummary(hash, item.command == "reword")
+	}
+
+	return continuing || item.command != "edit" || stopForEdit(item, author, message)
+}
+
+// junta a mensagem do commit atual com a de cada squash/fixup seguido, como o git mostra no editor
+func squashRebaseItem(item rebaseItem, message string, tree [20]byte, next []rebaseItem, continuing bool) bool {
+	head := string(utils.GetHeadHash())
+	headCommit, err := ReadCommit(head)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: cannot '%s' without a previous commit\n", item.command)
+		os.Exit(1)
+	}
+
+	fixups := strings.Fields(readRebaseFile("current-fixups"))
+	squashMessage := readRebaseFile("message-squash")
+	if len(fixups) == 0 {
+		squashMessage = fmt.Sprintf("# This is a combination of 2 commits.\n# This is the 1st commit message:\n\n%s", strings.TrimRight(headCommit.Message, "\n"))
+	}
+
+	count := len(fixups)/2 + 2
+	_, body, _ := strings.Cut(squashMessage, "\n")
+	squashMessage = fmt.Sprintf("# This is a combination of %d commits.\n%s\n\n", count, body)
+	if item.command == "squash" {
+		squashMessage += fmt.Sprintf("# This is the commit message #%d:\n\n%s", count, message)
+	} else {
+		squashMessage += fmt.Sprintf("# The commit message #%d will be skipped:\n\n%s", count, commentLines(message))
+	}
+	fixups = append(fixups, item.command, item.hash)
+
+	final := len(next) == 0 || (next[0].command != "squash" && next[0].command != "fixup")
+	commitMessage := squashMessage
+	hasSquash := strings.Contains(" "+strings.Join(fixups, " ")+" ", " squash ")
+	if final {
+		commitMessage = cleanupMessage(squashMessage)
+		if hasSquash {
+			edited, ok := editCommitMessage(squashMessage)
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Aborting commit due to empty commit message.\n")
+				os.Exit(1)
+			}
+			commitMessage = edited
+		}
+		os.Remove(rebasePath("current-fixups"))
+		os.Remove(rebasePath("message-squash"))
+	} else {
+		writeRebaseFile("current-fixups", strings.Join(fixups, " ")+"\n")
+		writeRebaseFile("message-squash", squashMessage+"\n")
+	}
+
+	reflogAction := item.command
+	if continuing {
+		reflogAction = "continue"
+	}
+	subject, _, _ := strings.Cut(commitMessage, "\n")
+	hash := createRebaseCommit(tree, headCommit.Parents, headCommit.Author, commitMessage, fmt.Sprintf("rebase (%s): %s", reflogAction, subject))
+	if final && hasSquash {
+		printCommitSummary(hash, true)
+	}
+
+	return true
+}
+
+func commentLines(message string) string {
+	var lines []string
+	for _, line := range strings.Split(message, "\n") {
+		if line == "" {
+			lines = append(lines, "#")
+		} else {
+			lines = append(lines, "# "+line)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// abre o editor com a mensagem; false quando ela volta vazia
+func editCommitMessage(message string) (string, bool) {
+	path := filepath.Join(".git", "COMMIT_EDITMSG")
+	content := message + "\n\n# Please enter the commit message for your changes. Lines starting\n# with '#' will be ignored, and an empty message aborts the commit.\n"
+	_ = os.WriteFile(path, []byte(content), 0644)
+
+	if err := launchEditor(gitEditor(), path); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return "", false
+	}
+
+	data, _ := os.ReadFile(path)
+	edited := cleanupMessage(string(data))
+	return edited, edited != ""
+}
+
+func createRebaseCommit(tree [20]byte, parents []string, author string, message string, reflogMessage string) string {
+	hash, object := utils.GetCommitHashObjectWithAuthor(tree, parents, author, message)
+	utils.SaveHashedObject(hash, object)
+
+	if err := utils.UpdateHead(fmt.Sprintf("%x", hash), reflogMessage); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(128)
+	}
+
+	return fmt.Sprintf("%x", hash)
+}
+
+func stopForEdit(item rebaseItem, author string, message string) bool {
+	head := string(utils.GetHeadHash())
+	writeRebaseFile("amend", head+"\n")
+	writeRebaseFile("stopped-sha", item.hash+"\n")
+	writeRebaseFile("message", strings.TrimRight(message, "\n")+"\n")
+	writeAuthorScript(author)
+
+	clearProgressLine()
+	fmt.Fprintf(os.Stderr, "Stopped at %s...  %s\n", item.hash[:7], item.rest)
+	// não há commit --amend: o continueRebase emenda o commit com o que estiver no índice
+	fmt.Fprintf(os.Stderr, "You can amend the commit now by staging your changes and running\n\n")
+	fmt.Fprintf(os.Stderr, "  ccgit rebase --continue\n")
+	return false
+}
+
+func stopRebase(item rebaseItem, commit *types.CommitObject, result *MergeResult) {
+	subject := formatSubject(commit.Message)
+	message := strings.TrimRight(commit.Message, "\n")
+
+	writeRebaseFile("message", message+"\n")
+	writeRebaseFile("stopped-sha", item.hash+"\n")
+	writeAuthorScript(commit.Author)
+	writeMergeMessage(message, result)
+	_ = os.WriteFile(filepath.Join(".git", "REBASE_HEAD"), fmt.Appendf(nil, "%s\n", item.hash), 0644)
+
+	fmt.Fprintf(os.Stderr, "error: could not apply %s... %s\n", item.hash[:7], subject)
+	fmt.Fprintf(os.Stderr, "hint: Resolve all conflicts manually, mark them as resolved with\n")
+	fmt.Fprintf(os.Stderr, "hint: \"git add/rm <conflicted_files>\", then run \"git rebase --continue\".\n")
+	fmt.Fprintf(os.Stderr, "hint: You can instead skip this commit: run \"git rebase --skip\".\n")
+	fmt.Fprintf(os.Stderr, "hint: To abort and get back to the state before \"git rebase\", run \"git rebase --abort\".\n")
+	fmt.Fprintf(os.Stderr, "Could not apply %s... %s\n", item.hash[:7], subject)
+}
+
+// a árvore de trabalho impediu o item; ele volta para o início da lista
+func rescheduleRebaseItem(item rebaseItem) {
+	done := readRebaseTodo("done")
+	if len(done) > 0 {
+		writeRebaseTodo("done", done[:len(done)-1])
+	}
+	writeRebaseTodo("git-rebase-todo", append([]rebaseItem{item}, readRebaseTodo("git-rebase-todo")...))
+
+	fmt.Fprintf(os.Stderr, "hint: Could not execute the todo command\n")
+	fmt.Fprintf(os.Stderr, "hint: \n")
+	fmt.Fprintf(os.Stderr, "hint:     %s\n", abbreviateRebaseItem(item))
+	fmt.Fprintf(os.Stderr, "hint: \n")
+	fmt.Fprintf(os.Stderr, "hint: It has been rescheduled; To edit the command before continuing, please\n")
+	fmt.Fprintf(os.Stderr, "hint: edit the todo list first:\n")
+	fmt.Fprintf(os.Stderr, "hint: \n")
+	fmt.Fprintf(os.Stderr, "hint:     git rebase --edit-todo\n")
+	fmt.Fprintf(os.Stderr, "hint:     git rebase --continue\n")
+	os.Exit(1)
+}
+
+func runRebaseExec(item rebaseItem) {
+	clearProgressLine()
+	fmt.Fprintf(os.Stderr, "Executing: %s\n", item.rest)
+
+	cmd := exec.Command("sh", "-c", item.rest)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: execution failed: %s\n", item.rest)
+		fmt.Fprintf(os.Stderr, "You can fix the problem, and then run\n\n")
+		fmt.Fprintf(os.Stderr, "  git rebase --continue\n\n\n")
+		os.Exit(1)
+	}
+}
+
+func abbreviateRebaseItem(item rebaseItem) string {
+	if item.hash == "" {
+		return item.String()
+	}
+
+	return fmt.Sprintf("%s %s %s", item.command, item.hash[:7], item.rest)
+}
+
+// apaga o "Rebasing (n/m)" antes de uma mensagem que precisa ficar na tela
+func clearProgressLine() {
+	fmt.Fprintf(os.Stderr, "\r\033[K")
+}
+
+// mesmo formato de shell que o git usa em rebase-merge/author-script
+func writeAuthorScript(author string) {
+	signature := utils.ParseSignature(author)
+	_, date, _ := strings.Cut(author, "> ")
+
+	quote := func(value string) string {
+		return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+	}
+	content := fmt.Sprintf("GIT_AUTHOR_NAME=%s\nGIT_AUTHOR_EMAIL=%s\nGIT_AUTHOR_DATE=%s\n", quote(signature.Name), quote(signature.Email), quote("@"+date))
+	writeRebaseFile("author-script", content)
+}
+
+func readAuthorScript() string {
+	values := map[string]string{}
+	for _, line := range strings.Split(readRebaseFile("author-script"), "\n") {
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		value = strings.ReplaceAll(value, `'\''`, "'")
+		values[key] = strings.TrimSuffix(strings.TrimPrefix(value, "'"), "'")
+	}
+
+	if values["GIT_AUTHOR_NAME"] == "" {
+		return utils.AuthorIdent()
+	}
+
+	return fmt.Sprintf("%s <%s> %s", values["GIT_AUTHOR_NAME"], values["GIT_AUTHOR_EMAIL"], strings.TrimPrefix(values["GIT_AUTHOR_DATE"], "@"))
+}
+
+func clearRebaseStop() {
+	for _, name := range []string{"amend", "stopped-sha", "message", "author-script"} {
+		os.Remove(rebasePath(name))
+	}
+	os.Remove(filepath.Join(".git", "REBASE_HEAD"))
+	removeMergeState()
+}
+
+func continueRebase() {
+	if hasUnmergedEntries() {
+		var paths []string
+		for _, entry := range ReadIndex().Entries {
+			if entry.Stage != 0 && (len(paths) == 0 || paths[len(paths)-1] != entry.Path) {
+				paths = append(paths, entry.Path)
+			}
+		}
+		for _, path := range paths {
+			fmt.Fprintf(os.Stdout, "%s: needs merge\n", path)
+		}
+		fmt.Fprintf(os.Stdout, "You must edit all merge conflicts and then\n")
+		fmt.Fprintf(os.Stdout, "mark them as resolved using git add\n")
+		os.Exit(1)
+	}
+
+	head := string(utils.GetHeadHash())
+	stopped := readRebaseFile("stopped-sha")
+	amend := readRebaseFile("amend")
+	staged := len(stagedChanges(head)) > 0
+
+	switch {
+	case amend != "":
+		// parado num edit: o que ficou no índice entra no commit editado
+		if amend == head && staged {
+			commit, _ := ReadCommit(head)
+			message := strings.TrimRight(commit.Message, "\n")
+			subject, _, _ := strings.Cut(message, "\n")
+			hash := createRebaseCommit(WriteTree(), commit.Parents, commit.Author, message, fmt.Sprintf("rebase (continue): %s", subject))
+			printCommitSummary(hash, true)
+		}
+	case stopped != "" && staged:
+		done := readRebaseTodo("done")
+		item := rebaseItem{command: "pick", hash: stopped}
+		if len(done) > 0 {
+			item = done[len(done)-1]
+		}
+		commitRebaseItem(item, readAuthorScript(), readRebaseFile("message"), WriteTree(), []string{head}, readRebaseTodo("git-rebase-todo"), true)
+	}
+
+	clearRebaseStop()
+	runRebase()
+}
+
+func skipRebase() {
+	abortMerge()
+	clearRebaseStop()
+	runRebase()
+}
+
+func abortRebase() {
+	headName := readRebaseFile("head-name")
+	origHead := readRebaseFile("orig-head")
+
+	if !checkoutTree(origHead, true, "reset") {
+		os.Exit(1)
+	}
+
+	message := fmt.Sprintf("rebase (abort): returning to %s", headName)
+	if branch, ok := strings.CutPrefix(headName, "refs/heads/"); ok {
+		err := utils.SetHeadBranch(branch, message)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+			os.Exit(128)
+		}
+	} else if err := utils.DetachHead(origHead, message); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(128)
+	}
+
+	clearRebaseStop()
+	os.RemoveAll(rebaseDir())
+}
+
+func editRebaseTodo() {
+	path := rebasePath("git-rebase-todo")
+	if err := launchEditor(sequenceEditor(), path); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	content, _ := os.ReadFile(path)
+	items, err := parseRebaseTodo(string(content), false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		printTodoFixHint()
+		os.Exit(1)
+	}
+
+	writeRebaseTodo("git-rebase-todo", items)
+	writeRebaseFile("end", fmt.Sprintf("%d\n", len(readRebaseTodo("done"))+len(items)))
+}
+
+func finishRebase() {
+	headName := readRebaseFile("head-name")
+	onto := readRebaseFile("onto")
+	head := string(utils.GetHeadHash())
+
+	if branch, ok := strings.CutPrefix(headName, "refs/heads/"); ok {
+		if err := utils.UpdateRef(headName, head, fmt.Sprintf("rebase (finish): %s onto %s", headName, onto)); err != nil {
+			fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+			os.Exit(128)
+		}
+		if err := utils.SetHeadBranch(branch, fmt.Sprintf("rebase (finish): returning to %s", headName)); err != nil {
+			fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+			os.Exit(128)
+		}
+	}
+
+	clearRebaseStop()
+	os.RemoveAll(rebaseDir())
+	clearProgressLine()
+	fmt.Fprintf(os.Stderr, "Successfully rebased and updated %s.\n", headName)
+}
@@ -0,0 +1,323 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/types"
+)
+
+const (
+	renameMaxScore     = 60000
+	renameDefaultScore = 30000
+	renameSpanHashBase = 107927
+	renameCandidates   = 4
+)
+
+type Rename struct {
+	Path  string
+	Score int
+}
+
+type renameCandidate struct {
+	src, dst  string
+	score     int
+	nameScore int
+}
+
+// detecta arquivos removidos de oldTree que reaparecem em newTree com outro nome
+func DetectRenames(oldTree map[string]types.TreeEntry, newTree map[string]types.TreeEntry, minScore int, relevant func(path string) bool) map[string]Rename {
+	var sources, dests []string
+	for _, path := range sortedPaths(oldTree) {
+		if _, ok := newTree[path]; !ok {
+			sources = append(sources, path)
+		}
+	}
+	for _, path := range sortedPaths(newTree) {
+		if _, ok := oldTree[path]; !ok {
+			dests = append(dests, path)
+		}
+	}
+
+	renames := map[string]Rename{}
+	used := map[string]bool{}
+	paired := map[string]bool{}
+
+	// renomeações exatas primeiro, preferindo o mesmo nome base
+	for _, dst := range dests {
+		best, bestScore := "", -1
+		for _, src := range sources {
+			srcEntry, dstEntry := oldTree[src], newTree[dst]
+			if used[src] || !sameHash(srcEntry, dstEntry) || isRegularMode(srcEntry.Mode) != isRegularMode(dstEntry.Mode) {
+				continue
+			}
+			if len(readBlob(fmt.Sprintf("%x", srcEntry.Hash))) == 0 {
+				continue
+			}
+
+			score := basenameScore(src, dst)
+			if score > bestScore {
+				best, bestScore = src, score
+			}
+		}
+		if best != "" {
+			renames[best] = Rename{Path: dst, Score: renameMaxScore}
+			used[best] = true
+			paired[dst] = true
+		}
+	}
+
+	var remainingSources, remainingDests []string
+	for _, src := range sources {
+		if !used[src] && isRegularMode(oldTree[src].Mode) && relevant(src) {
+			remainingSources = append(remainingSources, src)
+		}
+	}
+	for _, dst := range dests {
+		if !paired[dst] && isRegularMode(newTree[dst].Mode) {
+			remainingDests = append(remainingDests, dst)
+		}
+	}
+	if len(remainingSources) == 0 || len(remainingDests) == 0 {
+		return renames
+	}
+
+	contents := map[string][]byte{}
+	spans := map[string]map[uint32]int{}
+	load := func(tree map[string]types.TreeEntry, path string) {
+		key := fmt.Sprintf("%x", tree[path].Hash)
+		if _, ok := contents[key]; !ok {
+			contents[key] = readBlob(key)
+			spans[key] = hashSpans(contents[key])
+		}
+	}
+	similarity := func(src string, dst string, minScore int) int {
+		load(oldTree, src)
+		load(newTree, dst)
+		srcKey, dstKey := fmt.Sprintf("%x", oldTree[src].Hash), fmt.Sprintf("%x", newTree[dst].Hash)
+		return similarityScore(len(contents[srcKey]), spans[srcKey], len(contents[dstKey]), spans[dstKey], minScore)
+	}
+
+	// nomes base únicos dos dois lados são pareados antes, com um limiar mais alto
+	basenameMinScore := minScore + (renameMaxScore-minScore)/2
+	srcBasenames, dstBasenames := countBasenames(remainingSources), countBasenames(remainingDests)
+	for _, src := range remainingSources {
+		name := filepath.Base(src)
+		if srcBasenames[name] != 1 || dstBasenames[name] != 1 {
+			continue
+		}
+
+		for _, dst := range remainingDests {
+			if filepath.Base(dst) != name || paired[dst] {
+				continue
+			}
+			if score := similarity(src, dst, basenameMinScore); score >= basenameMinScore {
+				renames[src] = Rename{Path: dst, Score: score}
+				used[src] = true
+				paired[dst] = true
+			}
+		}
+	}
+
+	var candidates []renameCandidate
+	for _, dst := range remainingDests {
+		if paired[dst] {
+			continue
+		}
+
+		var best []renameCandidate
+		for _, src := range remainingSources {
+			if used[src] {
+				continue
+			}
+			if score := similarity(src, dst, minScore); score >= minScore {
+				best = append(best, renameCandidate{src: src, dst: dst, score: score, nameScore: basenameScore(src, dst)})
+			}
+		}
+		slices.SortStableFunc(best, compareRenameCandidates)
+		candidates = append(candidates, best[:min(len(best), renameCandidates)]...)
+	}
+
+	slices.SortStableFunc(candidates, compareRenameCandidates)
+	for _, candidate := range candidates {
+		if used[candidate.src] || paired[candidate.dst] {
+			continue
+		}
+		renames[candidate.src] = Rename{Path: candidate.dst, Score: candidate.score}
+		used[candidate.src] = true
+		paired[candidate.dst] = true
+	}
+
+	return renames
+}
+
+// como DiffTrees, mas um arquivo removido e outro adicionado com conteúdo parecido viram uma única renomeação
+func DiffTreesWithRenames(oldTree map[string]types.TreeEntry, newTree map[string]types.TreeEntry) []FileChange {
+	renames := DetectRenames(oldTree, newTree, renameDefaultScore, func(string) bool { return true })
+	if len(renames) == 0 {
+		return DiffTrees(oldTree, newTree)
+	}
+
+	sources := map[string]string{}
+	for src, rename := range renames {
+		sources[rename.Path] = src
+	}
+
+	var changes []FileChange
+	for _, change := range DiffTrees(oldTree, newTree) {
+		if _, ok := renames[change.Path]; ok && change.NewHash == "" {
+			continue
+		}
+
+		if src, ok := sources[change.Path]; ok {
+			change.OldPath = src
+			change.OldMode = oldTree[src].Mode
+			change.OldHash = fmt.Sprintf("%x", oldTree[src].Hash)
+			change.Similarity = renames[src].Score * 100 / renameMaxScore
+		}
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+// "a/b/c => a/d/c" vira "a/{b => d}/c"
+func renameDisplayName(oldPath string, newPath string) string {
+	prefix := 0
+	for i := 0; i < len(oldPath) && i < len(newPath) && oldPath[i] == newPath[i]; i++ {
+		if oldPath[i] == '/' {
+			prefix = i + 1
+		}
+	}
+
+	suffix := 0
+	adjust := 0
+	if prefix > 0 {
+		adjust = 1
+	}
+	for i, j := len(oldPath), len(newPath); i >= prefix-adjust && j >= prefix-adjust; i, j = i-1, j-1 {
+		a, b := byte(0), byte(0)
+		if i < len(oldPath) {
+			a = oldPath[i]
+		}
+		if j < len(newPath) {
+			b = newPath[j]
+		}
+		if a != b {
+			break
+		}
+		if a == '/' {
+			suffix = len(oldPath) - i
+		}
+	}
+
+	oldMiddle := oldPath[prefix:max(len(oldPath)-suffix, prefix)]
+	newMiddle := newPath[prefix:max(len(newPath)-suffix, prefix)]
+	if prefix+suffix == 0 {
+		return oldMiddle + " => " + newMiddle
+	}
+
+	return oldPath[:prefix] + "{" + oldMiddle + " => " + newMiddle + "}" + oldPath[len(oldPath)-suffix:]
+}
+
+func compareRenameCandidates(a renameCandidate, b renameCandidate) int {
+	if a.score != b.score {
+		return b.score - a.score
+	}
+
+	return b.nameScore - a.nameScore
+}
+
+func countBasenames(paths []string) map[string]int {
+	counts := map[string]int{}
+	for _, path := range paths {
+		counts[filepath.Base(path)]++
+	}
+
+	return counts
+}
+
+func basenameScore(src string, dst string) int {
+	if filepath.Base(src) == filepath.Base(dst) {
+		return 1
+	}
+
+	return 0
+}
+
+func isRegularMode(mode string) bool {
+	return mode == "100644" || mode == "100755"
+}
+
+// quanto do conteúdo de destino veio da origem, em trechos de até 64 bytes terminados em quebra de linha
+func similarityScore(srcSize int, srcSpans map[uint32]int, dstSize int, dstSpans map[uint32]int, minScore int) int {
+	maxSize, baseSize := max(srcSize, dstSize), min(srcSize, dstSize)
+	if baseSize*(renameMaxScore-minScore) < (maxSize-baseSize)*renameMaxScore || maxSize == 0 {
+		return 0
+	}
+
+	copied := 0
+	for hash, count := range srcSpans {
+		copied += min(count, dstSpans[hash])
+	}
+
+	return int(int64(copied) * renameMaxScore / int64(maxSize))
+}
+
+func hashSpans(content []byte) map[uint32]int {
+	text := !isBinaryContent(content)
+	spans := map[uint32]int{}
+
+	var accum1, accum2 uint32
+	n := 0
+	for i, b := range content {
+		c := uint32(b)
+		old := accum1
+
+		if text && c == '\r' && i+1 < len(content) && content[i+1] == '\n' {
+			continue
+		}
+
+		accum1 = (accum1 << 7) ^ (accum2 >> 25)
+		accum2 = (accum2 << 7) ^ (old >> 25)
+		accum1 += c
+		n++
+		if n < 64 && c != '\n' {
+			continue
+		}
+
+		spans[(accum1+accum2*0x61)%renameSpanHashBase] += n
+		n, accum1, accum2 = 0, 0, 0
+	}
+	if n > 0 {
+		spans[(accum1+accum2*0x61)%renameSpanHashBase] += n
+	}
+
+	return spans
+}
+
+// "50%" vale metade, e dígitos sem porcentagem são lidos como fração decimal ("5" também vale metade)
+func parseRenameScore(value string) (int, bool) {
+	if percent, ok := strings.CutSuffix(value, "%"); ok {
+		n, err := strconv.Atoi(percent)
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		return min(n, 100) * renameMaxScore / 100, true
+	}
+
+	n, err := strconv.Atoi(value[:min(len(value), 9)])
+	if err != nil || n < 0 {
+		return 0, false
+	}
+
+	scale := 1
+	for range value[:min(len(value), 9)] {
+		scale *= 10
+	}
+
+	return min(n*renameMaxScore/scale, renameMaxScore), true
+}
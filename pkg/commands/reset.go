@@ -0,0 +1,217 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/types"
+	"github.com/codecrafters-io/git-starter-go/pkg/utils"
+)
+
+func Reset(args ...string) {
+	err := utils.CheckGitRepo(".", false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	mode := ""
+	quiet := false
+	var positional []string
+	var pathspecs []string
+	hasSeparator := false
+
+	for i := 2; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "--soft", "--mixed", "--hard", "--keep", "--merge":
+			if mode != "" && mode != strings.TrimPrefix(arg, "--") {
+				fmt.Fprintf(os.Stderr, "fatal: options '--%s' and '%s' cannot be used together\n", mode, arg)
+				os.Exit(1)
+			}
+			mode = strings.TrimPrefix(arg, "--")
+		case "-q", "--quiet":
+			quiet = true
+		case "--":
+			pathspecs = append(pathspecs, args[i+1:]...)
+			hasSeparator = true
+			i = len(args)
+		default:
+			if strings.HasPrefix(arg, "-") {
+				fmt.Fprintf(os.Stderr, "error: unknown option `%s'\n", strings.TrimLeft(arg, "-"))
+				fmt.Fprintf(os.Stderr, "usage: ccgit reset [--soft | --mixed | --hard | --keep] [<commit>]\n")
+				fmt.Fprintf(os.Stderr, "   or: ccgit reset [<tree-ish>] [--] <pathspec>...\n")
+				os.Exit(1)
+			}
+			positional = append(positional, arg)
+		}
+	}
+
+	rev := "HEAD"
+	if len(positional) > 0 {
+		if _, err := ResolveRevision(positional[0]); err == nil || hasSeparator {
+			rev = positional[0]
+			positional = positional[1:]
+		}
+	}
+	pathspecs = append(positional, pathspecs...)
+
+	if len(pathspecs) > 0 {
+		if mode != "" && mode != "mixed" {
+			fmt.Fprintf(os.Stderr, "fatal: Cannot do %s reset with paths.\n", mode)
+			os.Exit(1)
+		}
+
+		if !resetPaths(rev, pathspecs) {
+			os.Exit(1)
+		}
+		if !quiet {
+			printUnstagedChanges()
+		}
+		return
+	}
+
+	if mode == "" {
+		mode = "mixed"
+	}
+
+	target, err := ResolveCommit(rev)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: ambiguous argument '%s': unknown revision or path not in the working tree.\n", rev)
+		os.Exit(1)
+	}
+
+	if !ResetToCommit(target, mode, fmt.Sprintf("reset: moving to %s", rev)) {
+		os.Exit(1)
+	}
+
+	switch mode {
+	case "hard", "keep", "merge":
+		if !quiet {
+			fmt.Fprintf(os.Stdout, "HEAD is now at %s\n", describeCommitOneline(target))
+		}
+	case "mixed":
+		if !quiet {
+			printUnstagedChanges()
+		}
+	}
+}
+
+func ResetToCommit(target string, mode string, message string) bool {
+	indexFile := ReadIndex()
+	for _, entry := range indexFile.Entries {
+		if entry.Stage != 0 && mode == "soft" {
+			fmt.Fprintf(os.Stderr, "fatal: Cannot do a soft reset in the middle of a merge.\n")
+			return false
+		}
+	}
+
+	headHash := string(utils.GetHeadHash())
+
+	switch mode {
+	case "hard":
+		if !checkoutTree(target, true, "reset") {
+			return false
+		}
+	case "keep", "merge":
+		if !checkoutTree(target, false, "reset") {
+			fmt.Fprintf(os.Stderr, "fatal: Could not reset index file to revision '%s'.\n", target[:7])
+			return false
+		}
+	case "mixed":
+		resetIndexToTree(readCommitTreeEntries(target))
+	}
+
+	if headHash != "" {
+		_ = os.WriteFile(filepath.Join(".git", "ORIG_HEAD"), fmt.Appendf(nil, "%s\n", headHash), 0644)
+	}
+
+	if err := utils.UpdateHead(target, message); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		return false
+	}
+
+	if mode != "soft" {
+		removeMergeState()
+	}
+
+	return true
+}
+
+func resetIndexToTree(tree map[string]types.TreeEntry) {
+	current := map[string]types.Entry{}
+	for _, entry := range ReadIndex().Entries {
+		if entry.Stage == 0 {
+			current[entry.Path] = entry
+		}
+	}
+
+	entries := map[string]types.Entry{}
+	for path, treeEntry := range tree {
+		if entry, ok := current[path]; ok && indexMatchesTreeEntry(entry, treeEntry) {
+			entries[path] = entry
+			continue
+		}
+		entries[path] = newIndexEntryFromTree(path, treeEntry)
+	}
+
+	WriteIndex(sortedIndexEntries(entries))
+}
+
+func resetPaths(rev string, pathspecs []string) bool {
+	if len(utils.GetHeadHash()) == 0 && rev == "HEAD" {
+		// sem commits ainda: tirar os caminhos do índice
+		entries := []types.Entry{}
+		for _, entry := range ReadIndex().Entries {
+			if !matchesAnyPathspec(pathspecs, entry.Path) {
+				entries = append(entries, entry)
+			}
+		}
+		WriteIndex(entries)
+		return true
+	}
+
+	return restorePaths(rev, true, false, false, pathspecs)
+}
+
+func matchesAnyPathspec(pathspecs []string, path string) bool {
+	for _, pathspec := range pathspecs {
+		if matchPathspec(pathspec, path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func printUnstagedChanges() {
+	var lines []string
+	for _, entry := range ReadIndex().Entries {
+		if entry.Stage != 0 {
+			continue
+		}
+
+		if !pathExists(entry.Path) {
+			lines = append(lines, fmt.Sprintf("D\t%s", entry.Path))
+		} else if !worktreeMatchesIndex(entry) {
+			lines = append(lines, fmt.Sprintf("M\t%s", entry.Path))
+		}
+	}
+
+	if len(lines) == 0 {
+		return
+	}
+
+	fmt.Fprintf(os.Stdout, "Unstaged changes after reset:\n")
+	for _, line := range lines {
+		fmt.Fprintf(os.Stdout, "%s\n", line)
+	}
+}
+
+func removeMergeState() {
+	for _, name := range []string{"MERGE_HEAD", "MERGE_MSG", "MERGE_MODE", "CHERRY_PICK_HEAD", "REVERT_HEAD", "AUTO_MERGE"} {
+		os.Remove(filepath.Join(".git", name))
+	}
+}
@@ -0,0 +1,255 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/types"
+	"github.com/codecrafters-io/git-starter-go/pkg/utils"
+)
+
+func Restore(args ...string) {
+	err := utils.CheckGitRepo(".", false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	var staged, worktree bool
+	var source string
+	var pathspecs []string
+
+	for i := 2; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-S" || arg == "--staged":
+			staged = true
+		case arg == "-W" || arg == "--worktree":
+			worktree = true
+		case arg == "-s" || arg == "--source":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "error: option `source' requires a value\n")
+				os.Exit(1)
+			}
+			source = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--source="):
+			source = strings.TrimPrefix(arg, "--source=")
+		case arg == "--":
+			pathspecs = append(pathspecs, args[i+1:]...)
+			i = len(args)
+		case strings.HasPrefix(arg, "-"):
+			fmt.Fprintf(os.Stderr, "error: unknown option `%s'\n", strings.TrimLeft(arg, "-"))
+			fmt.Fprintf(os.Stderr, "usage: ccgit restore [--staged] [--worktree] [--source=<tree-ish>] <pathspec>...\n")
+			os.Exit(1)
+		default:
+			pathspecs = append(pathspecs, arg)
+		}
+	}
+
+	if len(pathspecs) == 0 {
+		fmt.Fprintf(os.Stderr, "fatal: you must specify path(s) to restore\n")
+		os.Exit(1)
+	}
+
+	if !staged {
+		worktree = true
+	}
+	if source == "" && staged {
+		source = "HEAD"
+	}
+
+	if !restorePaths(source, staged, worktree, false, pathspecs) {
+		os.Exit(1)
+	}
+}
+
+func restorePaths(source string, staged bool, worktree bool, overlay bool, pathspecs []string) bool {
+	indexFile := ReadIndex()
+	indexEntries := map[string]types.Entry{}
+	unmerged := map[string]bool{}
+	for _, entry := range indexFile.Entries {
+		if entry.Stage != 0 {
+			unmerged[entry.Path] = true
+			continue
+		}
+		indexEntries[entry.Path] = entry
+	}
+
+	var sourceTree map[string]types.TreeEntry
+	if source != "" {
+		treeHash, err := resolveTreeish(source)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fatal: could not resolve %s\n", source)
+			return false
+		}
+		sourceTree = readTreeEntries(treeHash)
+	}
+
+	candidates := map[string]bool{}
+	for p := range indexEntries {
+		candidates[p] = true
+	}
+	for p := range unmerged {
+		candidates[p] = true
+	}
+	for p := range sourceTree {
+		candidates[p] = true
+	}
+
+	matched := map[string]bool{}
+	var paths []string
+	for p := range candidates {
+		for _, pathspec := range pathspecs {
+			if matchPathspec(pathspec, p) {
+				matched[pathspec] = true
+				paths = append(paths, p)
+				break
+			}
+		}
+	}
+	sort.Strings(paths)
+
+	ok := true
+	for _, pathspec := range pathspecs {
+		if !matched[pathspec] {
+			fmt.Fprintf(os.Stderr, "error: pathspec '%s' did not match any file(s) known to git\n", pathspec)
+			ok = false
+		}
+	}
+	if !ok {
+		return false
+	}
+
+	for _, p := range paths {
+		if unmerged[p] && source == "" {
+			fmt.Fprintf(os.Stderr, "error: path '%s' is unmerged\n", p)
+			return false
+		}
+	}
+
+	for _, p := range paths {
+		var treeEntry types.TreeEntry
+		var inSource bool
+		if sourceTree != nil {
+			treeEntry, inSource = sourceTree[p]
+		} else if entry, inIndex := indexEntries[p]; inIndex {
+			treeEntry = types.TreeEntry{Mode: utils.IndexModeString(entry.Mode), Name: path.Base(p), Hash: entry.SHA1[:]}
+			inSource = true
+		}
+
+		if !inSource && overlay {
+			continue
+		}
+
+		if staged {
+			delete(indexEntries, p)
+			if inSource {
+				indexEntries[p] = newIndexEntryFromTree(p, treeEntry)
+			}
+		}
+
+		if worktree {
+			if !inSource {
+				removeWorktreeFile(p)
+				continue
+			}
+
+			// uma entrada do índice pode apontar para um blob que nunca foi gravado
+			if !objectExists(fmt.Sprintf("%x", treeEntry.Hash)) {
+				fmt.Fprintf(os.Stderr, "error: unable to read sha1 file of %s (%x)\n", p, treeEntry.Hash)
+				return false
+			}
+			if err := writeWorktreeFile(p, treeEntry.Mode, treeEntry.Hash); err != nil {
+				fmt.Fprintf(os.Stderr, "error: unable to write file %s: %v\n", p, err)
+				return false
+			}
+
+			if entry, inIndex := indexEntries[p]; inIndex && indexMatchesTreeEntry(entry, treeEntry) {
+				indexEntries[p] = NewIndexEntry(p, entry.SHA1)
+			}
+		}
+	}
+
+	var entries []types.Entry
+	for _, entry := range indexFile.Entries {
+		if entry.Stage != 0 && !(staged && containsPath(paths, entry.Path)) {
+			entries = append(entries, entry)
+		}
+	}
+	for _, entry := range indexEntries {
+		entries = append(entries, entry)
+	}
+	WriteIndex(sortIndexEntries(entries))
+
+	return true
+}
+
+func resolveTreeish(rev string) (string, error) {
+	hash, err := ResolveRevision(rev)
+	if err != nil {
+		return "", err
+	}
+
+	return peelToTree(hash)
+}
+
+func matchPathspec(pathspec string, p string) bool {
+	pathspec = strings.TrimPrefix(pathspec, "./")
+	pathspec = strings.TrimSuffix(pathspec, "/")
+
+	if pathspec == "." || pathspec == "" || pathspec == p {
+		return true
+	}
+
+	if strings.HasPrefix(p, pathspec+"/") {
+		return true
+	}
+
+	if strings.ContainsAny(pathspec, "*?[") {
+		if ok, _ := path.Match(pathspec, p); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newIndexEntryFromTree(p string, treeEntry types.TreeEntry) types.Entry {
+	if pathExists(p) && worktreeMatchesTreeEntry(p, treeEntry) {
+		entry := NewIndexEntry(p, [20]byte(treeEntry.Hash))
+		if utils.IndexModeString(entry.Mode) == treeEntry.Mode {
+			return entry
+		}
+	}
+
+	mode, _ := strconv.ParseUint(treeEntry.Mode, 8, 32)
+	return types.Entry{
+		SHA1:       [20]byte(treeEntry.Hash),
+		Mode:       uint32(mode),
+		ObjectType: uint16(mode>>12) & 0xF,
+		Perms:      uint16(mode) & 0x1FF,
+		NameLength: uint16(len(p)),
+		Path:       p,
+	}
+}
+
+func containsPath(paths []string, p string) bool {
+	i := sort.SearchStrings(paths, p)
+	return i < len(paths) && paths[i] == p
+}
+
+func sortIndexEntries(entries []types.Entry) []types.Entry {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Path == entries[j].Path {
+			return entries[i].Stage < entries[j].Stage
+		}
+		return entries[i].Path < entries[j].Path
+	})
+
+	return entries
+}
@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/utils"
+)
+
+func CherryPick(args ...string) {
+	replayCommand("pick", args...)
+}
+
+func Revert(args ...string) {
+	replayCommand("revert", args...)
+}
+
+func replayCommand(action string, args ...string) {
+	err := utils.CheckGitRepo(".", false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	options := replayOptions{action: action}
+	var revs []string
+	var sequencerAction string
+
+	for i := 2; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "--continue", "--skip", "--abort", "--quit":
+			sequencerAction = strings.TrimPrefix(arg, "--")
+		case "-n", "--no-commit":
+			options.noCommit = true
+		case "-e", "--edit", "--no-edit":
+		case "-x":
+			if action != "pick" {
+				replayUsage(action, "error: unknown switch `x'")
+			}
+			options.recordOrigin = true
+		case "-m", "--mainline":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "error: switch `m' requires a value\n")
+				os.Exit(129)
+			}
+			options.mainline = parseMainline(args[i+1])
+			i++
+		case "-X", "--strategy-option":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "error: switch `X' requires a value\n")
+				os.Exit(129)
+			}
+			options.addStrategyOption(args[i+1])
+			i++
+		default:
+			if value, ok := strings.CutPrefix(arg, "--mainline="); ok {
+				options.mainline = parseMainline(value)
+				continue
+			}
+			if value, ok := strings.CutPrefix(arg, "--strategy-option="); ok {
+				options.addStrategyOption(value)
+				continue
+			}
+			if value, ok := strings.CutPrefix(arg, "-X"); ok {
+				options.addStrategyOption(value)
+				continue
+			}
+			if strings.HasPrefix(arg, "-") {
+				replayUsage(action, fmt.Sprintf("error: unknown option `%s'", strings.TrimLeft(arg, "-")))
+			}
+			revs = append(revs, arg)
+		}
+	}
+
+	switch sequencerAction {
+	case "continue":
+		continueReplay(options)
+		return
+	case "skip":
+		skipReplay(options)
+		return
+	case "abort":
+		abortReplay(options)
+		return
+	case "quit":
+		removeSequencerState()
+		removeMergeState()
+		return
+	}
+
+	if len(revs) == 0 {
+		replayUsage(action, "")
+	}
+
+	startReplay(revs, options)
+}
+
+func replayUsage(action string, message string) {
+	if message != "" {
+		fmt.Fprintf(os.Stderr, "%s\n", message)
+	}
+
+	if action == "revert" {
+		fmt.Fprintf(os.Stderr, "usage: ccgit revert [--[no-]edit] [-n] [-m <parent-number>] [-X <option>] <commit>...\n")
+		fmt.Fprintf(os.Stderr, "   or: ccgit revert (--continue | --skip | --abort | --quit)\n")
+	} else {
+		fmt.Fprintf(os.Stderr, "usage: ccgit cherry-pick [--edit] [-n] [-m <parent-number>] [-x] [-X <option>] <commit>...\n")
+		fmt.Fprintf(os.Stderr, "   or: ccgit cherry-pick (--continue | --skip | --abort | --quit)\n")
+	}
+	os.Exit(129)
+}
+
+func parseMainline(value string) int {
+	mainline, err := strconv.Atoi(value)
+	if err != nil || mainline <= 0 {
+		fmt.Fprintf(os.Stderr, "error: option `mainline' expects a number greater than zero\n")
+		os.Exit(129)
+	}
+
+	return mainline
+}
@@ -0,0 +1,287 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/utils"
+)
+
+var (
+	fullHashRegex   = regexp.MustCompile(`^[0-9a-f]{40}$`)
+	shortHashRegex  = regexp.MustCompile(`^[0-9a-f]{4,40}$`)
+	specialRefRegex = regexp.MustCompile(`^[A-Z_]*HEAD$`)
+)
+
+func ResolveRevision(rev string) (string, error) {
+	if treeish, path, found := strings.Cut(rev, ":"); found {
+		return resolveRevisionPath(treeish, path)
+	}
+
+	name := rev
+	suffix := ""
+	if i := strings.IndexAny(rev, "^~"); i >= 0 {
+		name, suffix = rev[:i], rev[i:]
+	}
+
+	hash, err := resolveRevisionName(name)
+	if err != nil {
+		return "", fmt.Errorf("ambiguous argument '%s': unknown revision or path not in the working tree.", rev)
+	}
+
+	for len(suffix) > 0 {
+		op := suffix[0]
+		suffix = suffix[1:]
+
+		if op == '^' && strings.HasPrefix(suffix, "{") {
+			end := strings.IndexByte(suffix, '}')
+			if end < 0 {
+				return "", fmt.Errorf("invalid revision '%s'", rev)
+			}
+
+			peelType := suffix[1:end]
+			suffix = suffix[end+1:]
+
+			switch peelType {
+			case "":
+				hash, _ = peelTag(hash)
+				if hash == "" {
+					return "", fmt.Errorf("invalid revision '%s'", rev)
+				}
+			case "commit":
+				hash, err = peelToCommit(hash)
+			case "tree":
+				hash, err = peelToTree(hash)
+			default:
+				hash, _ = peelTag(hash)
+				if ObjectKind(hash) != peelType {
+					err = fmt.Errorf("'%s' does not name a %s", rev, peelType)
+				}
+			}
+			if err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		digits := 0
+		for digits < len(suffix) && suffix[digits] >= '0' && suffix[digits] <= '9' {
+			digits++
+		}
+
+		n := 1
+		if digits > 0 {
+			n, _ = strconv.Atoi(suffix[:digits])
+			suffix = suffix[digits:]
+		}
+
+		hash, err = peelToCommit(hash)
+		if err != nil {
+			return "", err
+		}
+
+		if op == '^' {
+			if n == 0 {
+				continue
+			}
+
+			commit, err := ReadCommit(hash)
+			if err != nil || len(commit.Parents) < n {
+				return "", fmt.Errorf("ambiguous argument '%s': unknown revision or path not in the working tree.", rev)
+			}
+			hash = commit.Parents[n-1]
+			continue
+		}
+
+		for range n {
+			commit, err := ReadCommit(hash)
+			if err != nil || len(commit.Parents) == 0 {
+				return "", fmt.Errorf("ambiguous argument '%s': unknown revision or path not in the working tree.", rev)
+			}
+			hash = commit.Parents[0]
+		}
+	}
+
+	return hash, nil
+}
+
+func resolveRevisionPath(treeish string, path string) (string, error) {
+	if treeish == "" {
+		stage := 0
+		if len(path) >= 2 && path[1] == ':' && path[0] >= '0' && path[0] <= '3' {
+			stage = int(path[0] - '0')
+			path = path[2:]
+		}
+
+		for _, entry := range ReadIndex().Entries {
+			if entry.Path == path && int(entry.Stage) == stage {
+				return fmt.Sprintf("%x", entry.SHA1), nil
+			}
+		}
+
+		if stage == 0 && pathExists(path) {
+			return "", fmt.Errorf("path '%s' exists on disk, but not in the index", path)
+		}
+		return "", fmt.Errorf("path '%s' does not exist (neither on disk nor in the index)", path)
+	}
+
+	hash, err := resolveTreeish(treeish)
+	if err != nil {
+		return "", fmt.Errorf("invalid object name '%s'.", treeish)
+	}
+
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return hash, nil
+	}
+
+	for _, name := range strings.Split(path, "/") {
+		found := false
+		if ObjectKind(hash) == "tree" {
+			tree, err := DeserializeTreeObject(CatFileReadObject(hash[0:2], hash[2:]))
+			if err != nil {
+				return "", err
+			}
+			for _, entry := range tree.Entries {
+				if entry.Name == name {
+					hash = fmt.Sprintf("%x", entry.Hash)
+					found = true
+					break
+				}
+			}
+		}
+
+		if !found {
+			if pathExists(path) {
+				return "", fmt.Errorf("path '%s' exists on disk, but not in '%s'", path, treeish)
+			}
+			return "", fmt.Errorf("path '%s' does not exist in '%s'", path, treeish)
+		}
+	}
+
+	return hash, nil
+}
+
+func ResolveCommit(rev string) (string, error) {
+	hash, err := ResolveRevision(rev)
+	if err != nil {
+		return "", err
+	}
+
+	return peelToCommit(hash)
+}
+
+func resolveRevisionName(name string) (string, error) {
+	if name == "" || name == "@" || name == "HEAD" {
+		headHash := utils.GetHeadHash()
+		if len(headHash) == 0 {
+			return "", fmt.Errorf("HEAD does not point to a commit")
+		}
+		return string(headHash), nil
+	}
+
+	if fullHashRegex.MatchString(name) && objectExists(name) {
+		return name, nil
+	}
+
+	for _, candidate := range refCandidates(name) {
+		if hash, err := utils.ResolveRef(candidate); err == nil {
+			return hash, nil
+		}
+	}
+
+	if shortHashRegex.MatchString(name) {
+		return resolveShortHash(name)
+	}
+
+	return "", fmt.Errorf("unknown revision %s", name)
+}
+
+func refCandidates(name string) []string {
+	var candidates []string
+	if strings.HasPrefix(name, "refs/") || specialRefRegex.MatchString(name) {
+		candidates = append(candidates, name)
+	}
+
+	return append(candidates,
+		"refs/"+name,
+		"refs/tags/"+name,
+		"refs/heads/"+name,
+		"refs/remotes/"+name,
+		"refs/remotes/"+name+"/HEAD",
+	)
+}
+
+func dwimRef(name string) string {
+	if name == "@" {
+		name = "HEAD"
+	}
+
+	for _, candidate := range refCandidates(name) {
+		if _, err := utils.ResolveRef(candidate); err == nil {
+			return candidate
+		}
+	}
+
+	return ""
+}
+
+func resolveShortHash(prefix string) (string, error) {
+	entries, err := os.ReadDir(filepath.Join(".git", "objects", prefix[0:2]))
+	if err != nil {
+		return "", fmt.Errorf("unknown revision %s", prefix)
+	}
+
+	var matches []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), prefix[2:]) {
+			matches = append(matches, prefix[0:2]+entry.Name())
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("unknown revision %s", prefix)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("short object ID %s is ambiguous", prefix)
+	}
+}
+
+func ObjectKind(hash string) string {
+	if !objectExists(hash) {
+		return ""
+	}
+
+	return CatFileExtractKind(CatFileReadObject(hash[0:2], hash[2:]))
+}
+
+func peelToCommit(hash string) (string, error) {
+	peeled, _ := peelTag(hash)
+	if ObjectKind(peeled) != "commit" {
+		return "", fmt.Errorf("%s is not a commit", hash)
+	}
+
+	return peeled, nil
+}
+
+func peelToTree(hash string) (string, error) {
+	peeled, _ := peelTag(hash)
+	switch ObjectKind(peeled) {
+	case "tree":
+		return peeled, nil
+	case "commit":
+		commit, err := ReadCommit(peeled)
+		if err != nil {
+			return "", err
+		}
+		return commit.TreeHash, nil
+	}
+
+	return "", fmt.Errorf("%s is not a tree", hash)
+}
@@ -0,0 +1,625 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/types"
+	"github.com/codecrafters-io/git-starter-go/pkg/utils"
+)
+
+var trailerLineRegex = regexp.MustCompile(`^[A-Za-z0-9-]+: `)
+
+type replayOptions struct {
+	action          string
+	noCommit        bool
+	recordOrigin    bool
+	mainline        int
+	strategyOptions []string
+}
+
+type replayItem struct {
+	action string
+	hash   string
+}
+
+func (o replayOptions) command() string {
+	if o.action == "revert" {
+		return "revert"
+	}
+
+	return "cherry-pick"
+}
+
+func (o *replayOptions) addStrategyOption(value string) {
+	// valida já na leitura dos argumentos
+	parseStrategyOption(&MergeOptions{}, value)
+	o.strategyOptions = append(o.strategyOptions, value)
+}
+
+func (o replayOptions) fail() {
+	fmt.Fprintf(os.Stderr, "fatal: %s failed\n", o.command())
+	os.Exit(128)
+}
+
+func sequencerPath(name string) string {
+	return filepath.Join(".git", "sequencer", name)
+}
+
+func sequencerInProgress() bool {
+	_, err := os.Stat(filepath.Join(".git", "sequencer"))
+	return err == nil
+}
+
+func removeSequencerState() {
+	os.RemoveAll(filepath.Join(".git", "sequencer"))
+}
+
+func readPseudoRef(name string) string {
+	data, err := os.ReadFile(filepath.Join(".git", name))
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(data))
+}
+
+// ação e commit de um cherry-pick ou revert parado; hash vazio quando há uma sequência em andamento
+func replayInProgress() (string, string) {
+	if hash := readPseudoRef("CHERRY_PICK_HEAD"); hash != "" {
+		return "pick", hash
+	}
+	if hash := readPseudoRef("REVERT_HEAD"); hash != "" {
+		return "revert", hash
+	}
+	if sequencerInProgress() {
+		if todo := readTodo(); len(todo) > 0 {
+			return todo[0].action, ""
+		}
+	}
+
+	return "", ""
+}
+
+func startReplay(revs []string, options replayOptions) {
+	if hasUnmergedEntries() {
+		verb := "Cherry-picking"
+		if options.action == "revert" {
+			verb = "Reverting"
+		}
+		printUnmergedError(verb)
+		options.fail()
+	}
+
+	if sequencerInProgress() {
+		fmt.Fprintf(os.Stderr, "error: %s is already in progress\n", options.command())
+		fmt.Fprintf(os.Stderr, "hint: try \"git %s (--continue | --abort | --quit)\"\n", options.command())
+		options.fail()
+	}
+
+	commits, single := resolveReplayCommits(revs)
+	if single {
+		if !replayCommit(commits[0], options) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Join(".git", "sequencer"), 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "error: could not create sequencer directory '.git/sequencer': %v\n", err)
+		options.fail()
+	}
+
+	head := string(utils.GetHeadHash())
+	_ = os.WriteFile(sequencerPath("head"), fmt.Appendf(nil, "%s\n", head), 0644)
+	_ = os.WriteFile(sequencerPath("abort-safety"), fmt.Appendf(nil, "%s\n", head), 0644)
+	writeReplayOptions(options)
+
+	var todo []replayItem
+	for _, hash := range commits {
+		todo = append(todo, replayItem{action: options.action, hash: hash})
+	}
+	writeTodo(todo)
+
+	runTodo(options)
+}
+
+// um único commit é aplicado sem sequência; faixas e listas passam pelo .git/sequencer
+func resolveReplayCommits(revs []string) ([]string, bool) {
+	single := len(revs) == 1
+	var commits []string
+
+	for _, rev := range revs {
+		if !strings.Contains(rev, "..") && !strings.HasPrefix(rev, "^") {
+			continue
+		}
+
+		single = false
+		positive, negative, err := ParseRevisionRange(revs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fatal: bad revision '%s'\n", rev)
+			os.Exit(128)
+		}
+
+		walked := WalkCommits(positive, negative, false)
+		for i := len(walked) - 1; i >= 0; i-- {
+			commits = append(commits, walked[i].Hash)
+		}
+		if len(commits) == 0 {
+			fmt.Fprintf(os.Stderr, "error: empty commit set passed\n")
+			fmt.Fprintf(os.Stderr, "fatal: %s failed\n", "cherry-pick")
+			os.Exit(128)
+		}
+		return commits, single
+	}
+
+	for _, rev := range revs {
+		hash, err := ResolveCommit(rev)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fatal: bad revision '%s'\n", rev)
+			os.Exit(128)
+		}
+		commits = append(commits, hash)
+	}
+
+	return commits, single
+}
+
+func runTodo(options replayOptions) {
+	for {
+		todo := readTodo()
+		if len(todo) == 0 {
+			removeSequencerState()
+			return
+		}
+
+		options.action = todo[0].action
+		if !replayCommit(todo[0].hash, options) {
+			os.Exit(1)
+		}
+
+		writeTodo(todo[1:])
+		_ = os.WriteFile(sequencerPath("abort-safety"), fmt.Appendf(nil, "%s\n", utils.GetHeadHash()), 0644)
+	}
+}
+
+// aplica (ou desfaz) um commit sobre o índice atual; false quando parou por conflito
+func replayCommit(hash string, options replayOptions) bool {
+	commit, err := ReadCommit(hash)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: bad revision '%s'\n", hash)
+		os.Exit(128)
+	}
+
+	parent := ""
+	switch {
+	case len(commit.Parents) > 1 && options.mainline == 0:
+		fmt.Fprintf(os.Stderr, "error: commit %s is a merge but no -m option was given.\n", hash)
+		options.fail()
+	case len(commit.Parents) > 1 && options.mainline > len(commit.Parents):
+		fmt.Fprintf(os.Stderr, "error: commit %s does not have parent %d\n", hash, options.mainline)
+		options.fail()
+	case len(commit.Parents) > 1:
+		parent = commit.Parents[options.mainline-1]
+	case options.mainline > 0:
+		fmt.Fprintf(os.Stderr, "error: mainline was specified but commit %s is not a merge.\n", hash)
+		options.fail()
+	case len(commit.Parents) == 1:
+		parent = commit.Parents[0]
+	}
+
+	head := string(utils.GetHeadHash())
+	if !options.noCommit && len(stagedChanges(head)) > 0 {
+		fmt.Fprintf(os.Stderr, "error: your local changes would be overwritten by %s.\n", options.command())
+		fmt.Fprintf(os.Stderr, "hint: commit your changes or stash them to proceed.\n")
+		options.fail()
+	}
+
+	subject := formatSubject(commit.Message)
+	result, ok := pickChanges(hash, parent, options.action == "revert", options.strategyOptions)
+	if !ok {
+		options.fail()
+	}
+
+	message := replayMessage(commit, parent, options)
+	if !result.Clean() {
+		writeReplayState(hash, options, message, result)
+		verb := "apply"
+		if options.action == "revert" {
+			verb = "revert"
+		}
+		fmt.Fprintf(os.Stderr, "error: could not %s %s... %s\n", verb, hash[:7], subject)
+		if options.noCommit {
+			fmt.Fprintf(os.Stderr, "hint: after resolving the conflicts, mark the corrected paths\n")
+			fmt.Fprintf(os.Stderr, "hint: with 'git add <paths>' or 'git rm <paths>'\n")
+		} else {
+			fmt.Fprintf(os.Stderr, "hint: After resolving the conflicts, mark them with\n")
+			fmt.Fprintf(os.Stderr, "hint: \"git add/rm <pathspec>\", then run\n")
+			fmt.Fprintf(os.Stderr, "hint: \"git %s --continue\".\n", options.command())
+			fmt.Fprintf(os.Stderr, "hint: You can instead skip this commit with \"git %s --skip\".\n", options.command())
+			fmt.Fprintf(os.Stderr, "hint: To abort and get back to the state before \"git %s\",\n", options.command())
+			fmt.Fprintf(os.Stderr, "hint: run \"git %s --abort\".\n", options.command())
+		}
+		return false
+	}
+
+	if options.noCommit {
+		writeReplayState(hash, options, message, result)
+		return true
+	}
+
+	treeHash := BuildTree(result.Tree)
+	if fmt.Sprintf("%x", treeHash) == commitTreeHash(head) {
+		// revert vazio só mostra o status; pick vazio fica parado esperando --skip ou commit --allow-empty
+		if options.action == "pick" {
+			writeReplayState(hash, options, message, result)
+			printEmptyPick()
+		}
+		Status()
+		return false
+	}
+
+	author := utils.AuthorIdent()
+	if options.action == "pick" {
+		author = commit.Author
+	}
+	createReplayCommit(treeHash, head, author, message, fmt.Sprintf("%s: %s", options.command(), subject), true)
+	return true
+}
+
+// aplica no índice e na árvore de trabalho as mudanças que hash fez sobre parent (ou o inverso, no revert)
+func pickChanges(hash string, parent string, revert bool, strategyOptions []string) (*MergeResult, bool) {
+	label := hash[:7]
+	if commit, err := ReadCommit(hash); err == nil {
+		label = fmt.Sprintf("%s (%s)", hash[:7], formatSubject(commit.Message))
+	}
+
+	options := MergeOptions{OursLabel: "HEAD", Style: mergeConflictStyle()}
+	for _, value := range strategyOptions {
+		parseStrategyOption(&options, value)
+	}
+
+	base, theirs := readCommitTreeEntries(parent), readCommitTreeEntries(hash)
+	options.BaseLabel, options.TheirsLabel = "parent of "+label, label
+	if revert {
+		base, theirs = theirs, base
+		options.BaseLabel, options.TheirsLabel = label, "parent of "+label
+	}
+
+	// com -n a aplicação parte do índice, que pode já ter outras mudanças
+	ours := indexTreeEntries()
+	result := MergeTrees(base, ours, theirs, options)
+	if !checkMergeWorktree(ours, result) {
+		return result, false
+	}
+
+	applyMergeResult(ours, result)
+	result.PrintMessages(os.Stdout)
+	return result, true
+}
+
+func replayMessage(commit *types.CommitObject, parent string, options replayOptions) string {
+	if options.action == "revert" {
+		message := fmt.Sprintf("Revert \"%s\"\n\nThis reverts commit %s", formatSubject(commit.Message), commit.Hash)
+		if len(commit.Parents) > 1 {
+			message += fmt.Sprintf(", reversing\nchanges made to %s", parent)
+		}
+		return message + "."
+	}
+
+	message := strings.TrimRight(commit.Message, "\n")
+	if options.recordOrigin {
+		// o rodapé entra junto dos trailers existentes, ou num parágrafo novo
+		paragraphs := strings.Split(message, "\n\n")
+		separator := "\n\n"
+		if len(paragraphs) > 1 && isTrailerBlock(paragraphs[len(paragraphs)-1]) {
+			separator = "\n"
+		}
+		message += fmt.Sprintf("%s(cherry picked from commit %s)", separator, commit.Hash)
+	}
+
+	return message
+}
+
+func isTrailerBlock(paragraph string) bool {
+	for _, line := range strings.Split(paragraph, "\n") {
+		if !trailerLineRegex.MatchString(line) && !strings.HasPrefix(line, "(cherry picked from commit ") {
+			return false
+		}
+	}
+
+	return true
+}
+
+func writeReplayState(hash string, options replayOptions, message string, result *MergeResult) {
+	writeMergeMessage(message, result)
+
+	switch {
+	case options.action == "pick" && !options.noCommit:
+		_ = os.WriteFile(filepath.Join(".git", "CHERRY_PICK_HEAD"), fmt.Appendf(nil, "%s\n", hash), 0644)
+	case options.action == "revert" && (options.noCommit || !result.Clean()):
+		_ = os.WriteFile(filepath.Join(".git", "REVERT_HEAD"), fmt.Appendf(nil, "%s\n", hash), 0644)
+	}
+}
+
+func printEmptyPick() {
+	fmt.Fprintf(os.Stderr, "The previous cherry-pick is now empty, possibly due to conflict resolution.\n")
+	fmt.Fprintf(os.Stderr, "If you wish to commit it anyway, use:\n\n")
+	fmt.Fprintf(os.Stderr, "    git commit --allow-empty\n\n")
+	fmt.Fprintf(os.Stderr, "Otherwise, please use 'git cherry-pick --skip'\n")
+}
+
+func createReplayCommit(treeHash [20]byte, head string, author string, message string, reflogMessage string, showDate bool) {
+	var parents []string
+	if head != "" {
+		parents = append(parents, head)
+	}
+
+	hash, object := utils.GetCommitHashObjectWithAuthor(treeHash, parents, author, message)
+	utils.SaveHashedObject(hash, object)
+
+	if err := utils.UpdateHead(fmt.Sprintf("%x", hash), reflogMessage); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(128)
+	}
+	removeMergeState()
+
+	printCommitSummary(fmt.Sprintf("%x", hash), showDate)
+}
+
+// a data do autor só aparece quando ela veio de outro commit
+func printCommitSummary(hash string, showDate bool) {
+	commit, err := ReadCommit(hash)
+	if err != nil {
+		return
+	}
+
+	branch := utils.GetHeadBranch()
+	if utils.IsHeadDetached() {
+		branch = "detached HEAD"
+	}
+	parent := ""
+	if len(commit.Parents) > 0 {
+		parent = commit.Parents[0]
+	} else {
+		branch += " (root-commit)"
+	}
+	fmt.Fprintf(os.Stdout, "[%s %s] %s\n", branch, hash[:7], formatSubject(commit.Message))
+
+	author, committer := utils.ParseSignature(commit.Author), utils.ParseSignature(commit.Committer)
+	if author.Name != committer.Name || author.Email != committer.Email {
+		fmt.Fprintf(os.Stdout, " Author: %s <%s>\n", author.Name, author.Email)
+	}
+	if showDate {
+		fmt.Fprintf(os.Stdout, " Date: %s\n", utils.FormatDate(author.When, "default"))
+	}
+
+	changes := DiffTreesWithRenames(readCommitTreeEntries(parent), readCommitTreeEntries(hash))
+	WriteShortStat(os.Stdout, changes)
+	WriteSummary(os.Stdout, changes)
+}
+
+func commitTreeHash(hash string) string {
+	if hash == "" {
+		return ""
+	}
+
+	commit, err := ReadCommit(hash)
+	if err != nil {
+		return ""
+	}
+
+	return commit.TreeHash
+}
+
+func continueReplay(options replayOptions) {
+	action, hash := replayInProgress()
+	if action == "" {
+		fmt.Fprintf(os.Stderr, "error: no cherry-pick or revert in progress\n")
+		options.fail()
+	}
+
+	if hash != "" {
+		exitIfUnmerged("Committing")
+
+		head := string(utils.GetHeadHash())
+		if action == "pick" && len(stagedChanges(head)) == 0 {
+			printEmptyPick()
+			Status()
+			os.Exit(1)
+		}
+
+		message := readMergeMessage()
+		author := utils.AuthorIdent()
+		reflogMessage := "commit"
+		if action == "pick" {
+			if commit, err := ReadCommit(hash); err == nil {
+				author = commit.Author
+			}
+			reflogMessage = "commit (cherry-pick)"
+		}
+
+		treeHash := WriteTree()
+		subject, _, _ := strings.Cut(message, "\n")
+		createReplayCommit(treeHash, head, author, message, fmt.Sprintf("%s: %s", reflogMessage, subject), action == "pick")
+	}
+
+	if !sequencerInProgress() {
+		return
+	}
+
+	todo := readTodo()
+	if len(todo) > 0 {
+		writeTodo(todo[1:])
+	}
+	_ = os.WriteFile(sequencerPath("abort-safety"), fmt.Appendf(nil, "%s\n", utils.GetHeadHash()), 0644)
+	runTodo(readReplayOptions())
+}
+
+func skipReplay(options replayOptions) {
+	action, hash := replayInProgress()
+	if action == "" {
+		fmt.Fprintf(os.Stderr, "error: no %s in progress\n", options.command())
+		options.fail()
+	}
+
+	if hash == "" {
+		fmt.Fprintf(os.Stderr, "error: there is nothing to skip\n")
+		fmt.Fprintf(os.Stderr, "hint: have you committed already?\n")
+		fmt.Fprintf(os.Stderr, "hint: try \"git %s --continue\"\n", options.command())
+		options.fail()
+	}
+
+	abortMerge()
+	if !sequencerInProgress() {
+		return
+	}
+
+	todo := readTodo()
+	if len(todo) > 0 {
+		writeTodo(todo[1:])
+	}
+	runTodo(readReplayOptions())
+}
+
+func abortReplay(options replayOptions) {
+	action, _ := replayInProgress()
+	if action == "" {
+		fmt.Fprintf(os.Stderr, "error: no cherry-pick or revert in progress\n")
+		options.fail()
+	}
+
+	if !sequencerInProgress() {
+		abortMerge()
+		return
+	}
+
+	head := readPseudoRef(filepath.Join("sequencer", "head"))
+	safety := readPseudoRef(filepath.Join("sequencer", "abort-safety"))
+	removeSequencerState()
+
+	// HEAD mexido por fora da sequência não é desfeito
+	if safety != string(utils.GetHeadHash()) {
+		fmt.Fprintf(os.Stderr, "warning: You seem to have moved HEAD. Not rewinding, check your HEAD!\n")
+		removeMergeState()
+		return
+	}
+
+	if !ResetToCommit(head, "hard", fmt.Sprintf("reset: moving to %s", head)) {
+		options.fail()
+	}
+}
+
+func readTodo() []replayItem {
+	data, err := os.ReadFile(sequencerPath("todo"))
+	if err != nil {
+		return nil
+	}
+
+	var todo []replayItem
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		action := fields[0]
+		switch action {
+		case "p":
+			action = "pick"
+		case "r":
+			action = "revert"
+		}
+
+		hash, err := ResolveCommit(fields[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: invalid line: %s\n", line)
+			fmt.Fprintf(os.Stderr, "error: unusable instruction sheet: '.git/sequencer/todo'\n")
+			os.Exit(128)
+		}
+		todo = append(todo, replayItem{action: action, hash: hash})
+	}
+
+	return todo
+}
+
+func writeTodo(todo []replayItem) {
+	var sb strings.Builder
+	for _, item := range todo {
+		subject := ""
+		if commit, err := ReadCommit(item.hash); err == nil {
+			subject = formatSubject(commit.Message)
+		}
+		fmt.Fprintf(&sb, "%s %s %s\n", item.action, item.hash[:7], subject)
+	}
+
+	_ = os.WriteFile(sequencerPath("todo"), []byte(sb.String()), 0644)
+}
+
+// mesmo formato do config, só com as opções que fogem do padrão
+func writeReplayOptions(options replayOptions) {
+	var sb strings.Builder
+	if options.noCommit {
+		sb.WriteString("\tno-commit = true\n")
+	}
+	if options.recordOrigin {
+		sb.WriteString("\trecord-origin = true\n")
+	}
+	if options.mainline > 0 {
+		fmt.Fprintf(&sb, "\tmainline = %d\n", options.mainline)
+	}
+	for _, value := range options.strategyOptions {
+		fmt.Fprintf(&sb, "\tstrategy-option = %s\n", value)
+	}
+
+	if sb.Len() == 0 {
+		return
+	}
+	_ = os.WriteFile(sequencerPath("opts"), []byte("[options]\n"+sb.String()), 0644)
+}
+
+func readReplayOptions() replayOptions {
+	var options replayOptions
+
+	data, err := os.ReadFile(sequencerPath("opts"))
+	if err != nil {
+		return options
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, found := strings.Cut(strings.TrimSpace(line), "=")
+		if !found {
+			continue
+		}
+
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch key {
+		case "no-commit":
+			options.noCommit = value == "true"
+		case "record-origin":
+			options.recordOrigin = value == "true"
+		case "mainline":
+			options.mainline, _ = strconv.Atoi(value)
+		case "strategy-option":
+			options.strategyOptions = append(options.strategyOptions, value)
+		}
+	}
+
+	return options
+}
+
+func indexTreeEntries() map[string]types.TreeEntry {
+	entries := map[string]types.TreeEntry{}
+	for _, entry := range ReadIndex().Entries {
+		if entry.Stage == 0 {
+			entries[entry.Path] = types.TreeEntry{Name: filepath.Base(entry.Path), Mode: utils.IndexModeString(entry.Mode), Hash: slices.Clone(entry.SHA1[:])}
+		}
+	}
+
+	return entries
+}
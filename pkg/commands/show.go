@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/utils"
+)
+
+func Show(args ...string) {
+	err := utils.CheckGitRepo(".", false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	options, revs := ParseLogArgs(args, LogOptions{Format: "medium", MaxCount: -1, Patch: true, Combined: true})
+	if len(revs) == 0 {
+		revs = []string{"HEAD"}
+	}
+
+	printer := NewLogPrinter(os.Stdout, options)
+	for _, rev := range revs {
+		hash, err := ResolveRevision(rev)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+			os.Exit(128)
+		}
+
+		showObject(printer, rev, hash)
+	}
+}
+
+func showObject(printer *LogPrinter, name string, hash string) {
+	yellow, reset := "", ""
+	if printer.options.Color {
+		yellow, reset = "\033[33m", "\033[0m"
+	}
+
+	for {
+		switch ObjectKind(hash) {
+		case "tag":
+			if printer.shownOne {
+				fmt.Fprint(os.Stdout, "\n")
+			}
+
+			data := CatFileReadObject(hash[0:2], hash[2:])
+			headers, message, _ := strings.Cut(string(data[bytes.IndexByte(data, 0)+1:]), "\n\n")
+
+			target := ""
+			var header strings.Builder
+			for _, line := range strings.Split(headers, "\n") {
+				key, value, _ := strings.Cut(line, " ")
+				switch key {
+				case "object":
+					target = value
+				case "tag":
+					fmt.Fprintf(os.Stdout, "%stag %s%s\n", yellow, value, reset)
+				case "tagger":
+					tagger := utils.ParseSignature(value)
+					switch printer.options.Format {
+					case "oneline", "format":
+					case "fuller":
+						fmt.Fprintf(&header, "Tagger: %s <%s>\n", tagger.Name, tagger.Email)
+						fmt.Fprintf(&header, "TaggerDate: %s\n", utils.FormatDate(tagger.When, printer.options.DateFormat))
+					case "medium":
+						fmt.Fprintf(&header, "Tagger: %s <%s>\n", tagger.Name, tagger.Email)
+						fmt.Fprintf(&header, "Date:   %s\n", utils.FormatDate(tagger.When, printer.options.DateFormat))
+					default:
+						fmt.Fprintf(&header, "Tagger: %s <%s>\n", tagger.Name, tagger.Email)
+					}
+				}
+			}
+			fmt.Fprintf(os.Stdout, "%s\n%s", header.String(), message)
+			printer.shownOne = true
+
+			if !objectExists(target) {
+				fmt.Fprintf(os.Stderr, "error: could not read object %s\n", target)
+				os.Exit(1)
+			}
+			hash = target
+			continue
+		case "commit":
+			commit, err := ReadCommit(hash)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+				os.Exit(128)
+			}
+			if printer.graph != nil {
+				printer.graph.Update(commit)
+			}
+			printer.Print(commit)
+		case "tree":
+			if printer.shownOne {
+				fmt.Fprint(os.Stdout, "\n")
+			}
+
+			tree, err := DeserializeTreeObject(CatFileReadObject(hash[0:2], hash[2:]))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+				os.Exit(128)
+			}
+
+			fmt.Fprintf(os.Stdout, "%stree %s%s\n\n", yellow, name, reset)
+			for _, entry := range tree.Entries {
+				if utils.ModeStringToKind(entry.Mode) == "tree" {
+					fmt.Fprintf(os.Stdout, "%s/\n", entry.Name)
+				} else {
+					fmt.Fprintf(os.Stdout, "%s\n", entry.Name)
+				}
+			}
+			printer.shownOne = true
+		case "blob":
+			os.Stdout.Write(readBlob(hash))
+		default:
+			fmt.Fprintf(os.Stderr, "fatal: bad object %s\n", name)
+			os.Exit(128)
+		}
+
+		return
+	}
+}
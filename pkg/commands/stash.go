@@ -0,0 +1,432 @@
+package commands
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/types"
+	"github.com/codecrafters-io/git-starter-go/pkg/utils"
+)
+
+var stashEntryRegex = regexp.MustCompile(`^(?:refs/)?stash@\{(\d+)\}$`)
+
+func Stash(args ...string) {
+	subcommand := "push"
+	rest := args[2:]
+	if len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+		subcommand, rest = rest[0], rest[1:]
+	}
+
+	switch subcommand {
+	case "push":
+		stashPush(rest)
+	case "list":
+		stashList(rest)
+	case "apply", "pop":
+		stashApply(subcommand, rest)
+	case "drop":
+		stashDrop(rest)
+	default:
+		fmt.Fprintf(os.Stderr, "fatal: subcommand wasn't specified; 'push' can't be assumed due to unexpected token '%s'\n", subcommand)
+		os.Exit(128)
+	}
+}
+
+func stashUsage(message string) {
+	if message != "" {
+		fmt.Fprintf(os.Stderr, "%s\n", message)
+	}
+
+	fmt.Fprintf(os.Stderr, "usage: ccgit stash list\n")
+	fmt.Fprintf(os.Stderr, "   or: ccgit stash drop [-q | --quiet] [<stash>]\n")
+	fmt.Fprintf(os.Stderr, "   or: ccgit stash pop [--index] [-q | --quiet] [<stash>]\n")
+	fmt.Fprintf(os.Stderr, "   or: ccgit stash apply [--index] [-q | --quiet] [<stash>]\n")
+	fmt.Fprintf(os.Stderr, "   or: ccgit stash [push [-u | --include-untracked] [-q | --quiet] [(-m | --message) <message>]]\n")
+	os.Exit(129)
+}
+
+func stashPush(args []string) {
+	var includeUntracked, quiet bool
+	var message string
+	hasMessage := false
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-u" || arg == "--include-untracked":
+			includeUntracked = true
+		case arg == "--no-include-untracked":
+			includeUntracked = false
+		case arg == "-q" || arg == "--quiet":
+			quiet = true
+		case arg == "-m" || arg == "--message":
+			if i+1 >= len(args) {
+				stashUsage(fmt.Sprintf("error: %s requires a value", strings.TrimLeft(arg, "-")))
+			}
+			i++
+			message, hasMessage = args[i], true
+		case strings.HasPrefix(arg, "--message="):
+			message, hasMessage = strings.TrimPrefix(arg, "--message="), true
+		case strings.HasPrefix(arg, "-m"):
+			message, hasMessage = arg[2:], true
+		case strings.HasPrefix(arg, "-"):
+			stashUsage(fmt.Sprintf("error: unknown option `%s'", strings.TrimLeft(arg, "-")))
+		default:
+			stashUsage(fmt.Sprintf("error: pathspecs are not supported: '%s'", arg))
+		}
+	}
+
+	head := string(utils.GetHeadHash())
+	if head == "" {
+		fmt.Fprintf(os.Stderr, "You do not have the initial commit yet\n")
+		os.Exit(1)
+	}
+	exitIfStashUnmerged()
+
+	commit, err := ReadCommit(head)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(128)
+	}
+
+	index := indexTreeEntries()
+	worktree := stashWorktreeEntries(index)
+	var untracked []string
+	if includeUntracked {
+		untracked = untrackedPaths(index)
+	}
+
+	if maps.EqualFunc(index, readCommitTreeEntries(head), sameTreeEntry) && maps.EqualFunc(worktree, index, sameTreeEntry) && len(untracked) == 0 {
+		fmt.Fprintf(os.Stdout, "No local changes to save\n")
+		return
+	}
+
+	branch := utils.GetHeadBranch()
+	if utils.IsHeadDetached() {
+		branch = "(no branch)"
+	}
+	summary := fmt.Sprintf("%s: %s %s", branch, head[:7], formatSubject(commit.Message))
+
+	parents := []string{head, writeStashCommit(BuildTree(index), []string{head}, "index on "+summary)}
+	if len(untracked) > 0 {
+		entries := map[string]types.TreeEntry{}
+		for _, path := range untracked {
+			entries[path] = stashBlobEntry(path)
+		}
+		parents = append(parents, writeStashCommit(BuildTree(entries), nil, "untracked files on "+summary))
+	}
+
+	if hasMessage {
+		message = fmt.Sprintf("On %s: %s", branch, message)
+	} else {
+		message = "WIP on " + summary
+	}
+	stash := writeStashCommit(BuildTree(worktree), parents, message)
+
+	if err := utils.UpdateRef("refs/stash", stash, message); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: cannot update refs/stash: %v\n", err)
+		os.Exit(128)
+	}
+	if !quiet {
+		fmt.Fprintf(os.Stdout, "Saved working directory and index state %s\n", message)
+	}
+
+	// equivalente a reset --hard, mais a limpeza dos arquivos guardados com -u
+	if !checkoutTree(head, true, "reset") {
+		os.Exit(1)
+	}
+	for _, path := range untracked {
+		removeWorktreeFile(path)
+	}
+}
+
+func exitIfStashUnmerged() {
+	seen := map[string]bool{}
+	for _, entry := range ReadIndex().Entries {
+		if entry.Stage != 0 && !seen[entry.Path] {
+			seen[entry.Path] = true
+			fmt.Fprintf(os.Stderr, "%s: needs merge\n", entry.Path)
+		}
+	}
+
+	if len(seen) > 0 {
+		os.Exit(1)
+	}
+}
+
+// o índice com o conteúdo atual dos arquivos rastreados, como um add -u
+func stashWorktreeEntries(index map[string]types.TreeEntry) map[string]types.TreeEntry {
+	entries := map[string]types.TreeEntry{}
+	for path, entry := range index {
+		if !pathExists(path) {
+			continue
+		}
+
+		if worktreeEntry := stashBlobEntry(path); !sameTreeEntry(entry, worktreeEntry) {
+			entries[path] = worktreeEntry
+			continue
+		}
+		entries[path] = entry
+	}
+
+	return entries
+}
+
+func stashBlobEntry(path string) types.TreeEntry {
+	hash, object, _ := worktreeBlob(path, false)
+	utils.SaveHashedObject(hash, object)
+
+	mode := "100644"
+	if stat, err := os.Lstat(path); err == nil {
+		mode = utils.TreeModeString(stat.Mode())
+	}
+
+	return types.TreeEntry{Name: filepath.Base(path), Mode: mode, Hash: hash[:]}
+}
+
+func untrackedPaths(index map[string]types.TreeEntry) []string {
+	dirTree, _ := utils.GetDirTree(".", skipIgnored(utils.StandardIgnore(), ReadIndex().Entries), false)
+
+	var paths []string
+	for _, path := range dirTree {
+		if _, tracked := index[path]; !tracked {
+			paths = append(paths, path)
+		}
+	}
+
+	slices.Sort(paths)
+	return paths
+}
+
+func writeStashCommit(tree [20]byte, parents []string, message string) string {
+	hash, object := utils.GetCommitHashObject(tree, parents, message)
+	utils.SaveHashedObject(hash, object)
+
+	return fmt.Sprintf("%x", hash)
+}
+
+func stashList(args []string) {
+	if len(args) > 0 {
+		stashUsage(fmt.Sprintf("error: unknown argument '%s'", args[0]))
+	}
+
+	entries := utils.ReadReflog("refs/stash")
+	for i := len(entries) - 1; i >= 0; i-- {
+		fmt.Fprintf(os.Stdout, "stash@{%d}: %s\n", len(entries)-1-i, entries[i].Message)
+	}
+}
+
+func stashApply(action string, args []string) {
+	var restoreIndex, quiet bool
+	var rev string
+
+	for _, arg := range args {
+		switch {
+		case arg == "--index":
+			restoreIndex = true
+		case arg == "-q" || arg == "--quiet":
+			quiet = true
+		case strings.HasPrefix(arg, "-"):
+			stashUsage(fmt.Sprintf("error: unknown option `%s'", strings.TrimLeft(arg, "-")))
+		case rev != "":
+			stashUsage("error: too many arguments")
+		default:
+			rev = arg
+		}
+	}
+
+	name, position, hash := resolveStash(rev, action == "pop")
+	stash, err := ReadCommit(hash)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(128)
+	}
+	exitIfStashUnmerged()
+
+	base := readCommitTreeEntries(stash.Parents[0])
+	ours := indexTreeEntries()
+
+	// --index reaplica no índice só as mudanças que estavam staged
+	var indexTree map[string]types.TreeEntry
+	if stashedIndex := readCommitTreeEntries(stash.Parents[1]); restoreIndex && !maps.EqualFunc(stashedIndex, base, sameTreeEntry) {
+		result := MergeTrees(base, ours, stashedIndex, MergeOptions{Style: mergeConflictStyle()})
+		if !result.Clean() {
+			fmt.Fprintf(os.Stderr, "error: conflicts in index. Try without --index.\n")
+			os.Exit(1)
+		}
+		indexTree = result.Tree
+	}
+
+	var untracked map[string]types.TreeEntry
+	if len(stash.Parents) > 2 {
+		untracked = readCommitTreeEntries(stash.Parents[2])
+		existing := false
+		for _, path := range sortedPaths(untracked) {
+			if pathExists(path) {
+				fmt.Fprintf(os.Stderr, "%s already exists, no checkout\n", path)
+				existing = true
+			}
+		}
+		if existing {
+			fmt.Fprintf(os.Stderr, "error: could not restore untracked files from stash\n")
+			os.Exit(1)
+		}
+	}
+
+	options := MergeOptions{
+		OursLabel:   "Updated upstream",
+		TheirsLabel: "Stashed changes",
+		BaseLabel:   "Version stash was based on",
+		Style:       mergeConflictStyle(),
+	}
+	result := MergeTrees(base, ours, readCommitTreeEntries(hash), options)
+	if !checkMergeWorktree(ours, result) {
+		if !quiet {
+			Status()
+		}
+		os.Exit(1)
+	}
+
+	applyMergeResult(ours, result)
+	result.PrintMessages(os.Stdout)
+	for _, path := range sortedPaths(untracked) {
+		entry := untracked[path]
+		if err := writeWorktreeFile(path, entry.Mode, entry.Hash); err != nil {
+			fmt.Fprintf(os.Stderr, "error: unable to write file %s: %v\n", path, err)
+			os.Exit(128)
+		}
+	}
+
+	if result.Clean() {
+		// sem --index, só os arquivos novos continuam no índice
+		if indexTree == nil {
+			indexTree = maps.Clone(ours)
+			for path, entry := range result.Tree {
+				if _, ok := ours[path]; !ok {
+					indexTree[path] = entry
+				}
+			}
+		}
+		resetIndexToTree(indexTree)
+	}
+
+	if !quiet {
+		Status()
+	}
+
+	if !result.Clean() {
+		if action == "pop" {
+			fmt.Fprintf(os.Stdout, "The stash entry is kept in case you need it again.\n")
+		}
+		os.Exit(1)
+	}
+
+	if action == "pop" {
+		dropStash(name, position, hash, quiet)
+	}
+}
+
+func stashDrop(args []string) {
+	var quiet bool
+	var rev string
+
+	for _, arg := range args {
+		switch {
+		case arg == "-q" || arg == "--quiet":
+			quiet = true
+		case strings.HasPrefix(arg, "-"):
+			stashUsage(fmt.Sprintf("error: unknown option `%s'", strings.TrimLeft(arg, "-")))
+		case rev != "":
+			stashUsage("error: too many arguments")
+		default:
+			rev = arg
+		}
+	}
+
+	name, position, hash := resolveStash(rev, true)
+	dropStash(name, position, hash, quiet)
+}
+
+// aceita stash@{n}, só o número ou (no apply) qualquer commit com cara de stash
+func resolveStash(rev string, requireEntry bool) (string, int, string) {
+	entries := utils.ReadReflog("refs/stash")
+	if rev == "" {
+		if len(entries) == 0 {
+			fmt.Fprintf(os.Stderr, "No stash entries found.\n")
+			os.Exit(1)
+		}
+		rev = "refs/stash@{0}"
+	} else if _, err := strconv.Atoi(rev); err == nil {
+		rev = fmt.Sprintf("refs/stash@{%s}", rev)
+	}
+
+	position := -1
+	var hash string
+	if match := stashEntryRegex.FindStringSubmatch(rev); match != nil {
+		position, _ = strconv.Atoi(match[1])
+		if position >= len(entries) {
+			fmt.Fprintf(os.Stderr, "fatal: log for 'stash' only has %d entries\n", len(entries))
+			os.Exit(128)
+		}
+		hash = entries[len(entries)-1-position].New
+	} else {
+		resolved, err := ResolveCommit(rev)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s is not a valid reference\n", rev)
+			os.Exit(1)
+		}
+		hash = resolved
+	}
+
+	if commit, err := ReadCommit(hash); err != nil || len(commit.Parents) < 2 {
+		fmt.Fprintf(os.Stderr, "fatal: '%s' is not a stash-like commit\n", rev)
+		os.Exit(128)
+	}
+	if requireEntry && position < 0 {
+		fmt.Fprintf(os.Stderr, "error: '%s' is not a stash reference\n", rev)
+		os.Exit(1)
+	}
+
+	return rev, position, hash
+}
+
+// tira a entrada do reflog, que é a pilha; o ref passa a apontar para o novo topo
+func dropStash(name string, position int, hash string, quiet bool) {
+	entries := utils.ReadReflog("refs/stash")
+	i := len(entries) - 1 - position
+	if i < 0 || i >= len(entries) {
+		return
+	}
+
+	remaining := append(entries[:i:i], entries[i+1:]...)
+	if len(remaining) == 0 {
+		if err := utils.DeleteRef("refs/stash"); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		if i < len(remaining) {
+			remaining[i].Old = strings.Repeat("0", 40)
+			if i > 0 {
+				remaining[i].Old = remaining[i-1].New
+			}
+		}
+		if err := utils.WriteReflog("refs/stash", remaining); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		if top := remaining[len(remaining)-1].New; top != entries[len(entries)-1].New {
+			_ = utils.WriteRef("refs/stash", top)
+		}
+	}
+
+	if !quiet {
+		fmt.Fprintf(os.Stdout, "Dropped %s (%s)\n", name, hash)
+	}
+}
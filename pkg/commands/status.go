@@ -2,6 +2,7 @@ package commands
 
 import (
 	"fmt"
+	"io"
 	"maps"
 	"os"
 	"path/filepath"
@@ -20,65 +21,13 @@ func Status(args ...string) {
 	}
 	shouldColor := !noColor && utils.IsTerminal()
 
-	headTree := map[string]string{}
-	indexEntries := map[string]string{}
-	workingFiles := map[string]string{}
-
-	indexFile := ReadIndex(args...)
-	for _, entry := range indexFile.Entries {
-		hash := fmt.Sprintf("%x", entry.SHA1[:])
-		indexEntries[entry.Path] = hash
-	}
-
-	headTreeObject := ReadHead()
-	maps.Copy(headTree, ExtractTreeHashs(".", headTreeObject.Entries))
-
-	dirTree, _ := utils.GetDirTree(".", []string{}, false)
-	for _, path := range dirTree {
-		hash, _, _ := utils.GetBlobHashObject(path)
-		workingFiles[path] = fmt.Sprintf("%x", hash[:])
-	}
-
-	var changedFiles []string
-	var untrackedFiles []string
-	var deletedFiles []string
-	var stagedFiles []string
-	for path, shaDisk := range workingFiles {
-		shaIndex, inIndex := indexEntries[path]
-		shaHead, inHead := headTree[path]
-
-		if !inIndex && !inHead {
-			// untracked
-			untrackedFiles = append(untrackedFiles, path)
-		}
-
-		if inIndex && shaDisk != shaIndex {
-			// modified, not staged
-			changedFiles = append(changedFiles, path)
-		}
-
-		if inIndex && (shaIndex != shaHead) {
-			// staged changes
-			stagedFiles = append(stagedFiles, path)
-		}
-	}
-
-	for path := range headTree {
-		_, inIndex := indexEntries[path]
-		_, inDisk := workingFiles[path]
+	report := readStatus(args...)
+	headTree, workingFiles, unmergedStages := report.headTree, report.workingFiles, report.unmerged
+	changedFiles, untrackedFiles, deletedFiles, stagedFiles := report.changed, report.untracked, report.deleted, report.staged
 
-		if !inIndex && !inDisk {
-			stagedFiles = append(stagedFiles, path)
-		} else if inIndex && !inDisk {
-			deletedFiles = append(deletedFiles, path)
-		}
-	}
-
-	branch := utils.GetHeadBranch()
+	printStatusHeader(os.Stdout, len(unmergedStages) > 0)
 
-	fmt.Fprintf(os.Stdout, "On branch %s\n", branch)
-
-	if len(deletedFiles) == 0 && len(changedFiles) == 0 && len(untrackedFiles) == 0 && len(stagedFiles) == 0 {
+	if len(deletedFiles) == 0 && len(changedFiles) == 0 && len(untrackedFiles) == 0 && len(stagedFiles) == 0 && len(unmergedStages) == 0 {
 		fmt.Fprintf(os.Stdout, "nothing to commit, working tree clean")
 	}
 
@@ -128,6 +77,15 @@ func Status(args ...string) {
 		fmt.Println()
 	}
 
+	if len(unmergedStages) > 0 {
+		fmt.Fprintf(os.Stdout, "Unmerged paths:\n")
+		paths := slices.Sorted(maps.Keys(unmergedStages))
+		for _, path := range paths {
+			fmt.Fprintf(os.Stdout, "\t%s:\t%s\n", unmergedDescription(unmergedStages[path]), path)
+		}
+		fmt.Println()
+	}
+
 	if len(deletedFiles)+len(changedFiles) > 0 {
 		fmt.Fprintf(os.Stdout, "Changes not staged for commit:\n")
 		files := make([]types.FileInfo, 0, len(changedFiles)+len(deletedFiles))
@@ -161,6 +119,170 @@ func Status(args ...string) {
 	}
 }
 
+type statusReport struct {
+	headTree     map[string]string
+	index        map[string]string
+	workingFiles map[string]string
+	unmerged     map[string]int
+	staged       []string
+	changed      []string
+	deleted      []string
+	untracked    []string
+}
+
+// separa os arquivos como o status mostra: no índice, fora dele e não rastreados
+func readStatus(args ...string) statusReport {
+	headTree := map[string]string{}
+	indexEntries := map[string]string{}
+	workingFiles := map[string]string{}
+
+	unmergedStages := map[string]int{}
+
+	indexFile := ReadIndex(args...)
+	for _, entry := range indexFile.Entries {
+		if entry.Stage != 0 {
+			unmergedStages[entry.Path] |= 1 << (entry.Stage - 1)
+			continue
+		}
+		hash := fmt.Sprintf("%x", entry.SHA1[:])
+		indexEntries[entry.Path] = hash
+	}
+
+	headTreeObject := ReadHead()
+	maps.Copy(headTree, ExtractTreeHashs(".", headTreeObject.Entries))
+
+	dirTree, _ := utils.GetDirTree(".", skipIgnored(utils.StandardIgnore(), indexFile.Entries), false)
+	for _, path := range dirTree {
+		// só os rastreados são comparados; o git não passa os outros pelo clean
+		if _, tracked := indexEntries[path]; !tracked {
+			workingFiles[path] = ""
+			continue
+		}
+		hash, _, _ := worktreeBlob(path, false)
+		workingFiles[path] = fmt.Sprintf("%x", hash[:])
+	}
+
+	var changedFiles []string
+	var untrackedFiles []string
+	var deletedFiles []string
+	var stagedFiles []string
+	for path, shaDisk := range workingFiles {
+		if _, unmerged := unmergedStages[path]; unmerged {
+			continue
+		}
+		shaIndex, inIndex := indexEntries[path]
+		shaHead, inHead := headTree[path]
+
+		if !inIndex && !inHead {
+			// untracked
+			untrackedFiles = append(untrackedFiles, path)
+		}
+
+		if inIndex && shaDisk != shaIndex {
+			// modified, not staged
+			changedFiles = append(changedFiles, path)
+		}
+
+		if inIndex && (shaIndex != shaHead) {
+			// staged changes
+			stagedFiles = append(stagedFiles, path)
+		}
+	}
+
+	for path := range headTree {
+		if _, unmerged := unmergedStages[path]; unmerged {
+			continue
+		}
+		_, inIndex := indexEntries[path]
+		_, inDisk := workingFiles[path]
+
+		if !inIndex && !inDisk {
+			stagedFiles = append(stagedFiles, path)
+		} else if inIndex && !inDisk {
+			deletedFiles = append(deletedFiles, path)
+		}
+	}
+
+	return statusReport{
+		headTree:     headTree,
+		index:        indexEntries,
+		workingFiles: workingFiles,
+		unmerged:     unmergedStages,
+		staged:       stagedFiles,
+		changed:      changedFiles,
+		deleted:      deletedFiles,
+		untracked:    untrackedFiles,
+	}
+}
+
+// filtro para o GetDirTree: descarta o que está ignorado e fora do índice. Um
+// diretório ignorado ainda é percorrido se tiver arquivos rastreados
+func skipIgnored(ignore *utils.Ignore, entries []types.Entry) func(string, bool) bool {
+	tracked := trackedPaths(entries)
+
+	return func(path string, isDir bool) bool {
+		return !tracked[filepath.ToSlash(path)] && ignore.IsIgnored(path, isDir)
+	}
+}
+
+// os caminhos do índice e os diretórios que os contêm
+func trackedPaths(entries []types.Entry) map[string]bool {
+	tracked := map[string]bool{}
+	for _, entry := range entries {
+		for path := entry.Path; path != "." && !tracked[path]; path = filepath.Dir(path) {
+			tracked[path] = true
+		}
+	}
+
+	return tracked
+}
+
+// branch atual e a operação em andamento (rebase, cherry-pick, merge)
+func printStatusHeader(w io.Writer, unmerged bool) {
+	if rebaseInProgress() {
+		printRebaseStatus(w, unmerged)
+	} else if utils.IsHeadDetached() {
+		headHash := utils.GetHeadHash()
+		fmt.Fprintf(w, "HEAD detached at %s\n", headHash[:min(7, len(headHash))])
+	} else {
+		fmt.Fprintf(w, "On branch %s\n", utils.GetHeadBranch())
+	}
+
+	switch action, hash := replayInProgress(); {
+	case rebaseInProgress():
+		// o rebase já explicou o estado no lugar do cabeçalho
+	case action != "":
+		printReplayStatus(w, action, hash, unmerged)
+	case unmerged:
+		fmt.Fprintf(w, "You have unmerged paths.\n")
+		fmt.Fprintf(w, "  (fix conflicts and run \"git commit\")\n")
+		fmt.Fprintf(w, "  (use \"git merge --abort\" to abort the merge)\n\n")
+	case len(readMergeHeads()) > 0:
+		fmt.Fprintf(w, "All conflicts fixed but you are still merging.\n")
+		fmt.Fprintf(w, "  (use \"git commit\" to conclude merge)\n\n")
+	}
+}
+
+// stages é uma máscara: bit 0 = base, bit 1 = nosso, bit 2 = deles
+func unmergedDescription(stages int) string {
+	switch stages {
+	case 0b001:
+		return "both deleted"
+	case 0b010:
+		return "added by us"
+	case 0b011:
+		return "deleted by them"
+	case 0b100:
+		return "added by them"
+	case 0b101:
+		return "deleted by us"
+	case 0b110:
+		return "both added"
+	}
+
+	return "both modified"
+}
+
 func ReadHead() *types.TreeObject {
 	headHash := utils.GetHeadHash()
 
@@ -194,7 +316,7 @@ func ExtractTreeHashs(basePath string, entries []types.TreeEntry) map[string]str
 
 			treeFileData := CatFileReadObject(hash[0:2], hash[2:])
 			headTreeObject, _ := DeserializeTreeObject(treeFileData)
-			subtreeHahses := ExtractTreeHashs(entry.Name, headTreeObject.Entries)
+			subtreeHahses := ExtractTreeHashs(filepath.Join(basePath, entry.Name), headTreeObject.Entries)
 			maps.Copy(hashes, subtreeHahses)
 		} else {
 			hash := fmt.Sprintf("%x", entry.Hash[:])
@@ -204,3 +326,111 @@ func ExtractTreeHashs(basePath string, entries []types.TreeEntry) map[string]str
 
 	return hashes
 }
+
+func printReplayStatus(w io.Writer, action string, hash string, unmerged bool) {
+	command, verb, operation := "cherry-pick", "cherry-picking", "the cherry-pick operation"
+	if action == "revert" {
+		command, verb, operation = "revert", "reverting", "the revert operation"
+	}
+
+	if sequencerInProgress() {
+		if action == "revert" {
+			fmt.Fprintf(w, "Revert currently in progress.\n")
+		} else {
+			fmt.Fprintf(w, "Cherry-pick currently in progress.\n")
+		}
+	} else {
+		fmt.Fprintf(w, "You are currently %s commit %s.\n", verb, hash[:min(7, len(hash))])
+	}
+
+	switch {
+	case unmerged:
+		fmt.Fprintf(w, "  (fix conflicts and run \"git %s --continue\")\n", command)
+	case hash == "":
+		fmt.Fprintf(w, "  (run \"git %s --continue\" to continue)\n", command)
+	default:
+		fmt.Fprintf(w, "  (all conflicts fixed: run \"git %s --continue\")\n", command)
+	}
+	fmt.Fprintf(w, "  (use \"git %s --skip\" to skip this patch)\n", command)
+	fmt.Fprintf(w, "  (use \"git %s --abort\" to cancel %s)\n\n", command, operation)
+}
+
+func printRebaseStatus(w io.Writer, unmerged bool) {
+	onto := readRebaseFile("onto")
+	onto = onto[:min(7, len(onto))]
+	fmt.Fprintf(w, "interactive rebase in progress; onto %s\n", onto)
+
+	done, todo := rebaseStatusLines("done"), rebaseStatusLines("git-rebase-todo")
+	switch len(done) {
+	case 0:
+		fmt.Fprintf(w, "No commands done.\n")
+	case 1:
+		fmt.Fprintf(w, "Last command done (1 command done):\n")
+	default:
+		fmt.Fprintf(w, "Last commands done (%d commands done):\n", len(done))
+	}
+	for _, line := range done[max(0, len(done)-2):] {
+		fmt.Fprintf(w, "   %s\n", line)
+	}
+	if len(done) > 2 {
+		fmt.Fprintf(w, "  (see more in file %s)\n", rebasePath("done"))
+	}
+
+	switch len(todo) {
+	case 0:
+		fmt.Fprintf(w, "No commands remaining.\n")
+	case 1:
+		fmt.Fprintf(w, "Next command to do (1 remaining command):\n")
+	default:
+		fmt.Fprintf(w, "Next commands to do (%d remaining commands):\n", len(todo))
+	}
+	for _, line := range todo[:min(2, len(todo))] {
+		fmt.Fprintf(w, "   %s\n", line)
+	}
+	if len(todo) > 0 {
+		fmt.Fprintf(w, "  (use \"git rebase --edit-todo\" to view and edit)\n")
+	}
+
+	branch := strings.TrimPrefix(readRebaseFile("head-name"), "refs/heads/")
+	switch {
+	case unmerged:
+		fmt.Fprintf(w, "You are currently rebasing branch '%s' on '%s'.\n", branch, onto)
+		fmt.Fprintf(w, "  (fix conflicts and then run \"git rebase --continue\")\n")
+		fmt.Fprintf(w, "  (use \"git rebase --skip\" to skip this patch)\n")
+		fmt.Fprintf(w, "  (use \"git rebase --abort\" to check out the original branch)\n\n")
+	case pathExists(filepath.Join(".git", "MERGE_MSG")):
+		fmt.Fprintf(w, "You are currently rebasing branch '%s' on '%s'.\n", branch, onto)
+		fmt.Fprintf(w, "  (all conflicts fixed: run \"git rebase --continue\")\n\n")
+	default:
+		fmt.Fprintf(w, "You are currently editing a commit while rebasing branch '%s' on '%s'.\n", branch, onto)
+		fmt.Fprintf(w, "  (use \"git commit --amend\" to amend the current commit)\n")
+		fmt.Fprintf(w, "  (use \"git rebase --continue\" once you are satisfied with your changes)\n\n")
+	}
+}
+
+// linhas da lista como estão no arquivo, mesmo que o rebase tenha parado por uma linha inválida
+func rebaseStatusLines(name string) []string {
+	content, _ := os.ReadFile(rebasePath(name))
+
+	var lines []string
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		command, rest, _ := strings.Cut(line, " ")
+		if rebaseCommands[command] == "exec" {
+			lines = append(lines, line)
+			continue
+		}
+
+		rev, subject, _ := strings.Cut(rest, " ")
+		if len(rev) == 40 {
+			rev = rev[:7]
+		}
+		lines = append(lines, fmt.Sprintf("%s %s %s", command, rev, subject))
+	}
+
+	return lines
+}
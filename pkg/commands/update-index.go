@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/sha1"
 	"encoding/binary"
+	"fmt"
 	"log"
 	"log/slog"
 	"os"
@@ -15,19 +16,37 @@ import (
 )
 
 func UpdateIndex(path string, hash [20]byte) {
-	stats, err := os.Stat(path)
+	var entries []types.Entry
+	index := ReadIndex()
+
+	fileEntry := NewIndexEntry(path, hash)
+
+	entries = append(entries, fileEntry)
+	for _, entry := range index.Entries {
+		if entry.Path != fileEntry.Path {
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+
+	indexBuffer := WriteIndex(entries)
+	slog.Debug(fmt.Sprintf("Index buffer:\n%+v", indexBuffer))
+}
+
+func NewIndexEntry(path string, hash [20]byte) types.Entry {
+	stats, err := os.Lstat(path)
 	if err != nil {
 		log.Fatalf("Error to describe file: %v", err)
 	}
 
-	var entries []types.Entry
-	index := ReadIndex()
-
 	objBits, permBits := utils.GitModeFromGoMode(stats.Mode())
 	mode32 := (objBits << 12) | permBits
 
 	sys := stats.Sys().(*syscall.Stat_t)
-	fileEntry := types.Entry{
+	return types.Entry{
 		SHA1:             hash,
 		Mode:             mode32,
 		Size:             uint32(stats.Size()),
@@ -50,20 +69,6 @@ func UpdateIndex(path string, hash [20]byte) {
 		Future:           false,
 		SkipWorktree:     false,
 	}
-
-	entries = append(entries, fileEntry)
-	for _, entry := range index.Entries {
-		if entry.SHA1 != fileEntry.SHA1 && entry.Path != fileEntry.Path {
-			entries = append(entries, entry)
-		}
-	}
-
-	sort.Slice(entries, func(i, j int) bool {
-		return entries[i].Path < entries[j].Path
-	})
-
-	indexBuffer := WriteIndex(entries)
-	slog.Debug("Index buffer:\n%+v", indexBuffer)
 }
 
 func WriteIndex(entries []types.Entry) bytes.Buffer {
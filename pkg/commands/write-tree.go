@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
+	"strings"
 
 	"github.com/codecrafters-io/git-starter-go/pkg/types"
 	"github.com/codecrafters-io/git-starter-go/pkg/utils"
@@ -30,64 +31,65 @@ func WriteTree(args ...string) [20]byte {
 		}
 	}
 
-	trees := map[string][]types.TreeEntry{}
-	subtrees := map[string][]types.TreeEntry{}
-
+	entries := map[string]types.TreeEntry{}
+	unmerged := false
 	for _, entry := range indexFile.Entries {
-		dir, file := filepath.Split(entry.Path)
-		if filepath.Base(dir) != filepath.Clean(dir) {
-			dir = filepath.Clean(dir)
-			if _, ok := trees[dir]; !ok {
-				subtrees[dir] = []types.TreeEntry{}
-			}
-			subtrees[dir] = append(subtrees[dir], types.TreeEntry{Name: file, Mode: utils.TreeModeString(os.FileMode(entry.Mode)), Hash: entry.SHA1[:]})
-		} else {
-			dir = filepath.Clean(dir)
-			if _, ok := trees[dir]; !ok {
-				trees[dir] = []types.TreeEntry{}
-			}
-			trees[dir] = append(trees[dir], types.TreeEntry{Name: file, Hash: entry.SHA1[:], Mode: utils.TreeModeString(os.FileMode(entry.Mode))})
+		if entry.Stage != 0 {
+			fmt.Fprintf(os.Stderr, "%s: unmerged (%x)\n", entry.Path, entry.SHA1)
+			unmerged = true
+			continue
 		}
+
+		entries[entry.Path] = types.TreeEntry{Name: filepath.Base(entry.Path), Mode: utils.IndexModeString(entry.Mode), Hash: entry.SHA1[:]}
 	}
 
-	for key, tree := range subtrees {
-		baseDir := filepath.Dir(filepath.Clean(key))
-		dir := filepath.Base(filepath.Clean(key))
-		if _, ok := trees[baseDir]; ok {
-			treeObject := &types.TreeObject{Entries: tree}
-			object := treeObject.ToBytes()
-			hash := sha1.Sum(object)
-			utils.SaveHashedObject(hash, object)
-
-			trees[baseDir] = append(trees[baseDir], types.TreeEntry{Name: dir, Mode: "040000", Hash: hash[:]})
-			slog.Debug(fmt.Sprintf("BaseDir: %s - Dir: %s - Hash: %x\n", baseDir, dir, hash))
-		}
+	if unmerged {
+		fmt.Fprintf(os.Stderr, "fatal: git-write-tree: error building trees\n")
+		os.Exit(128)
 	}
 
-	finalTree := []types.TreeEntry{}
-	for key, tree := range trees {
-		if key == "." {
-			finalTree = append(finalTree, tree...)
-		} else {
-			treeObject := &types.TreeObject{Entries: tree}
-			object := treeObject.ToBytes()
-			hash := sha1.Sum(object)
-			utils.SaveHashedObject(hash, object)
-
-			finalTree = append(finalTree, types.TreeEntry{Name: key, Mode: "040000", Hash: hash[:]})
-			slog.Debug(fmt.Sprintf("Dir: %s - Hash: %x\n", key, hash))
+	hash := BuildTree(entries)
+	slog.Debug(fmt.Sprintf("Final tree: Hash - %x", hash))
+
+	return hash
+}
+
+func BuildTree(entries map[string]types.TreeEntry) [20]byte {
+	trees := map[string][]types.TreeEntry{}
+	seen := map[string]bool{}
+	var dirs []string
+
+	for path, entry := range entries {
+		dir, file := filepath.Split(path)
+		dir = filepath.Clean(dir)
+		trees[dir] = append(trees[dir], types.TreeEntry{Name: file, Mode: entry.Mode, Hash: entry.Hash})
+
+		for dir != "." && !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+			dir = filepath.Dir(dir)
 		}
 	}
 
-	slog.Debug(fmt.Sprintf("Trees: %+v", trees))
+	// os diretórios mais profundos são gravados primeiro para o pai já conhecer o hash
+	slices.SortFunc(dirs, func(a, b string) int {
+		return strings.Count(b, "/") - strings.Count(a, "/")
+	})
 
-	treeObject := &types.TreeObject{Entries: finalTree}
+	for _, dir := range dirs {
+		treeObject := &types.TreeObject{Entries: trees[dir]}
+		object := treeObject.ToBytes()
+		hash := sha1.Sum(object)
+		utils.SaveHashedObject(hash, object)
+
+		parent := filepath.Dir(dir)
+		trees[parent] = append(trees[parent], types.TreeEntry{Name: filepath.Base(dir), Mode: "040000", Hash: hash[:]})
+		slog.Debug(fmt.Sprintf("Dir: %s - Hash: %x\n", dir, hash))
+	}
+
+	treeObject := &types.TreeObject{Entries: trees["."]}
 	object := treeObject.ToBytes()
 	hash := sha1.Sum(object)
-	slog.Debug(fmt.Sprintf("Final tree: Hash - %x, content: %+v", hash, finalTree))
-	for _, entry := range finalTree {
-		slog.Debug(fmt.Sprintf("%s %s %x %s\n", entry.Mode, utils.ModeStringToKind(entry.Mode), entry.Hash, entry.Name))
-	}
 	utils.SaveHashedObject(hash, object)
 
 	return hash
@@ -0,0 +1,496 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const maxIncludeDepth = 10
+
+// uma variável já resolvida, com a seção e o nome em minúsculas na chave
+type Entry struct {
+	Key     string
+	Value   string
+	NoValue bool
+	Scope   string
+	Path    string
+}
+
+type Source struct {
+	Scope string
+	Path  string
+}
+
+var loaded []Entry
+var loadedOK bool
+
+func LocalPath() string {
+	return filepath.Join(".git", "config")
+}
+
+func WorktreePath() string {
+	return filepath.Join(".git", "config.worktree")
+}
+
+func SystemPath() string {
+	if path := os.Getenv("GIT_CONFIG_SYSTEM"); path != "" {
+		return path
+	}
+
+	return "/etc/gitconfig"
+}
+
+// o GIT_CONFIG_GLOBAL substitui os dois arquivos globais
+func GlobalPaths() []string {
+	if path, ok := os.LookupEnv("GIT_CONFIG_GLOBAL"); ok {
+		return []string{path}
+	}
+
+	var paths []string
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	home := os.Getenv("HOME")
+	if xdg == "" && home != "" {
+		xdg = filepath.Join(home, ".config")
+	}
+	if xdg != "" {
+		paths = append(paths, filepath.Join(xdg, "git", "config"))
+	}
+	if home != "" {
+		paths = append(paths, filepath.Join(home, ".gitconfig"))
+	}
+
+	return paths
+}
+
+// arquivo onde um --global escreve: o ~/.gitconfig, a não ser que só exista o do XDG
+func GlobalPath() string {
+	paths := GlobalPaths()
+	if len(paths) == 0 {
+		return ""
+	}
+
+	if len(paths) == 2 {
+		if _, err := os.Stat(paths[1]); err != nil {
+			if _, err := os.Stat(paths[0]); err == nil {
+				return paths[0]
+			}
+		}
+	}
+
+	return paths[len(paths)-1]
+}
+
+// em ordem de precedência crescente: system, global, local e worktree
+func Sources() []Source {
+	var sources []Source
+	if noSystem, _ := ParseBool(os.Getenv("GIT_CONFIG_NOSYSTEM"), false); !noSystem {
+		sources = append(sources, Source{Scope: "system", Path: SystemPath()})
+	}
+	for _, path := range GlobalPaths() {
+		sources = append(sources, Source{Scope: "global", Path: path})
+	}
+	sources = append(sources, Source{Scope: "local", Path: LocalPath()})
+
+	return sources
+}
+
+// lê todos os escopos; o config.worktree só vale com extensions.worktreeConfig
+func Load() ([]Entry, error) {
+	var entries []Entry
+	for _, source := range Sources() {
+		fileEntries, err := ReadFile(source.Path, source.Scope, true)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, fileEntries...)
+	}
+
+	if worktreeConfig, _ := lastValue(entries, "extensions.worktreeconfig"); worktreeConfig != nil {
+		if enabled, err := ParseBool(worktreeConfig.Value, worktreeConfig.NoValue); err == nil && enabled {
+			fileEntries, err := ReadFile(WorktreePath(), "worktree", true)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, fileEntries...)
+		}
+	}
+
+	return entries, nil
+}
+
+// arquivos que não existem são tratados como vazios
+func ReadFile(path string, scope string, includes bool) ([]Entry, error) {
+	return readFile(path, scope, includes, 0)
+}
+
+func readFile(path string, scope string, includes bool, depth int) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) || os.IsPermission(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	items, err := parse(data, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, it := range items {
+		if it.kind != itemEntry {
+			continue
+		}
+
+		entry := Entry{Key: joinKey(it.section, it.subsection, it.name), Value: it.value, NoValue: it.noValue, Scope: scope, Path: path}
+		entries = append(entries, entry)
+
+		if !includes || it.name != "path" || it.noValue {
+			continue
+		}
+		switch {
+		case it.section == "include" && it.subsection == "":
+		case it.section == "includeif" && includeConditionMatches(it.subsection, path):
+		default:
+			continue
+		}
+
+		if depth+1 > maxIncludeDepth {
+			return nil, fmt.Errorf("exceeded maximum include depth (%d) while including\n\t%s\nfrom\n\t%s", maxIncludeDepth, it.value, path)
+		}
+		included, err := readFile(includePath(it.value, path), scope, includes, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, included...)
+	}
+
+	return entries, nil
+}
+
+func includePath(value string, from string) string {
+	value = ExpandPath(value)
+	if filepath.IsAbs(value) {
+		return value
+	}
+
+	return filepath.Join(filepath.Dir(from), value)
+}
+
+// só gitdir: e gitdir/i: são reconhecidos, como padrões glob sobre o caminho do .git
+func includeConditionMatches(condition string, from string) bool {
+	pattern, found := strings.CutPrefix(condition, "gitdir:")
+	ignoreCase := false
+	if !found {
+		pattern, found = strings.CutPrefix(condition, "gitdir/i:")
+		ignoreCase = true
+	}
+	if !found || pattern == "" {
+		return false
+	}
+
+	if rest, ok := strings.CutPrefix(pattern, "./"); ok {
+		pattern = filepath.Join(filepath.Dir(from), rest)
+		if strings.HasSuffix(rest, "/") || rest == "" {
+			pattern += "/"
+		}
+	} else {
+		pattern = ExpandPath(pattern)
+	}
+	if !filepath.IsAbs(pattern) {
+		pattern = "**/" + pattern
+	}
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+
+	gitDir, err := filepath.Abs(".git")
+	if err != nil {
+		return false
+	}
+	candidates := []string{gitDir}
+	if real, err := filepath.EvalSymlinks(gitDir); err == nil && real != gitDir {
+		candidates = append(candidates, real)
+	}
+
+	expression := "^" + globToRegexp(pattern) + "$"
+	if ignoreCase {
+		expression = "(?i)" + expression
+	}
+	re, err := regexp.Compile(expression)
+	if err != nil {
+		return false
+	}
+
+	for _, candidate := range candidates {
+		if re.MatchString(filepath.ToSlash(candidate)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// "*" não atravessa "/", "**" atravessa
+func globToRegexp(pattern string) string {
+	var out strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					out.WriteString("(?:.*/)?")
+				} else {
+					out.WriteString(".*")
+				}
+			} else {
+				out.WriteString("[^/]*")
+			}
+		case '?':
+			out.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				out.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			out.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			out.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	return out.String()
+}
+
+func ExpandPath(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		return filepath.Join(os.Getenv("HOME"), rest)
+	}
+	if path == "~" {
+		return os.Getenv("HOME")
+	}
+
+	return path
+}
+
+func joinKey(section string, subsection string, name string) string {
+	if subsection == "" {
+		return section + "." + name
+	}
+
+	return section + "." + subsection + "." + name
+}
+
+// seção e nome não diferenciam maiúsculas, a subseção diferencia
+func CanonicalKey(key string) string {
+	first := strings.IndexByte(key, '.')
+	last := strings.LastIndexByte(key, '.')
+	if first < 0 {
+		return strings.ToLower(key)
+	}
+
+	return strings.ToLower(key[:first]) + key[first:last] + strings.ToLower(key[last:])
+}
+
+// confere o formato section[.subsection].name com as mesmas mensagens do git
+func ValidateKey(key string) error {
+	first := strings.IndexByte(key, '.')
+	last := strings.LastIndexByte(key, '.')
+	if first <= 0 {
+		return fmt.Errorf("key does not contain a section: %s", key)
+	}
+	if last == len(key)-1 {
+		return fmt.Errorf("key does not contain variable name: %s", key)
+	}
+
+	for _, c := range []byte(key[:first]) {
+		if !isAlnum(c) && c != '-' {
+			return fmt.Errorf("invalid key: %s", key)
+		}
+	}
+
+	name := key[last+1:]
+	if !isAlpha(name[0]) {
+		return fmt.Errorf("invalid key: %s", key)
+	}
+	for _, c := range []byte(name) {
+		if !isAlnum(c) && c != '-' {
+			return fmt.Errorf("invalid key: %s", key)
+		}
+	}
+
+	if first < last && strings.Contains(key[first+1:last], "\n") {
+		return fmt.Errorf("invalid key (newline): %s", key)
+	}
+
+	return nil
+}
+
+func cachedEntries() []Entry {
+	if !loadedOK {
+		entries, err := Load()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+			os.Exit(128)
+		}
+		loaded, loadedOK = entries, true
+	}
+
+	return loaded
+}
+
+// as escritas invalidam o que já foi lido
+func reset() {
+	loaded, loadedOK = nil, false
+}
+
+func lastValue(entries []Entry, key string) (*Entry, bool) {
+	key = CanonicalKey(key)
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Key == key {
+			return &entries[i], true
+		}
+	}
+
+	return nil, false
+}
+
+func Lookup(key string) (Entry, bool) {
+	entry, ok := lastValue(cachedEntries(), key)
+	if !ok {
+		return Entry{}, false
+	}
+
+	return *entry, true
+}
+
+func Get(key string) (string, bool) {
+	entry, ok := Lookup(key)
+	return entry.Value, ok
+}
+
+func GetAll(key string) []string {
+	key = CanonicalKey(key)
+
+	var values []string
+	for _, entry := range cachedEntries() {
+		if entry.Key == key {
+			values = append(values, entry.Value)
+		}
+	}
+
+	return values
+}
+
+func All() []Entry {
+	return cachedEntries()
+}
+
+// um valor inválido encerra o programa, como no git
+func GetBool(key string, fallback bool) bool {
+	entry, ok := Lookup(key)
+	if !ok {
+		return fallback
+	}
+
+	value, err := ParseBool(entry.Value, entry.NoValue)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: bad boolean config value '%s' for '%s'\n", entry.Value, key)
+		os.Exit(128)
+	}
+
+	return value
+}
+
+func GetInt(key string, fallback int64) int64 {
+	entry, ok := Lookup(key)
+	if !ok {
+		return fallback
+	}
+
+	value, err := ParseInt(entry.Value)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: bad numeric config value '%s' for '%s' in file %s: %v\n", entry.Value, key, entry.Path, err)
+		os.Exit(128)
+	}
+
+	return value
+}
+
+func GetPath(key string) (string, bool) {
+	value, ok := Get(key)
+	if !ok {
+		return "", false
+	}
+
+	return ExpandPath(value), true
+}
+
+// uma variável sem "=" conta como verdadeira e um valor vazio como falso
+func ParseBool(value string, noValue bool) (bool, error) {
+	if noValue {
+		return true, nil
+	}
+
+	switch strings.ToLower(value) {
+	case "true", "yes", "on":
+		return true, nil
+	case "false", "no", "off", "":
+		return false, nil
+	}
+
+	if number, err := ParseInt(value); err == nil {
+		return number != 0, nil
+	}
+
+	return false, fmt.Errorf("bad boolean config value '%s'", value)
+}
+
+// aceita os sufixos k, m e g
+func ParseInt(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("invalid unit")
+	}
+
+	factor := int64(1)
+	switch value[len(value)-1] {
+	case 'k', 'K':
+		factor = 1 << 10
+	case 'm', 'M':
+		factor = 1 << 20
+	case 'g', 'G':
+		factor = 1 << 30
+	}
+	if factor > 1 {
+		value = value[:len(value)-1]
+	}
+
+	number, err := strconv.ParseInt(value, 0, 64)
+	if err != nil {
+		if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+			return 0, fmt.Errorf("out of range")
+		}
+		return 0, fmt.Errorf("invalid unit")
+	}
+
+	result := number * factor
+	if number != 0 && result/number != factor {
+		return 0, fmt.Errorf("out of range")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,324 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrNotFound       = errors.New("no such key")
+	ErrMultipleValues = errors.New("multiple values")
+	ErrNoSection      = errors.New("no such section")
+)
+
+// chave como o usuário escreveu: seção e nome mantêm a grafia no arquivo
+type keyParts struct {
+	section    string
+	subsection string
+	name       string
+}
+
+func splitKey(key string) keyParts {
+	first := strings.IndexByte(key, '.')
+	last := strings.LastIndexByte(key, '.')
+	parts := keyParts{section: key[:first], name: key[last+1:]}
+	if first < last {
+		parts.subsection = key[first+1 : last]
+	}
+
+	return parts
+}
+
+func (k keyParts) matches(it item) bool {
+	return it.kind == itemEntry && it.section == strings.ToLower(k.section) && it.subsection == k.subsection && it.name == strings.ToLower(k.name)
+}
+
+func (k keyParts) header() string {
+	return sectionHeader(k.section, k.subsection)
+}
+
+func sectionHeader(section string, subsection string) string {
+	if subsection == "" {
+		return fmt.Sprintf("[%s]", section)
+	}
+
+	subsection = strings.ReplaceAll(subsection, `\`, `\\`)
+	return fmt.Sprintf("[%s \"%s\"]", section, strings.ReplaceAll(subsection, `"`, `\"`))
+}
+
+func (k keyParts) line(value string) string {
+	return fmt.Sprintf("\t%s = %s\n", k.name, quoteValue(value))
+}
+
+// aspas só quando espaços nas pontas ou comentários mudariam a leitura
+func quoteValue(value string) string {
+	quote := strings.ContainsAny(value, "#;") || strings.HasPrefix(value, " ") || strings.HasSuffix(value, " ")
+
+	var out strings.Builder
+	for _, c := range []byte(value) {
+		switch c {
+		case '\n':
+			out.WriteString(`\n`)
+		case '\t':
+			out.WriteString(`\t`)
+		case '"', '\\':
+			out.WriteByte('\\')
+			out.WriteByte(c)
+		default:
+			out.WriteByte(c)
+		}
+	}
+
+	if quote {
+		return `"` + out.String() + `"`
+	}
+	return out.String()
+}
+
+type editedFile struct {
+	path  string
+	data  []byte
+	items []item
+}
+
+func openFile(path string) (*editedFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	items, err := parse(data, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &editedFile{path: path, data: data, items: items}, nil
+}
+
+func (f *editedFile) save() error {
+	reset()
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(f.path, f.data, 0644)
+}
+
+func (f *editedFile) splice(start int, end int, text string) {
+	f.data = slices.Concat(f.data[:start], []byte(text), f.data[end:])
+}
+
+// depois da última linha da seção, ou uma seção nova no fim do arquivo
+func (f *editedFile) insert(key keyParts, value string) {
+	position := -1
+	inSection := false
+	for _, it := range f.items {
+		if it.kind == itemSection {
+			inSection = it.section == strings.ToLower(key.section) && it.subsection == key.subsection
+		}
+		if inSection {
+			position = it.end
+		}
+	}
+
+	if position < 0 {
+		prefix := ""
+		if len(f.data) > 0 && f.data[len(f.data)-1] != '\n' {
+			prefix = "\n"
+		}
+		f.splice(len(f.data), len(f.data), prefix+key.header()+"\n"+key.line(value))
+		return
+	}
+
+	if position < len(f.data) && f.data[position] == '\n' {
+		position++
+	} else if position > 0 && f.data[position-1] != '\n' {
+		f.splice(position, position, "\n")
+		position++
+	}
+	f.splice(position, position, key.line(value))
+}
+
+func (f *editedFile) matching(key keyParts, matcher func(string) bool) []item {
+	var matches []item
+	for _, it := range f.items {
+		if key.matches(it) && (matcher == nil || matcher(it.value)) {
+			matches = append(matches, it)
+		}
+	}
+
+	return matches
+}
+
+// remove as linhas de trás para frente e, junto, as seções que ficarem vazias
+func (f *editedFile) remove(removed []item) {
+	spans := map[int]int{}
+	for _, it := range removed {
+		spans[it.start] = it.end
+	}
+
+	for i, it := range f.items {
+		if it.kind != itemSection {
+			continue
+		}
+
+		touched, empty := false, true
+		for _, other := range f.items[i+1:] {
+			if other.kind == itemSection {
+				break
+			}
+			if _, ok := spans[other.start]; ok && other.kind == itemEntry {
+				touched = true
+				continue
+			}
+			empty = false
+		}
+
+		if touched && empty {
+			end := it.end
+			if end < len(f.data) && f.data[end] == '\n' {
+				end++
+			}
+			spans[it.start] = end
+		}
+	}
+
+	starts := make([]int, 0, len(spans))
+	for start := range spans {
+		starts = append(starts, start)
+	}
+	slices.Sort(starts)
+	for i := len(starts) - 1; i >= 0; i-- {
+		f.splice(starts[i], spans[starts[i]], "")
+	}
+}
+
+// troca o valor único da chave; com várias ocorrências devolve ErrMultipleValues
+func Set(path string, key string, value string, matcher func(string) bool) error {
+	f, err := openFile(path)
+	if err != nil {
+		return err
+	}
+
+	parts := splitKey(key)
+	matches := f.matching(parts, matcher)
+	switch len(matches) {
+	case 0:
+		f.insert(parts, value)
+	case 1:
+		f.splice(matches[0].start, matches[0].end, parts.line(value))
+	default:
+		return ErrMultipleValues
+	}
+
+	return f.save()
+}
+
+func Add(path string, key string, value string) error {
+	f, err := openFile(path)
+	if err != nil {
+		return err
+	}
+
+	f.insert(splitKey(key), value)
+	return f.save()
+}
+
+// as ocorrências que casam viram uma só linha, no lugar da última
+func ReplaceAll(path string, key string, value string, matcher func(string) bool) error {
+	f, err := openFile(path)
+	if err != nil {
+		return err
+	}
+
+	parts := splitKey(key)
+	matches := f.matching(parts, matcher)
+	if len(matches) == 0 {
+		f.insert(parts, value)
+		return f.save()
+	}
+
+	last := matches[len(matches)-1]
+	for i := len(matches) - 2; i >= 0; i-- {
+		f.splice(matches[i].start, matches[i].end, "")
+	}
+	shift := 0
+	for _, it := range matches[:len(matches)-1] {
+		shift += it.end - it.start
+	}
+	f.splice(last.start-shift, last.end-shift, parts.line(value))
+
+	return f.save()
+}
+
+func Unset(path string, key string, matcher func(string) bool, all bool) error {
+	f, err := openFile(path)
+	if err != nil {
+		return err
+	}
+
+	matches := f.matching(splitKey(key), matcher)
+	if len(matches) == 0 {
+		return ErrNotFound
+	}
+	if len(matches) > 1 && !all {
+		return ErrMultipleValues
+	}
+
+	f.remove(matches)
+	return f.save()
+}
+
+// os nomes são "section" ou "section.subsection"
+func RenameSection(path string, oldName string, newName string) error {
+	section, subsection, _ := strings.Cut(newName, ".")
+	return editSections(path, oldName, sectionHeader(section, subsection))
+}
+
+func RemoveSection(path string, name string) error {
+	return editSections(path, name, "")
+}
+
+// com header vazio a seção inteira sai do arquivo
+func editSections(path string, name string, header string) error {
+	f, err := openFile(path)
+	if err != nil {
+		return err
+	}
+
+	section, subsection, _ := strings.Cut(name, ".")
+	section = strings.ToLower(section)
+
+	found := false
+	for i := len(f.items) - 1; i >= 0; i-- {
+		it := f.items[i]
+		if it.kind != itemSection || it.section != section || it.subsection != subsection {
+			continue
+		}
+		found = true
+
+		if header != "" {
+			f.splice(it.start, it.end, header)
+			continue
+		}
+
+		end := len(f.data)
+		for _, other := range f.items[i+1:] {
+			if other.kind == itemSection {
+				end = other.start
+				break
+			}
+		}
+		f.splice(it.start, end, "")
+	}
+
+	if !found {
+		return fmt.Errorf("%w: %s", ErrNoSection, name)
+	}
+
+	return f.save()
+}
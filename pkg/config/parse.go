@@ -0,0 +1,241 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	itemSection = iota
+	itemEntry
+	itemComment
+)
+
+// um cabeçalho, variável ou comentário do arquivo, com a posição em bytes
+// para que as escritas preservem o resto do texto
+type item struct {
+	kind       int
+	section    string
+	subsection string
+	name       string
+	value      string
+	noValue    bool
+	start      int
+	end        int
+	line       int
+}
+
+type parser struct {
+	data []byte
+	pos  int
+	line int
+}
+
+// separa o conteúdo de um arquivo de configuração no formato do git
+func parse(data []byte, path string) ([]item, error) {
+	p := &parser{data: data, line: 1}
+	var items []item
+	var section, subsection string
+
+	for p.pos < len(p.data) {
+		lineStart := p.pos
+		p.skipBlank()
+		if p.pos >= len(p.data) {
+			break
+		}
+
+		switch c := p.data[p.pos]; {
+		case c == '\n':
+			p.pos++
+			p.line++
+		case c == '#' || c == ';':
+			p.skipLine()
+			items = append(items, item{kind: itemComment, section: section, subsection: subsection, start: lineStart, end: p.pos, line: p.line - 1})
+		case c == '[':
+			start := p.pos
+			name, sub, ok := p.parseHeader()
+			if !ok {
+				return nil, fmt.Errorf("bad config line %d in file %s", p.line, path)
+			}
+			section, subsection = name, sub
+			items = append(items, item{kind: itemSection, section: section, subsection: subsection, start: start, end: p.pos, line: p.line})
+		case isAlpha(c):
+			line := p.line
+			name := p.parseName()
+			value, noValue, ok := p.parseValue()
+			if !ok || section == "" {
+				return nil, fmt.Errorf("bad config line %d in file %s", line, path)
+			}
+			items = append(items, item{kind: itemEntry, section: section, subsection: subsection, name: name, value: value, noValue: noValue, start: lineStart, end: p.pos, line: line})
+		default:
+			return nil, fmt.Errorf("bad config line %d in file %s", p.line, path)
+		}
+	}
+
+	return items, nil
+}
+
+func (p *parser) skipBlank() {
+	for p.pos < len(p.data) && (p.data[p.pos] == ' ' || p.data[p.pos] == '\t' || p.data[p.pos] == '\r') {
+		p.pos++
+	}
+}
+
+func (p *parser) skipLine() {
+	for p.pos < len(p.data) && p.data[p.pos] != '\n' {
+		p.pos++
+	}
+	if p.pos < len(p.data) {
+		p.pos++
+		p.line++
+	}
+}
+
+// [section], [section "subsection"] ou a forma antiga [section.subsection]
+func (p *parser) parseHeader() (string, string, bool) {
+	p.pos++
+	start := p.pos
+	for p.pos < len(p.data) && (isAlnum(p.data[p.pos]) || p.data[p.pos] == '-' || p.data[p.pos] == '.') {
+		p.pos++
+	}
+	name := string(p.data[start:p.pos])
+	if name == "" {
+		return "", "", false
+	}
+
+	if p.pos < len(p.data) && p.data[p.pos] == ']' {
+		p.pos++
+		if section, subsection, found := strings.Cut(name, "."); found {
+			return strings.ToLower(section), strings.ToLower(subsection), true
+		}
+		return strings.ToLower(name), "", true
+	}
+
+	p.skipBlank()
+	if p.pos >= len(p.data) || p.data[p.pos] != '"' || strings.Contains(name, ".") {
+		return "", "", false
+	}
+	p.pos++
+
+	var subsection strings.Builder
+	for {
+		if p.pos >= len(p.data) || p.data[p.pos] == '\n' {
+			return "", "", false
+		}
+		c := p.data[p.pos]
+		p.pos++
+		if c == '"' {
+			break
+		}
+		if c == '\\' {
+			if p.pos >= len(p.data) || p.data[p.pos] == '\n' {
+				return "", "", false
+			}
+			c = p.data[p.pos]
+			p.pos++
+		}
+		subsection.WriteByte(c)
+	}
+
+	if p.pos >= len(p.data) || p.data[p.pos] != ']' {
+		return "", "", false
+	}
+	p.pos++
+
+	return strings.ToLower(name), subsection.String(), true
+}
+
+func (p *parser) parseName() string {
+	start := p.pos
+	for p.pos < len(p.data) && (isAlnum(p.data[p.pos]) || p.data[p.pos] == '-') {
+		p.pos++
+	}
+
+	return strings.ToLower(string(p.data[start:p.pos]))
+}
+
+// o valor vai até o fim da linha: aspas protegem espaços e comentários,
+// e uma barra no fim da linha continua o valor na próxima
+func (p *parser) parseValue() (string, bool, bool) {
+	p.skipBlank()
+	if p.pos >= len(p.data) || p.data[p.pos] == '\n' || p.data[p.pos] == '#' || p.data[p.pos] == ';' {
+		p.skipLine()
+		return "", true, true
+	}
+	if p.data[p.pos] != '=' {
+		return "", false, false
+	}
+	p.pos++
+
+	var value strings.Builder
+	quoted := false
+	spaces := 0
+	for {
+		if p.pos >= len(p.data) {
+			if quoted {
+				return "", false, false
+			}
+			return value.String(), false, true
+		}
+
+		c := p.data[p.pos]
+		p.pos++
+		if c == '\n' {
+			p.line++
+			if quoted {
+				return "", false, false
+			}
+			return value.String(), false, true
+		}
+
+		if !quoted && (c == ' ' || c == '\t' || c == '\r') {
+			if value.Len() > 0 {
+				spaces++
+			}
+			continue
+		}
+		if !quoted && (c == '#' || c == ';') {
+			p.skipLine()
+			return value.String(), false, true
+		}
+
+		for ; spaces > 0; spaces-- {
+			value.WriteByte(' ')
+		}
+
+		switch c {
+		case '"':
+			quoted = !quoted
+		case '\\':
+			if p.pos >= len(p.data) {
+				return "", false, false
+			}
+			escaped := p.data[p.pos]
+			p.pos++
+			switch escaped {
+			case '\n':
+				p.line++
+			case 'n':
+				value.WriteByte('\n')
+			case 't':
+				value.WriteByte('\t')
+			case 'b':
+				value.WriteByte('\b')
+			case '\\', '"':
+				value.WriteByte(escaped)
+			default:
+				return "", false, false
+			}
+		default:
+			value.WriteByte(c)
+		}
+	}
+}
+
+func isAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isAlnum(c byte) bool {
+	return isAlpha(c) || (c >= '0' && c <= '9')
+}
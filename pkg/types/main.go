@@ -5,6 +5,8 @@ import (
 	"encoding/binary"
 	"fmt"
 	"sort"
+	"strings"
+	"time"
 )
 
 type TreeCacheExtension struct {
@@ -32,6 +34,19 @@ type TreeObject struct {
 type CommitObject struct {
 	Tree *TreeObject
 	// Parent *CommitObject
+
+	Hash      string
+	TreeHash  string
+	Parents   []string
+	Author    string
+	Committer string
+	Message   string
+}
+
+type Signature struct {
+	Name  string
+	Email string
+	When  time.Time
 }
 
 type FileInfo struct {
@@ -108,6 +123,7 @@ func (e Entry) ToBytes() []byte {
 	var flags uint16 = 0
 	nameLen := min(len(e.Path), 0x0FFF)
 	flags |= uint16(nameLen) & 0x0FFF
+	flags |= (uint16(e.Stage) & 0x3) << 12
 
 	flagBytes := make([]byte, 2)
 	binary.BigEndian.PutUint16(flagBytes, flags)
@@ -138,10 +154,10 @@ func (t *TreeObject) ToBytes() []byte {
 		nameJ := t.Entries[j].Name
 
 		// Git internamente considera `tree` como terminando com /
-		if t.Entries[i].Mode == "040000" {
+		if strings.TrimPrefix(t.Entries[i].Mode, "0") == "40000" {
 			nameI += "/"
 		}
-		if t.Entries[j].Mode == "040000" {
+		if strings.TrimPrefix(t.Entries[j].Mode, "0") == "40000" {
 			nameJ += "/"
 		}
 
@@ -149,7 +165,8 @@ func (t *TreeObject) ToBytes() []byte {
 	})
 
 	for _, e := range t.Entries {
-		body.WriteString(e.Mode)
+		// o modo de árvore é gravado sem o zero à esquerda
+		body.WriteString(strings.TrimPrefix(e.Mode, "0"))
 		body.WriteByte(' ')
 
 		body.WriteString(e.Name)
@@ -0,0 +1,353 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/config"
+)
+
+// valor de um atributo: AttrSet ("attr"), AttrUnset ("-attr"),
+// AttrUnspecified ("!attr" ou nenhuma linha) ou o texto de "attr=valor"
+type AttrValue string
+
+const (
+	AttrUnspecified AttrValue = ""
+	AttrSet         AttrValue = "\x00set"
+	AttrUnset       AttrValue = "\x00unset"
+)
+
+func (v AttrValue) String() string {
+	switch v {
+	case AttrUnspecified:
+		return "unspecified"
+	case AttrSet:
+		return "set"
+	case AttrUnset:
+		return "unset"
+	}
+
+	return string(v)
+}
+
+type attrState struct {
+	name  string
+	value AttrValue
+}
+
+type attrLine struct {
+	match  pathPattern
+	macro  string
+	states []attrState
+}
+
+// regras do .gitattributes em ordem de prioridade: info/attributes, os arquivos por
+// diretório (o mais fundo primeiro), core.attributesFile e o /etc/gitattributes
+type Attributes struct {
+	foldCase bool
+	read     func(path string) ([]byte, error)
+	info     []attrLine
+	dirs     map[string][]attrLine
+	global   [][]attrLine
+	macros   map[string][]attrState
+	order    []string
+}
+
+// read lê os .gitattributes de cada diretório: do disco, do índice, ou dos dois
+func LoadAttributes(read func(path string) ([]byte, error)) *Attributes {
+	attrs := &Attributes{
+		foldCase: config.GetBool("core.ignorecase", false),
+		read:     read,
+		dirs:     map[string][]attrLine{},
+		macros:   map[string][]attrState{},
+	}
+
+	// a macro embutida fica abaixo de tudo
+	builtin := attrs.parse("[attr]binary -diff -merge -text", "[builtin]", "", true)
+	attrs.global = append(attrs.global, builtin)
+
+	if noSystem, _ := config.ParseBool(os.Getenv("GIT_ATTR_NOSYSTEM"), false); !noSystem {
+		if data, err := os.ReadFile("/etc/gitattributes"); err == nil {
+			attrs.global = append(attrs.global, attrs.parse(string(data), "/etc/gitattributes", "", true))
+		}
+	}
+	if path := globalAttributesFile(); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			attrs.global = append(attrs.global, attrs.parse(string(data), path, "", true))
+		}
+	}
+	root := attrs.dirPatterns("")
+	infoPath := filepath.Join(".git", "info", "attributes")
+	if data, err := os.ReadFile(infoPath); err == nil {
+		attrs.info = attrs.parse(string(data), infoPath, "", true)
+	}
+
+	// macros só valem nesses arquivos; a origem de maior prioridade vence
+	for _, lines := range attrs.global {
+		attrs.defineMacros(lines)
+	}
+	attrs.defineMacros(root)
+	attrs.defineMacros(attrs.info)
+
+	return attrs
+}
+
+func globalAttributesFile() string {
+	if path, ok := config.GetPath("core.attributesfile"); ok {
+		return path
+	}
+
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	if xdg == "" && os.Getenv("HOME") != "" {
+		xdg = filepath.Join(os.Getenv("HOME"), ".config")
+	}
+	if xdg == "" {
+		return ""
+	}
+
+	return filepath.Join(xdg, "git", "attributes")
+}
+
+// os atributos determinados para o caminho, inclusive os "!attr" explícitos.
+// Cada atributo fica com a linha de maior prioridade que o menciona
+func (a *Attributes) Check(path string) map[string]AttrValue {
+	path = filepath.ToSlash(filepath.Clean(path))
+	result := map[string]AttrValue{}
+
+	var fill func(states []attrState)
+	fill = func(states []attrState) {
+		for i := len(states) - 1; i >= 0; i-- {
+			state := states[i]
+			if _, done := result[state.name]; done {
+				continue
+			}
+			result[state.name] = state.value
+			if macro, ok := a.macros[state.name]; ok && state.value == AttrSet {
+				fill(macro)
+			}
+		}
+	}
+	fillFrom := func(lines []attrLine) {
+		for i := len(lines) - 1; i >= 0; i-- {
+			if lines[i].macro == "" && lines[i].match.matches(path, false, a.foldCase) {
+				fill(lines[i].states)
+			}
+		}
+	}
+
+	var dirs []string
+	for dir := parentDir(path); ; dir = parentDir(dir) {
+		dirs = append(dirs, dir)
+		if dir == "" {
+			break
+		}
+	}
+	// os arquivos dos diretórios são lidos de cima para baixo, como no git
+	for i := len(dirs) - 1; i >= 0; i-- {
+		a.dirPatterns(dirs[i])
+	}
+
+	fillFrom(a.info)
+	for _, dir := range dirs {
+		fillFrom(a.dirs[dir])
+	}
+	for i := len(a.global) - 1; i >= 0; i-- {
+		fillFrom(a.global[i])
+	}
+
+	return result
+}
+
+func (a *Attributes) Get(path string, name string) AttrValue {
+	return a.Check(path)[name]
+}
+
+// nomes na ordem em que apareceram, que é a ordem do check-attr -a
+func (a *Attributes) Names() []string {
+	return a.order
+}
+
+func (a *Attributes) dirPatterns(dir string) []attrLine {
+	if lines, ok := a.dirs[dir]; ok {
+		return lines
+	}
+
+	source, base := ".gitattributes", ""
+	if dir != "" {
+		source, base = dir+"/.gitattributes", dir+"/"
+	}
+	var lines []attrLine
+	if data, err := a.read(source); err == nil {
+		lines = a.parse(string(data), source, base, dir == "")
+	}
+	a.dirs[dir] = lines
+
+	return lines
+}
+
+// chamada da menor para a maior prioridade; no mesmo arquivo vence a última linha
+func (a *Attributes) defineMacros(lines []attrLine) {
+	for _, line := range lines {
+		if line.macro != "" {
+			a.macros[line.macro] = line.states
+		}
+	}
+}
+
+func (a *Attributes) register(name string) {
+	for _, known := range a.order {
+		if known == name {
+			return
+		}
+	}
+
+	a.order = append(a.order, name)
+}
+
+func (a *Attributes) parse(data string, source string, base string, macrosAllowed bool) []attrLine {
+	data = strings.TrimPrefix(data, "\ufeff")
+
+	var lines []attrLine
+	for number, text := range strings.Split(data, "\n") {
+		if line, ok := a.parseLine(text, source, number+1, base, macrosAllowed); ok {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+func (a *Attributes) parseLine(text string, source string, number int, base string, macrosAllowed bool) (attrLine, bool) {
+	text = strings.TrimLeft(text, " \t\r")
+	if text == "" || text[0] == '#' {
+		return attrLine{}, false
+	}
+	original := strings.TrimRight(text, " \t\r")
+
+	var name string
+	if text[0] == '"' {
+		unquoted, rest, err := unquoteC(text)
+		if err != nil {
+			return attrLine{}, false
+		}
+		name, text = unquoted, rest
+	} else {
+		end := strings.IndexAny(text, " \t\r")
+		if end < 0 {
+			end = len(text)
+		}
+		name, text = text[:end], text[end:]
+	}
+
+	var line attrLine
+	if macro, ok := strings.CutPrefix(name, "[attr]"); ok {
+		if !macrosAllowed {
+			fmt.Fprintf(os.Stderr, "%s not allowed: %s:%d\n", original, source, number)
+			return attrLine{}, false
+		}
+		if !ValidAttrName(macro) {
+			fmt.Fprintf(os.Stderr, "%s is not a valid attribute name: %s:%d\n", macro, source, number)
+			return attrLine{}, false
+		}
+		a.register(macro)
+		line.macro = macro
+	} else {
+		if strings.HasPrefix(name, "!") {
+			fmt.Fprintf(os.Stderr, "warning: Negative patterns are ignored in git attributes\n")
+			fmt.Fprintf(os.Stderr, "Use '\\!' for literal leading exclamation.\n")
+			return attrLine{}, false
+		}
+		match, ok := newPathPattern(name, base)
+		if !ok {
+			return attrLine{}, false
+		}
+		line.match = match
+	}
+
+	for _, token := range strings.Fields(text) {
+		state := attrState{name: token, value: AttrSet}
+		switch {
+		case strings.HasPrefix(token, "-"):
+			state = attrState{name: token[1:], value: AttrUnset}
+		case strings.HasPrefix(token, "!"):
+			state = attrState{name: token[1:], value: AttrUnspecified}
+		default:
+			if name, value, ok := strings.Cut(token, "="); ok {
+				state = attrState{name: name, value: AttrValue(value)}
+			}
+		}
+		if !ValidAttrName(state.name) {
+			fmt.Fprintf(os.Stderr, "%s is not a valid attribute name: %s:%d\n", state.name, source, number)
+			return attrLine{}, false
+		}
+		a.register(state.name)
+		line.states = append(line.states, state)
+	}
+
+	return line, true
+}
+
+// letras, dígitos, "-", "." e "_", sem começar com "-"
+func ValidAttrName(name string) bool {
+	if name == "" || name[0] == '-' {
+		return false
+	}
+	for _, c := range name {
+		if !(c == '-' || c == '.' || c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// padrão entre aspas com os escapes do C; devolve o resto da linha
+func unquoteC(text string) (string, string, error) {
+	var sb strings.Builder
+	for i := 1; i < len(text); i++ {
+		switch c := text[i]; c {
+		case '"':
+			return sb.String(), text[i+1:], nil
+		case '\\':
+			i++
+			if i >= len(text) {
+				return "", "", fmt.Errorf("unterminated quote")
+			}
+			switch e := text[i]; e {
+			case 'a':
+				sb.WriteByte('\a')
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case 'v':
+				sb.WriteByte('\v')
+			case '0', '1', '2', '3':
+				if i+2 >= len(text) {
+					return "", "", fmt.Errorf("bad octal escape")
+				}
+				value, err := strconv.ParseUint(text[i:i+3], 8, 8)
+				if err != nil {
+					return "", "", err
+				}
+				sb.WriteByte(byte(value))
+				i += 2
+			default:
+				sb.WriteByte(e)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return "", "", fmt.Errorf("unterminated quote")
+}
@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/config"
+	"github.com/codecrafters-io/git-starter-go/pkg/types"
+)
+
+// identidade do autor: GIT_AUTHOR_*, depois author.* e user.* da configuração.
+// Sem nome ou email o programa encerra, como o git
+func AuthorIdent() string {
+	return FormatSignature(resolveIdent("AUTHOR", "author", true))
+}
+
+// com --author o nome e o email vêm do usuário, e a configuração deixa de ser exigida
+func OverrideAuthorIdent(name string, email string, when time.Time) string {
+	sig := resolveIdent("AUTHOR", "author", name == "")
+	if name != "" {
+		sig.Name, sig.Email = name, email
+	}
+	if !when.IsZero() {
+		sig.When = when
+	}
+
+	return FormatSignature(sig)
+}
+
+func CommitterIdent() string {
+	return FormatSignature(resolveIdent("COMMITTER", "committer", true))
+}
+
+// o reflog nunca falha: sem identidade configurada usa o usuário do sistema
+func ReflogIdent() string {
+	return FormatSignature(resolveIdent("COMMITTER", "committer", false))
+}
+
+func resolveIdent(env string, role string, strict bool) types.Signature {
+	name := firstNonEmpty(os.Getenv("GIT_"+env+"_NAME"), configValue(role+".name"), configValue("user.name"))
+	email := firstNonEmpty(os.Getenv("GIT_"+env+"_EMAIL"), configValue(role+".email"), configValue("user.email"), os.Getenv("EMAIL"))
+	configOnly := strict && config.GetBool("user.useconfigonly", false)
+
+	if name == "" {
+		if configOnly {
+			identityUnknown(role, "no name was given and auto-detection is disabled")
+		}
+		name = systemName()
+	}
+
+	if email == "" {
+		if configOnly {
+			identityUnknown(role, "no email was given and auto-detection is disabled")
+		}
+		email = systemEmail()
+		// sem domínio o git não confia no email adivinhado
+		if strict && strings.HasSuffix(email, ".(none)") {
+			identityUnknown(role, fmt.Sprintf("unable to auto-detect email address (got '%s')", email))
+		}
+	}
+
+	if strict && SanitizeIdent(name) == "" {
+		identityUnknown(role, fmt.Sprintf("empty ident name (for <%s>) not allowed", email))
+	}
+
+	when := time.Now()
+	if date := os.Getenv("GIT_" + env + "_DATE"); date != "" {
+		parsed, err := ParseIdentDate(date)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fatal: invalid date format: %s\n", date)
+			os.Exit(128)
+		}
+		when = parsed
+	}
+
+	return types.Signature{Name: SanitizeIdent(name), Email: SanitizeIdent(email), When: when}
+}
+
+func identityUnknown(role string, reason string) {
+	label := strings.ToUpper(role[:1]) + role[1:]
+	fmt.Fprintf(os.Stderr, "%s identity unknown\n\n", label)
+	fmt.Fprintf(os.Stderr, "*** Please tell me who you are.\n\n")
+	fmt.Fprintf(os.Stderr, "Run\n\n")
+	fmt.Fprintf(os.Stderr, "  git config --global user.email \"you@example.com\"\n")
+	fmt.Fprintf(os.Stderr, "  git config --global user.name \"Your Name\"\n\n")
+	fmt.Fprintf(os.Stderr, "to set your account's default identity.\n")
+	fmt.Fprintf(os.Stderr, "Omit --global to set the identity only in this repository.\n\n")
+	fmt.Fprintf(os.Stderr, "fatal: %s\n", reason)
+	os.Exit(128)
+}
+
+// nome completo do usuário do sistema (gecos), ou o login
+func systemName() string {
+	if current, err := user.Current(); err == nil {
+		name, _, _ := strings.Cut(current.Name, ",")
+		return firstNonEmpty(name, current.Username)
+	}
+
+	return os.Getenv("USER")
+}
+
+func systemEmail() string {
+	username := os.Getenv("USER")
+	if current, err := user.Current(); err == nil {
+		username = current.Username
+	}
+
+	hostname, _ := os.Hostname()
+	if !strings.Contains(hostname, ".") {
+		hostname += ".(none)"
+	}
+
+	return fmt.Sprintf("%s@%s", username, hostname)
+}
+
+// "Nome <email>", como o --author do commit recebe
+func ParseIdent(value string) (string, string, bool) {
+	start := strings.IndexByte(value, '<')
+	end := strings.LastIndexByte(value, '>')
+	if start < 0 || end < start {
+		return "", "", false
+	}
+
+	return SanitizeIdent(value[:start]), SanitizeIdent(value[start+1 : end]), true
+}
+
+// tira os caracteres que quebrariam o cabeçalho e a pontuação solta nas pontas
+func SanitizeIdent(value string) string {
+	value = strings.Map(func(r rune) rune {
+		if r == '<' || r == '>' || r == '\n' {
+			return -1
+		}
+		return r
+	}, value)
+
+	return strings.Trim(value, " \t.,:;\"'\\")
+}
+
+func configValue(key string) string {
+	value, _ := config.Get(key)
+	return value
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,267 @@
+package utils
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/config"
+)
+
+type IgnorePattern struct {
+	Text    string // a linha como escrita, sem os espaços finais
+	Source  string
+	Line    int
+	Negated bool
+
+	match pathPattern
+}
+
+// padrão de caminho do .gitignore, que o .gitattributes também usa
+type pathPattern struct {
+	pattern string
+	base    string // diretório do arquivo de origem, com "/" no fim
+	noDir   bool   // sem "/" o padrão casa com o nome em qualquer nível
+	dirOnly bool
+}
+
+// regras de exclusão em ordem de prioridade: linha de comando, os arquivos por
+// diretório (o mais fundo primeiro) e por fim info/exclude e core.excludesFile
+type Ignore struct {
+	PerDirectory string
+
+	foldCase bool
+	cmdline  []IgnorePattern
+	files    [][]IgnorePattern
+	dirs     map[string][]IgnorePattern
+}
+
+func NewIgnore() *Ignore {
+	return &Ignore{foldCase: config.GetBool("core.ignorecase", false), dirs: map[string][]IgnorePattern{}}
+}
+
+// as exclusões que add, status e ls-files --exclude-standard usam
+func StandardIgnore() *Ignore {
+	ignore := NewIgnore()
+	ignore.AddStandard()
+
+	return ignore
+}
+
+// .gitignore em cada diretório, core.excludesFile (ou o ignore do XDG) e info/exclude
+func (ig *Ignore) AddStandard() {
+	ig.PerDirectory = ".gitignore"
+
+	excludesFile, ok := config.GetPath("core.excludesfile")
+	if !ok {
+		xdg := os.Getenv("XDG_CONFIG_HOME")
+		if xdg == "" && os.Getenv("HOME") != "" {
+			xdg = filepath.Join(os.Getenv("HOME"), ".config")
+		}
+		if xdg != "" {
+			excludesFile = filepath.Join(xdg, "git", "ignore")
+		}
+	}
+	if excludesFile != "" {
+		_ = ig.AddFile(excludesFile)
+	}
+	_ = ig.AddFile(filepath.Join(".git", "info", "exclude"))
+}
+
+func (ig *Ignore) AddPattern(text string) {
+	if pattern, ok := parseIgnoreLine(text, "", 0, ""); ok {
+		ig.cmdline = append(ig.cmdline, pattern)
+	}
+}
+
+// arquivos adicionados depois têm prioridade sobre os anteriores
+func (ig *Ignore) AddFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	ig.files = append(ig.files, parseIgnoreFile(string(data), path, ""))
+	return nil
+}
+
+func (ig *Ignore) Empty() bool {
+	return len(ig.cmdline) == 0 && len(ig.files) == 0 && ig.PerDirectory == ""
+}
+
+// o padrão que decide o caminho, ou nil. Um diretório pai excluído exclui tudo
+// abaixo dele, e um "!" não consegue reincluir o que está lá dentro
+func (ig *Ignore) Match(path string, isDir bool) *IgnorePattern {
+	path = filepath.ToSlash(filepath.Clean(path))
+	for i := strings.IndexByte(path, '/'); i >= 0; i = nextSlash(path, i) {
+		if pattern := ig.matchAt(path[:i], true); pattern != nil && !pattern.Negated {
+			return pattern
+		}
+	}
+
+	return ig.matchAt(path, isDir)
+}
+
+func (ig *Ignore) IsIgnored(path string, isDir bool) bool {
+	pattern := ig.Match(path, isDir)
+	return pattern != nil && !pattern.Negated
+}
+
+func nextSlash(path string, i int) int {
+	next := strings.IndexByte(path[i+1:], '/')
+	if next < 0 {
+		return -1
+	}
+
+	return i + 1 + next
+}
+
+func (ig *Ignore) matchAt(path string, isDir bool) *IgnorePattern {
+	if pattern := lastMatch(ig.cmdline, path, isDir, ig.foldCase); pattern != nil {
+		return pattern
+	}
+
+	if ig.PerDirectory != "" {
+		for dir := parentDir(path); ; dir = parentDir(dir) {
+			if pattern := lastMatch(ig.dirPatterns(dir), path, isDir, ig.foldCase); pattern != nil {
+				return pattern
+			}
+			if dir == "" {
+				break
+			}
+		}
+	}
+
+	for i := len(ig.files) - 1; i >= 0; i-- {
+		if pattern := lastMatch(ig.files[i], path, isDir, ig.foldCase); pattern != nil {
+			return pattern
+		}
+	}
+
+	return nil
+}
+
+func parentDir(path string) string {
+	if i := strings.LastIndexByte(path, '/'); i >= 0 {
+		return path[:i]
+	}
+
+	return ""
+}
+
+// o .gitignore de cada diretório é lido uma vez, quando alguém abaixo dele é consultado
+func (ig *Ignore) dirPatterns(dir string) []IgnorePattern {
+	if patterns, ok := ig.dirs[dir]; ok {
+		return patterns
+	}
+
+	source, base := ig.PerDirectory, ""
+	if dir != "" {
+		source, base = dir+"/"+ig.PerDirectory, dir+"/"
+	}
+	data, _ := os.ReadFile(source)
+	patterns := parseIgnoreFile(string(data), source, base)
+	ig.dirs[dir] = patterns
+
+	return patterns
+}
+
+// dentro de um arquivo a última linha que casa vence
+func lastMatch(patterns []IgnorePattern, path string, isDir bool, foldCase bool) *IgnorePattern {
+	for i := len(patterns) - 1; i >= 0; i-- {
+		if patterns[i].match.matches(path, isDir, foldCase) {
+			return &patterns[i]
+		}
+	}
+
+	return nil
+}
+
+func (p pathPattern) matches(target string, isDir bool, foldCase bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+
+	if p.noDir {
+		return Wildmatch(p.pattern, path.Base(target), foldCase)
+	}
+
+	rest, ok := strings.CutPrefix(target, p.base)
+	if !ok {
+		return false
+	}
+
+	return Wildmatch(p.pattern, rest, foldCase)
+}
+
+// "/" no fim restringe a diretórios; qualquer outra "/" ancora no diretório base
+func newPathPattern(text string, base string) (pathPattern, bool) {
+	pattern := pathPattern{base: base}
+	if strings.HasSuffix(text, "/") {
+		pattern.dirOnly = true
+		text = strings.TrimSuffix(text, "/")
+	}
+	if text == "" {
+		return pathPattern{}, false
+	}
+
+	pattern.noDir = !strings.Contains(text, "/")
+	pattern.pattern = strings.TrimPrefix(text, "/")
+
+	return pattern, true
+}
+
+func parseIgnoreFile(data string, source string, base string) []IgnorePattern {
+	data = strings.TrimPrefix(data, "\ufeff")
+
+	var patterns []IgnorePattern
+	for i, line := range strings.Split(data, "\n") {
+		if pattern, ok := parseIgnoreLine(line, source, i+1, base); ok {
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	return patterns
+}
+
+func parseIgnoreLine(line string, source string, number int, base string) (IgnorePattern, bool) {
+	line = trimTrailingSpaces(line)
+	if line == "" || line[0] == '#' {
+		return IgnorePattern{}, false
+	}
+
+	pattern := IgnorePattern{Text: line, Source: source, Line: number}
+	text := line
+	if text[0] == '!' {
+		pattern.Negated = true
+		text = text[1:]
+	}
+
+	match, ok := newPathPattern(text, base)
+	if !ok {
+		return IgnorePattern{}, false
+	}
+	pattern.match = match
+
+	return pattern, true
+}
+
+// espaços no fim são descartados, a não ser que escapados com "\"
+func trimTrailingSpaces(line string) string {
+	end := len(line)
+	for end > 0 && line[end-1] == ' ' {
+		end--
+	}
+	if end < len(line) && end > 0 {
+		backslashes := 0
+		for i := end - 1; i >= 0 && line[i] == '\\'; i-- {
+			backslashes++
+		}
+		if backslashes%2 == 1 {
+			end++
+		}
+	}
+
+	return line[:end]
+}
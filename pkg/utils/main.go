@@ -6,12 +6,9 @@ import (
 	"crypto/sha1"
 	"encoding/binary"
 	"fmt"
-	"math"
 	"os"
 	"path/filepath"
-	"slices"
 	"strings"
-	"time"
 
 	"github.com/codecrafters-io/git-starter-go/pkg/types"
 	"golang.org/x/term"
@@ -33,13 +30,18 @@ func ReadVarInt(data []byte, offset *int) uint {
 }
 
 func GitModeFromGoMode(mode os.FileMode) (objectTypeBits uint32, permsBits uint32) {
-	perms := uint32(mode.Perm())
+	// Git só guarda 0644 ou 0755 para arquivos e 0 para symlinks
+	perms := uint32(0644)
+	if mode.Perm()&0111 != 0 {
+		perms = 0755
+	}
 
 	var obj uint32
 	if mode.IsRegular() {
 		obj = 0b1000
 	} else if mode&os.ModeSymlink != 0 {
 		obj = 0b1010
+		perms = 0
 	} else {
 		obj = 0b1110
 	}
@@ -61,6 +63,19 @@ func TreeModeString(mode os.FileMode) string {
 	return "100644"
 }
 
+func IndexModeString(mode uint32) string {
+	switch (mode >> 12) & 0xF {
+	case 0b1010:
+		return "120000"
+	case 0b1110:
+		return "160000"
+	}
+	if mode&0111 != 0 {
+		return "100755"
+	}
+	return "100644"
+}
+
 func ModeStringToKind(mode string) string {
 	switch mode {
 	case "040000", "40000":
@@ -74,59 +89,89 @@ func ModeStringToKind(mode string) string {
 
 func GetHeadBranch() string {
 	headFile, _ := os.ReadFile(".git/HEAD")
-	ref := []byte("ref: refs/heads/")
-	branch := string(headFile[len(ref):])
-	branch = strings.Split(branch, "\n")[0]
+	head := strings.Split(string(headFile), "\n")[0]
+
+	branch, ok := strings.CutPrefix(head, "ref: refs/heads/")
+	if !ok {
+		return ""
+	}
 
 	return branch
 }
 
+func IsHeadDetached() bool {
+	headFile, err := os.ReadFile(".git/HEAD")
+	if err != nil {
+		return false
+	}
+
+	return !strings.HasPrefix(string(headFile), "ref: ")
+}
+
 func GetHeadHash() []byte {
+	if IsHeadDetached() {
+		headFile, _ := os.ReadFile(".git/HEAD")
+		return bytes.TrimSpace(headFile)
+	}
+
 	branch := GetHeadBranch()
-	var headHash []byte
-	headFile, err := os.ReadFile(fmt.Sprintf(".git/refs/heads/%s", branch))
+	hash, err := ResolveRef(fmt.Sprintf("refs/heads/%s", branch))
 	if err != nil {
-		packedRefsFile, _ := os.ReadFile(".git/packed-refs")
-		i := 0
-		for i < len(packedRefsFile) {
-			startLine := i
-			for i < len(packedRefsFile) && packedRefsFile[i] != '\n' {
-				i++
-			}
-			packedRefLine := packedRefsFile[startLine:i]
-			line := strings.Split(string(packedRefLine), " ")
-			if strings.Contains(line[1], branch) {
-				headHash = append(headHash, packedRefLine[0:20]...)
-			}
-			i++
-		}
+		return nil
+	}
+
+	return []byte(hash)
+}
 
-		return headHash
+func UpdateHead(hash string, message string) error {
+	if IsHeadDetached() {
+		return UpdateRef("HEAD", hash, message)
 	}
 
-	i := 0
-	for i < len(headFile) {
-		startHash := i
-		for i < len(headFile) && headFile[i] != '\n' {
-			i++
-		}
-		headHash = append(headHash, headFile[startHash:i]...)
-		i++
+	old := string(GetHeadHash())
+	if err := UpdateRef(fmt.Sprintf("refs/heads/%s", GetHeadBranch()), hash, message); err != nil {
+		return err
+	}
+
+	return AppendReflog("HEAD", old, hash, message)
+}
+
+func DetachHead(hash string, message string) error {
+	old := string(GetHeadHash())
+	if err := os.WriteFile(".git/HEAD", fmt.Appendf(nil, "%s\n", hash), 0644); err != nil {
+		return err
+	}
+
+	return AppendReflog("HEAD", old, hash, message)
+}
+
+func SetHeadBranch(branch string, message string) error {
+	old := string(GetHeadHash())
+	ref := fmt.Sprintf("refs/heads/%s", branch)
+	if err := os.WriteFile(".git/HEAD", fmt.Appendf(nil, "ref: %s\n", ref), 0644); err != nil {
+		return err
 	}
 
-	return headHash
+	hash, _ := ResolveRef(ref)
+	if hash == "" {
+		return nil
+	}
+
+	return AppendReflog("HEAD", old, hash, message)
 }
 
-func GetDirTree(path string, ignores []string, sub bool) ([]string, error) {
+// skip recebe o caminho relativo de cada entrada e pode descartá-la (e o que
+// houver abaixo, se for um diretório)
+func GetDirTree(path string, skip func(path string, isDir bool) bool, sub bool) ([]string, error) {
 	dirTree, _ := os.ReadDir(path)
 	var dirNames []string
 
 	for _, dir := range dirTree {
-		if slices.Contains(ignores, dir.Name()) || dir.Name() == ".git" {
+		if dir.Name() == ".git" || (skip != nil && skip(filepath.Join(path, dir.Name()), dir.IsDir())) {
 			continue
 		}
 		if dir.IsDir() {
-			subdirs, _ := GetDirTree(filepath.Join(path, dir.Name(), "/"), ignores, true)
+			subdirs, _ := GetDirTree(filepath.Join(path, dir.Name(), "/"), skip, true)
 			dirNames = append(dirNames, subdirs...)
 		} else if sub {
 			dirNames = append(dirNames, filepath.Join(path, dir.Name(), "/"))
@@ -159,7 +204,13 @@ func CheckGitRepo(path string, init bool) error {
 }
 
 func GetBlobHashObject(path string) (h [20]byte, o []byte, c []byte) {
-	content, _ := os.ReadFile(path)
+	var content []byte
+	if stat, err := os.Lstat(path); err == nil && stat.Mode()&os.ModeSymlink != 0 {
+		target, _ := os.Readlink(path)
+		content = []byte(target)
+	} else {
+		content, _ = os.ReadFile(path)
+	}
 	file_len := len(content)
 	var object []byte
 	object = append(object, fmt.Appendf(nil, "blob %d\x00", file_len)...)
@@ -179,24 +230,20 @@ func GetTreeHashObject(content []byte) ([20]byte, []byte, []byte) {
 	return hash, object, content
 }
 
-func GetCommitHashObject(treeHash [20]byte, messages ...string) ([20]byte, []byte) {
-	authorName := "Murilo Alves"
-	authorEmail := "hi@omurilo.dev"
-	ts := time.Now().Unix()
-	_, offset := time.Now().Zone()
-	offsetHours := offset / 3600
-	offsetMinutes := (offset % 3600) / 60
-	tzOffset := fmt.Sprintf("%+03d%02d", offsetHours, int(math.Abs(float64(offsetMinutes))))
+func GetCommitHashObject(treeHash [20]byte, parents []string, messages ...string) ([20]byte, []byte) {
+	return GetCommitHashObjectWithAuthor(treeHash, parents, AuthorIdent(), messages...)
+}
 
-	parent := GetHeadHash()
+func GetCommitHashObjectWithAuthor(treeHash [20]byte, parents []string, author string, messages ...string) ([20]byte, []byte) {
+	ident := CommitterIdent()
 
 	var body []byte
 	body = append(body, fmt.Appendf(nil, "tree %x\n", treeHash)...)
-	if parent != nil {
+	for _, parent := range parents {
 		body = append(body, fmt.Appendf(nil, "parent %s\n", parent)...)
 	}
-	body = append(body, fmt.Appendf(nil, "author %s <%s> %d %s\n", authorName, authorEmail, ts, tzOffset)...)
-	body = append(body, fmt.Appendf(nil, "committer %s <%s> %d %s\n\n", authorName, authorEmail, ts, tzOffset)...)
+	body = append(body, fmt.Appendf(nil, "author %s\n", author)...)
+	body = append(body, fmt.Appendf(nil, "committer %s\n\n", ident)...)
 	for _, message := range messages {
 		body = append(body, fmt.Appendf(nil, "%s\n", message)...)
 	}
@@ -0,0 +1,312 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const PackedRefsHeader = "# pack-refs with: peeled fully-peeled sorted \n"
+
+type ReflogEntry struct {
+	Old     string
+	New     string
+	Ident   string
+	Message string
+}
+
+type PackedRef struct {
+	Name   string
+	Hash   string
+	Peeled string
+}
+
+func ReadPackedRefs() []PackedRef {
+	packedRefsFile, err := os.ReadFile(filepath.Join(".git", "packed-refs"))
+	if err != nil {
+		return nil
+	}
+
+	var refs []PackedRef
+	for _, line := range strings.Split(string(packedRefsFile), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// linhas `^<oid>` guardam o objeto descascado da tag anterior
+		if strings.HasPrefix(line, "^") {
+			if len(refs) > 0 {
+				refs[len(refs)-1].Peeled = line[1:]
+			}
+			continue
+		}
+
+		hash, name, found := strings.Cut(line, " ")
+		if !found || len(hash) != 40 {
+			continue
+		}
+		refs = append(refs, PackedRef{Name: name, Hash: hash})
+	}
+
+	return refs
+}
+
+func WritePackedRefs(refs []PackedRef) error {
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].Name < refs[j].Name
+	})
+
+	var sb strings.Builder
+	sb.WriteString(PackedRefsHeader)
+	for _, ref := range refs {
+		fmt.Fprintf(&sb, "%s %s\n", ref.Hash, ref.Name)
+		if ref.Peeled != "" {
+			fmt.Fprintf(&sb, "^%s\n", ref.Peeled)
+		}
+	}
+
+	path := filepath.Join(".git", "packed-refs")
+	lockPath := path + ".lock"
+	lockFile, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return fmt.Errorf("Unable to create '%s': %v", lockPath, err)
+	}
+
+	if _, err := lockFile.WriteString(sb.String()); err != nil {
+		lockFile.Close()
+		os.Remove(lockPath)
+		return err
+	}
+	lockFile.Close()
+
+	return os.Rename(lockPath, path)
+}
+
+func ReadLooseRefs() map[string]string {
+	refs := map[string]string{}
+	root := filepath.Join(".git", "refs")
+
+	_ = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || strings.HasSuffix(path, ".lock") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+
+		value := strings.TrimSpace(string(content))
+		if strings.HasPrefix(value, "ref: ") || len(value) != 40 {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(".git", path)
+		refs[filepath.ToSlash(rel)] = value
+		return nil
+	})
+
+	return refs
+}
+
+func ReadAllRefs() map[string]string {
+	// refs soltas têm precedência sobre as empacotadas
+	refs := map[string]string{}
+	for _, ref := range ReadPackedRefs() {
+		refs[ref.Name] = ref.Hash
+	}
+	for name, hash := range ReadLooseRefs() {
+		refs[name] = hash
+	}
+
+	return refs
+}
+
+func ResolveRef(name string) (string, error) {
+	for range 10 {
+		content, err := os.ReadFile(filepath.Join(".git", name))
+		if err == nil {
+			value := strings.TrimSpace(string(content))
+			if target, ok := strings.CutPrefix(value, "ref: "); ok {
+				name = target
+				continue
+			}
+			return value, nil
+		}
+
+		for _, ref := range ReadPackedRefs() {
+			if ref.Name == name {
+				return ref.Hash, nil
+			}
+		}
+
+		return "", fmt.Errorf("reference '%s' not found", name)
+	}
+
+	return "", fmt.Errorf("reference '%s' is too deeply nested", name)
+}
+
+func RefExists(name string) bool {
+	_, err := ResolveRef(name)
+	return err == nil
+}
+
+func DeleteRef(name string) error {
+	var found bool
+
+	loosePath := filepath.Join(".git", name)
+	if _, err := os.Stat(loosePath); err == nil {
+		if err := os.Remove(loosePath); err != nil {
+			return err
+		}
+		RemoveEmptyRefDirs(filepath.Dir(loosePath))
+		found = true
+	}
+
+	packed := ReadPackedRefs()
+	var remaining []PackedRef
+	for _, ref := range packed {
+		if ref.Name == name {
+			found = true
+			continue
+		}
+		remaining = append(remaining, ref)
+	}
+
+	if len(remaining) != len(packed) {
+		if err := WritePackedRefs(remaining); err != nil {
+			return err
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("reference '%s' not found", name)
+	}
+
+	logPath := filepath.Join(".git", "logs", name)
+	if os.Remove(logPath) == nil {
+		RemoveEmptyRefDirs(filepath.Dir(logPath))
+	}
+	return nil
+}
+
+// vale tanto para .git/refs quanto para os reflogs em .git/logs/refs
+func RemoveEmptyRefDirs(dir string) {
+	root := filepath.Join(".git", "refs")
+	if strings.HasPrefix(dir, filepath.Join(".git", "logs")) {
+		root = filepath.Join(".git", "logs", "refs")
+	}
+	stop := map[string]bool{
+		root:                         true,
+		filepath.Join(root, "heads"): true,
+		filepath.Join(root, "tags"):  true,
+	}
+
+	for !stop[dir] && strings.HasPrefix(dir, root) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+func WriteRef(name string, hash string) error {
+	path := filepath.Join(".git", name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, fmt.Appendf(nil, "%s\n", hash), 0644)
+}
+
+func UpdateRef(name string, hash string, message string) error {
+	old, _ := ResolveRef(name)
+
+	if err := WriteRef(name, hash); err != nil {
+		return err
+	}
+
+	return AppendReflog(name, old, hash, message)
+}
+
+func ReadReflog(name string) []ReflogEntry {
+	content, err := os.ReadFile(filepath.Join(".git", "logs", name))
+	if err != nil {
+		return nil
+	}
+
+	var entries []ReflogEntry
+	for _, line := range strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") {
+		header, message, _ := strings.Cut(line, "\t")
+		fields := strings.SplitN(header, " ", 3)
+		if len(fields) < 3 {
+			continue
+		}
+
+		entries = append(entries, ReflogEntry{Old: fields[0], New: fields[1], Ident: fields[2], Message: message})
+	}
+
+	return entries
+}
+
+func AppendReflog(name string, oldHash string, newHash string, message string) error {
+	if oldHash == "" {
+		oldHash = strings.Repeat("0", 40)
+	}
+
+	path := filepath.Join(".git", "logs", name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	logFile, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	message = strings.ReplaceAll(message, "\n", " ")
+	_, err = fmt.Fprintf(logFile, "%s %s %s\t%s\n", oldHash, newHash, ReflogIdent(), message)
+	return err
+}
+
+// regrava o reflog inteiro, na ordem do arquivo (mais antigo primeiro)
+func WriteReflog(name string, entries []ReflogEntry) error {
+	var content []byte
+	for _, entry := range entries {
+		content = fmt.Appendf(content, "%s %s %s\t%s\n", entry.Old, entry.New, entry.Ident, entry.Message)
+	}
+
+	return os.WriteFile(filepath.Join(".git", "logs", name), content, 0644)
+}
+
+func CheckRefFormat(name string) bool {
+	if name == "" || name == "@" || strings.HasPrefix(name, "-") || strings.HasPrefix(name, "/") {
+		return false
+	}
+
+	if strings.HasSuffix(name, "/") || strings.HasSuffix(name, ".") || strings.HasSuffix(name, ".lock") {
+		return false
+	}
+
+	if strings.Contains(name, "..") || strings.Contains(name, "//") || strings.Contains(name, "@{") {
+		return false
+	}
+
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r) {
+			return false
+		}
+	}
+
+	for _, component := range strings.Split(name, "/") {
+		if strings.HasPrefix(component, ".") {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-io/git-starter-go/pkg/types"
+)
+
+var relativeDateRegex = regexp.MustCompile(`^(\d+|an?|one)\s*\.?\s*(second|sec|minute|min|hour|day|week|month|year)s?\.?\s*(ago)?$`)
+
+func ParseSignature(value string) types.Signature {
+	var sig types.Signature
+
+	start := strings.IndexByte(value, '<')
+	end := strings.LastIndexByte(value, '>')
+	if start < 0 || end < start {
+		sig.Name = strings.TrimSpace(value)
+		return sig
+	}
+
+	sig.Name = strings.TrimSpace(value[:start])
+	sig.Email = value[start+1 : end]
+
+	fields := strings.Fields(value[end+1:])
+	if len(fields) == 0 {
+		return sig
+	}
+
+	ts, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return sig
+	}
+
+	location := time.UTC
+	if len(fields) > 1 {
+		location = ParseTimezone(fields[1])
+	}
+	sig.When = time.Unix(ts, 0).In(location)
+
+	return sig
+}
+
+func ParseTimezone(zone string) *time.Location {
+	if len(zone) != 5 || (zone[0] != '+' && zone[0] != '-') {
+		return time.UTC
+	}
+
+	hours, err1 := strconv.Atoi(zone[1:3])
+	minutes, err2 := strconv.Atoi(zone[3:5])
+	if err1 != nil || err2 != nil {
+		return time.UTC
+	}
+
+	offset := hours*3600 + minutes*60
+	if zone[0] == '-' {
+		offset = -offset
+	}
+
+	return time.FixedZone("", offset)
+}
+
+func FormatSignature(sig types.Signature) string {
+	return fmt.Sprintf("%s <%s> %d %s", sig.Name, sig.Email, sig.When.Unix(), sig.When.Format("-0700"))
+}
+
+func FormatDate(when time.Time, format string) string {
+	switch format {
+	case "iso", "iso8601":
+		return when.Format("2006-01-02 15:04:05 -0700")
+	case "iso-strict", "iso8601-strict":
+		return when.Format("2006-01-02T15:04:05-07:00")
+	case "rfc", "rfc2822":
+		return when.Format("Mon, 2 Jan 2006 15:04:05 -0700")
+	case "short":
+		return when.Format("2006-01-02")
+	case "raw":
+		return fmt.Sprintf("%d %s", when.Unix(), when.Format("-0700"))
+	case "unix":
+		return strconv.FormatInt(when.Unix(), 10)
+	case "relative":
+		return FormatRelativeDate(when, time.Now())
+	case "local":
+		return when.Local().Format("Mon Jan 2 15:04:05 2006")
+	default:
+		return when.Format("Mon Jan 2 15:04:05 2006 -0700")
+	}
+}
+
+func FormatRelativeDate(when time.Time, now time.Time) string {
+	diff := int64(now.Sub(when).Seconds())
+	if diff < 0 {
+		return "in the future"
+	}
+
+	plural := func(n int64, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("%d %s", n, unit)
+		}
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+
+	if diff < 90 {
+		return plural(diff, "second") + " ago"
+	}
+
+	diff = (diff + 30) / 60
+	if diff < 90 {
+		return plural(diff, "minute") + " ago"
+	}
+
+	diff = (diff + 30) / 60
+	if diff < 36 {
+		return plural(diff, "hour") + " ago"
+	}
+
+	diff = (diff + 12) / 24
+	if diff < 14 {
+		return plural(diff, "day") + " ago"
+	}
+	if diff < 70 {
+		return plural((diff+3)/7, "week") + " ago"
+	}
+	if diff < 365 {
+		return plural((diff+15)/30, "month") + " ago"
+	}
+	if diff < 1825 {
+		totalMonths := (diff*12*2 + 365) / (365 * 2)
+		years := totalMonths / 12
+		months := totalMonths % 12
+		if months > 0 {
+			return fmt.Sprintf("%s, %s ago", plural(years, "year"), plural(months, "month"))
+		}
+		return plural(years, "year") + " ago"
+	}
+
+	return plural((diff+183)/365, "year") + " ago"
+}
+
+// só formatos absolutos: "@ts +zona", "ts +zona", RFC 2822 e ISO 8601
+func ParseIdentDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	if ts, ok := strings.CutPrefix(value, "@"); ok {
+		// como no git, os dígitos vêm logo depois do "@"
+		if len(ts) == 0 || ts[0] < '0' || ts[0] > '9' {
+			return time.Time{}, fmt.Errorf("invalid date format: %s", value)
+		}
+		fields := strings.Fields(ts)
+		seconds, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date format: %s", value)
+		}
+		location := time.UTC
+		if len(fields) > 1 {
+			location = ParseTimezone(fields[1])
+		}
+		return time.Unix(seconds, 0).In(location), nil
+	}
+
+	if fields := strings.Fields(value); len(fields) == 2 && len(fields[0]) >= 9 && len(fields[1]) == 5 {
+		if seconds, err := strconv.ParseInt(fields[0], 10, 64); err == nil {
+			return time.Unix(seconds, 0).In(ParseTimezone(fields[1])), nil
+		}
+	}
+
+	layouts := []string{
+		time.RFC3339,
+		"2006-01-02T15:04:05-0700",
+		"2006-01-02T15:04:05",
+		"2006-01-02T15:04",
+		"2006-01-02 15:04:05 -0700",
+		"2006-01-02 15:04:05 -07:00",
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04",
+		"2006-01-02",
+		"2006.01.02",
+		"01/02/2006",
+		"Mon, 2 Jan 2006 15:04:05 -0700",
+		"Mon, 02 Jan 2006 15:04:05 -0700",
+		"2 Jan 2006 15:04:05 -0700",
+		"Mon Jan 2 15:04:05 2006 -0700",
+		"Mon Jan 2 15:04:05 2006",
+		"Jan 2 2006",
+		"2 Jan 2006",
+	}
+	for _, layout := range layouts {
+		if when, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return when, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date format: %s", value)
+}
+
+func ParseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	now := time.Now()
+
+	if when, err := ParseIdentDate(value); err == nil {
+		return when, nil
+	}
+
+	lower := strings.ToLower(value)
+	switch lower {
+	case "now":
+		return now, nil
+	case "today":
+		year, month, day := now.Date()
+		return time.Date(year, month, day, 0, 0, 0, 0, time.Local), nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1), nil
+	}
+
+	lower = strings.ReplaceAll(lower, ".", " ")
+	if match := relativeDateRegex.FindStringSubmatch(strings.Join(strings.Fields(lower), " ")); match != nil {
+		amount := 1
+		if n, err := strconv.Atoi(match[1]); err == nil {
+			amount = n
+		}
+
+		switch match[2] {
+		case "second", "sec":
+			return now.Add(-time.Duration(amount) * time.Second), nil
+		case "minute", "min":
+			return now.Add(-time.Duration(amount) * time.Minute), nil
+		case "hour":
+			return now.Add(-time.Duration(amount) * time.Hour), nil
+		case "day":
+			return now.AddDate(0, 0, -amount), nil
+		case "week":
+			return now.AddDate(0, 0, -7*amount), nil
+		case "month":
+			return now.AddDate(0, -amount, 0), nil
+		case "year":
+			return now.AddDate(-amount, 0, 0), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date format: %s", value)
+}
@@ -0,0 +1,228 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	wildMatch = iota
+	wildNoMatch
+	wildAbortAll
+	wildAbortToStarStar
+)
+
+// casamento de padrões como o wildmatch do git: "*" e "?" não atravessam "/",
+// e "**" entre barras (ou nas pontas) atravessa diretórios
+func Wildmatch(pattern string, text string, foldCase bool) bool {
+	return dowild(pattern, 0, text, foldCase) == wildMatch
+}
+
+func dowild(pattern string, pi int, t string, fold bool) int {
+	for ; pi < len(pattern); pi++ {
+		pc := pattern[pi]
+		if len(t) == 0 && pc != '*' {
+			return wildAbortAll
+		}
+
+		switch pc {
+		case '\\':
+			pi++
+			if pi == len(pattern) || !sameByte(pattern[pi], t[0], fold) {
+				return wildNoMatch
+			}
+		case '?':
+			if t[0] == '/' {
+				return wildNoMatch
+			}
+		case '*':
+			matchSlash := false
+			start := pi
+			for pi+1 < len(pattern) && pattern[pi+1] == '*' {
+				pi++
+			}
+			if pi > start {
+				// "**" só vale entre barras ou nas pontas; senão é um "*" comum
+				prevOk := start == 0 || pattern[start-1] == '/'
+				next := pattern[pi+1:]
+				if prevOk && (next == "" || next[0] == '/' || strings.HasPrefix(next, `\/`)) {
+					// "**/" também casa com nenhum diretório
+					if next != "" && next[0] == '/' && dowild(pattern, pi+2, t, fold) == wildMatch {
+						return wildMatch
+					}
+					matchSlash = true
+				}
+			}
+			pi++
+
+			if pi == len(pattern) {
+				if !matchSlash && strings.Contains(t, "/") {
+					return wildAbortToStarStar
+				}
+				return wildMatch
+			}
+			if !matchSlash && pattern[pi] == '/' {
+				slash := strings.IndexByte(t, '/')
+				if slash < 0 {
+					return wildAbortAll
+				}
+				// a barra é consumida no fim do laço
+				t = t[slash:]
+				break
+			}
+
+			for ; len(t) > 0; t = t[1:] {
+				if result := dowild(pattern, pi, t, fold); result != wildNoMatch {
+					if !matchSlash || result != wildAbortToStarStar {
+						return result
+					}
+				} else if !matchSlash && t[0] == '/' {
+					return wildAbortToStarStar
+				}
+			}
+			return wildAbortAll
+		case '[':
+			end, matched, ok := matchClass(pattern, pi+1, t[0], fold)
+			if !ok {
+				return wildAbortAll
+			}
+			if !matched || t[0] == '/' {
+				return wildNoMatch
+			}
+			pi = end
+		default:
+			if !sameByte(pc, t[0], fold) {
+				return wildNoMatch
+			}
+		}
+
+		t = t[1:]
+	}
+
+	if len(t) > 0 {
+		return wildNoMatch
+	}
+
+	return wildMatch
+}
+
+// avalia a classe que começa em pi (logo após o "["); devolve a posição do "]"
+func matchClass(pattern string, pi int, c byte, fold bool) (int, bool, bool) {
+	negated := false
+	if pi < len(pattern) && (pattern[pi] == '!' || pattern[pi] == '^') {
+		negated = true
+		pi++
+	}
+
+	matched := false
+	var prev byte
+	for first := true; ; first = false {
+		if pi >= len(pattern) {
+			return 0, false, false
+		}
+		pc := pattern[pi]
+		if pc == ']' && !first {
+			break
+		}
+
+		switch {
+		case pc == '\\':
+			pi++
+			if pi >= len(pattern) {
+				return 0, false, false
+			}
+			pc = pattern[pi]
+			if sameByte(pc, c, fold) {
+				matched = true
+			}
+		case pc == '-' && prev != 0 && pi+1 < len(pattern) && pattern[pi+1] != ']':
+			pi++
+			high := pattern[pi]
+			if high == '\\' {
+				pi++
+				if pi >= len(pattern) {
+					return 0, false, false
+				}
+				high = pattern[pi]
+			}
+			if prev <= c && c <= high {
+				matched = true
+			}
+			if fold && unicode.IsLetter(rune(c)) {
+				lower, upper := byte(unicode.ToLower(rune(c))), byte(unicode.ToUpper(rune(c)))
+				if (prev <= lower && lower <= high) || (prev <= upper && upper <= high) {
+					matched = true
+				}
+			}
+			pc = 0
+		case pc == '[' && pi+1 < len(pattern) && pattern[pi+1] == ':':
+			end := strings.Index(pattern[pi+2:], ":]")
+			if end < 0 {
+				// sem fechamento o "[" vale como caractere comum
+				if sameByte(pc, c, fold) {
+					matched = true
+				}
+				break
+			}
+			class := pattern[pi+2 : pi+2+end]
+			if !classMatches(class, c, fold) {
+				if !knownClass(class) {
+					return 0, false, false
+				}
+			} else {
+				matched = true
+			}
+			pi += end + 3
+			pc = 0
+		default:
+			if sameByte(pc, c, fold) {
+				matched = true
+			}
+		}
+
+		prev = pc
+		pi++
+	}
+
+	return pi, matched != negated, true
+}
+
+var characterClasses = map[string]func(rune) bool{
+	"alnum":  func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) },
+	"alpha":  unicode.IsLetter,
+	"blank":  func(r rune) bool { return r == ' ' || r == '\t' },
+	"cntrl":  unicode.IsControl,
+	"digit":  unicode.IsDigit,
+	"graph":  func(r rune) bool { return unicode.IsGraphic(r) && r != ' ' },
+	"lower":  unicode.IsLower,
+	"print":  unicode.IsPrint,
+	"punct":  unicode.IsPunct,
+	"space":  unicode.IsSpace,
+	"upper":  unicode.IsUpper,
+	"xdigit": func(r rune) bool { return strings.ContainsRune("0123456789abcdefABCDEF", r) },
+}
+
+func knownClass(class string) bool {
+	_, ok := characterClasses[class]
+	return ok
+}
+
+func classMatches(class string, c byte, fold bool) bool {
+	test, ok := characterClasses[class]
+	if !ok || c >= 0x80 {
+		return false
+	}
+	if fold && (class == "upper" || class == "lower") {
+		return unicode.IsLetter(rune(c))
+	}
+
+	return test(rune(c))
+}
+
+func sameByte(a byte, b byte, fold bool) bool {
+	if fold {
+		return unicode.ToLower(rune(a)) == unicode.ToLower(rune(b))
+	}
+
+	return a == b
+}